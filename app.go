@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"yt-downloader/internal/ffmpeg"
+	"yt-downloader/internal/library"
+	"yt-downloader/internal/queue"
 	"yt-downloader/internal/video"
 	"yt-downloader/internal/youtube"
 
@@ -19,16 +23,36 @@ import (
 
 // App struct
 type App struct {
-	ctx             context.Context
-	downloader      *youtube.Downloader
-	videoServer     *video.Server
-	ffmpegInstaller *ffmpeg.Installer
-	previewServer   *http.Server
-	previewListener net.Listener
-	previewBaseURL  string
-	previewErr      error
-	tempDir         string
-	currentVideoID  string
+	ctx              context.Context
+	downloader       *youtube.Downloader
+	videoServer      *video.Server
+	ffmpegInstaller  *ffmpeg.Installer
+	previewServer    *http.Server
+	previewListener  net.Listener
+	previewBaseURL   string
+	previewErr       error
+	tempDir          string
+	downloadsDir     string
+	thumbsDir        string
+	captionsDir      string
+	currentVideoID   string
+	currentOffset    float64 // Where the current preview starts in the source video
+	currentAudioOnly bool
+	currentChapters  []youtube.Chapter
+	currentSource    library.Provenance // Title, author and URL of the current preview, for clip tags
+	localVideoID     string             // ID of the file opened with LoadLocalFile whose preview is a converted copy of localOriginal
+	localOriginal    string
+	queue            *queue.Queue
+	toolsMu          sync.Mutex
+	captureMu        sync.Mutex
+	stopCapture      func() // Ends the running CaptureLive, if any
+
+	settingsMu   sync.Mutex
+	settingsPath string
+	settings     settings
+	cookiesPath  string // Imported cookies.txt, see ImportCookies
+	library      *library.Library
+	sourceCache  *youtube.SourceCache // Earlier preview downloads; nil if it couldn't be opened
 }
 
 // NewApp creates a new App application struct
@@ -46,7 +70,7 @@ func (a *App) startup(ctx context.Context) {
 	// Create temp directory for downloads
 	tempDir, err := os.MkdirTemp("", "yt-downloader-*")
 	if err != nil {
-		runtime.LogError(ctx, fmt.Sprintf("Failed to create temp directory: %v", err))
+		logError(ctx, fmt.Sprintf("Failed to create temp directory: %v", err))
 	} else {
 		a.tempDir = tempDir
 		a.videoServer.SetAllowedDir(tempDir)
@@ -57,7 +81,7 @@ func (a *App) startup(ctx context.Context) {
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		a.previewErr = err
-		runtime.LogError(ctx, fmt.Sprintf("Failed to start preview server: %v", err))
+		logError(ctx, fmt.Sprintf("Failed to start preview server: %v", err))
 	} else {
 		a.previewListener = ln
 		a.previewServer = &http.Server{Handler: a.videoServer}
@@ -70,14 +94,93 @@ func (a *App) startup(ctx context.Context) {
 	// Initialize FFmpeg installer
 	installer, err := ffmpeg.NewInstaller()
 	if err != nil {
-		runtime.LogError(ctx, fmt.Sprintf("Failed to initialize FFmpeg installer: %v", err))
+		logError(ctx, fmt.Sprintf("Failed to initialize FFmpeg installer: %v", err))
 	} else {
 		a.ffmpegInstaller = installer
 	}
+
+	dataDir, err := appDataDir()
+	if err != nil {
+		logError(ctx, fmt.Sprintf("Failed to locate app data directory: %v", err))
+		return
+	}
+
+	// Load settings and open the media library, if one has been chosen
+	a.settingsPath = filepath.Join(dataDir, "settings.json")
+	if s, err := loadSettings(a.settingsPath); err != nil {
+		logError(ctx, fmt.Sprintf("Failed to load settings: %v", err))
+	} else {
+		a.settings = s
+	}
+	if len(a.settings.Strategy) > 0 {
+		if err := a.downloader.SetStrategy(a.settings.Strategy); err != nil {
+			logError(ctx, fmt.Sprintf("Ignoring saved download strategy: %v", err))
+		}
+	}
+	if err := a.applyNetwork(a.settings.Network); err != nil {
+		logError(ctx, fmt.Sprintf("Ignoring saved network settings: %v", err))
+	}
+	a.downloader.SetRateLimit(a.settings.RateLimit)
+	a.cookiesPath = filepath.Join(dataDir, cookiesFileName)
+	a.loadCookies()
+	if a.settings.LibraryDir != "" {
+		if err := a.openLibrary(a.settings.LibraryDir); err != nil {
+			logError(ctx, fmt.Sprintf("Failed to open library: %v", err))
+		}
+	}
+
+	// Start the download queue, restoring jobs saved by a previous session
+	a.downloadsDir = filepath.Join(dataDir, "downloads")
+	if err := os.MkdirAll(a.downloadsDir, 0755); err != nil {
+		logError(ctx, fmt.Sprintf("Failed to create downloads directory: %v", err))
+	}
+	a.videoServer.AddAllowedDir(a.downloadsDir)
+	a.thumbsDir = filepath.Join(dataDir, "thumbnails")
+	a.videoServer.AddAllowedDir(a.thumbsDir)
+	a.captionsDir = filepath.Join(dataDir, "captions")
+
+	// Keep preview downloads so reloading a video doesn't download it again
+	if cache, err := youtube.OpenSourceCache(filepath.Join(dataDir, "sources"), youtube.DefaultCacheSize); err != nil {
+		logError(ctx, fmt.Sprintf("Failed to open download cache: %v", err))
+	} else {
+		a.sourceCache = cache
+		a.downloader.SetCache(cache)
+	}
+
+	q, err := queue.New(filepath.Join(dataDir, "queue.json"), a.runJob, func(job queue.Job) {
+		emitEvent(a.ctx, "queue:job", job)
+	})
+	if err != nil {
+		logError(ctx, fmt.Sprintf("Failed to load download queue: %v", err))
+		return
+	}
+	a.queue = q
+	// The queue's downloads and the one in the editor share the rate limit
+	a.downloader.SetDownloadSlots(q.Concurrency() + 1)
+	a.queue.OnPause(func(paused bool, resumeAt time.Time) {
+		status := map[string]interface{}{"paused": paused}
+		if paused && !resumeAt.IsZero() {
+			status["resumeAt"] = resumeAt.Format("15:04")
+		}
+		emitEvent(a.ctx, "queue:paused", status)
+	})
+	a.queue.Start(ctx)
+}
+
+// appDataDir returns the directory for state that must survive restarts
+func appDataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "yt-downloader"), nil
 }
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
+	if a.queue != nil {
+		a.queue.Stop()
+	}
 	if a.previewServer != nil {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		_ = a.previewServer.Shutdown(shutdownCtx)
@@ -103,85 +206,354 @@ type VideoInfo struct {
 	VideoURL     string  `json:"videoUrl"`
 	SourceWidth  int     `json:"sourceWidth"`
 	SourceHeight int     `json:"sourceHeight"`
+	Offset       float64 `json:"offset"`    // Where the preview file starts in the source video
+	TrimStart    float64 `json:"trimStart"` // Initial trim range within the preview file, 0/0 for the whole file
+	TrimEnd      float64 `json:"trimEnd"`
+	AudioOnly    bool    `json:"audioOnly"` // The preview has no video; show a waveform instead
+
+	// Chapters within the preview file, as offered for one-click clips
+	Chapters []youtube.Chapter `json:"chapters"`
+}
+
+// LoadVideo downloads a YouTube video and returns its info. formats picks specific
+// streams (see ListFormats); leave it zero to choose automatically. The quality
+// ceiling always comes from the settings. A timestamp in the URL (t=, or start= and
+// end= on embed links) presets the trim range.
+func (a *App) LoadVideo(url string, formats youtube.FormatOptions) (*VideoInfo, error) {
+	info, err := a.loadVideo(url, nil, formats)
+	if err != nil {
+		return nil, err
+	}
+	if parsed, err := youtube.ParseURL(url); err == nil {
+		presetTrim(info, parsed.Start, parsed.End)
+	}
+	return info, nil
+}
+
+// presetTrim sets the initial trim range to start-end in the source video, ending at
+// the end of the preview when end is 0. Ranges outside the preview are ignored.
+func presetTrim(info *VideoInfo, start float64, end float64) {
+	if start <= 0 && end <= 0 {
+		return
+	}
+	start -= info.Offset
+	if start < 0 {
+		start = 0
+	}
+	if end > 0 {
+		end -= info.Offset
+		if end <= 0 {
+			return
+		}
+	}
+	if end <= 0 || end > info.Duration {
+		end = info.Duration
+	}
+	if start >= end {
+		return
+	}
+	info.TrimStart = start
+	info.TrimEnd = end
 }
 
-// LoadVideo downloads a YouTube video and returns its info
-func (a *App) LoadVideo(url string) (*VideoInfo, error) {
+// LoadVideoSection downloads only start-end (in seconds) of a YouTube video, plus a
+// margin on each side for adjusting the trim, and returns its info with the trim
+// range preset to the requested section.
+func (a *App) LoadVideoSection(url string, start float64, end float64, formats youtube.FormatOptions) (*VideoInfo, error) {
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("invalid time range: %.3f-%.3f", start, end)
+	}
+	return a.loadVideo(url, &youtube.Section{Start: start, End: end}, formats)
+}
+
+// ListFormats returns every stream YouTube offers for a video, for choosing formats by hand
+func (a *App) ListFormats(url string) ([]youtube.FormatInfo, error) {
+	ffmpegPath, ytdlpPath := a.toolPaths("")
+	return a.downloader.ListFormats(a.ctx, url, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath})
+}
+
+// emitDownloadError tells the frontend why a video couldn't be loaded, with what each
+// source tried, so it can suggest what to do
+func (a *App) emitDownloadError(jobID string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	var attempts []youtube.Attempt
+	var dlErr *youtube.DownloadError
+	if errors.As(err, &dlErr) {
+		attempts = dlErr.Attempts
+	}
+	// A running livestream can be captured instead
+	var liveStatus youtube.LiveStatus
+	var liveErr *youtube.LiveError
+	if errors.As(err, &liveErr) {
+		liveStatus = liveErr.Status
+	}
+	emitEvent(a.ctx, "download:error", map[string]interface{}{
+		"jobId":      jobID,
+		"category":   youtube.CategoryOf(err),
+		"message":    err.Error(),
+		"attempts":   attempts,
+		"liveStatus": liveStatus,
+	})
+}
+
+// previewReady reports why videos can't be previewed, if they can't
+func (a *App) previewReady() error {
 	if a.previewBaseURL == "" {
 		if a.previewErr != nil {
-			return nil, fmt.Errorf("preview server failed to start: %w", a.previewErr)
+			return fmt.Errorf("preview server failed to start: %w", a.previewErr)
 		}
-		return nil, fmt.Errorf("preview server not available")
+		return fmt.Errorf("preview server not available")
 	}
+	return nil
+}
 
-	// Get video info first
-	info, err := a.downloader.GetVideoInfo(a.ctx, url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video info: %w", err)
+// downloadProgress returns a progress function that forwards reports to the frontend
+// as download:progress events for jobID
+func (a *App) downloadProgress(jobID string) youtube.ProgressFunc {
+	return func(p youtube.Progress) {
+		emitEvent(a.ctx, "download:progress", map[string]interface{}{
+			"jobId":           jobID,
+			"progress":        p.Fraction,
+			"stage":           p.Stage,
+			"downloadedBytes": p.DownloadedBytes,
+			"totalBytes":      p.TotalBytes,
+			"speed":           p.Speed,
+			"eta":             p.ETA,
+			"elapsed":         p.Elapsed,
+			"retry":           p.Retry,
+			"maxRetries":      p.MaxRetries,
+			"retryDelay":      p.RetryDelay,
+			"retryReason":     p.RetryReason,
+		})
 	}
+}
 
-	// Clear any previous video
-	a.videoServer.ClearVideo()
+func (a *App) loadVideo(url string, section *youtube.Section, formats youtube.FormatOptions) (*VideoInfo, error) {
+	if err := a.previewReady(); err != nil {
+		return nil, err
+	}
 
-	// Download video with progress updates
-	ffmpegPath := ""
-	if a.ffmpegInstaller != nil && a.ffmpegInstaller.IsInstalled() {
-		ffmpegPath = a.ffmpegInstaller.GetFFmpegPath()
+	// Videos already in the library open without touching the network, unless
+	// specific formats were asked for
+	if entry, ok := a.findInLibrary(url, section, formats.AudioOnly); ok && formats.VideoItag == 0 && formats.AudioItag == 0 {
+		return a.openLibraryEntry(entry, section)
 	}
-	ytdlpPath := ""
-	if a.ffmpegInstaller != nil {
-		ytdlpPath = a.ffmpegInstaller.GetYtdlpPath()
-		// Auto-download yt-dlp if not available (bundled version may fail due to Gatekeeper)
-		if ytdlpPath == "" {
-			runtime.LogInfo(a.ctx, "yt-dlp not found, attempting auto-download...")
-			runtime.EventsEmit(a.ctx, "download:status", "Installing yt-dlp for high-quality downloads...")
-			if err := a.ffmpegInstaller.InstallYtdlp(a.ctx); err != nil {
-				runtime.LogWarning(a.ctx, fmt.Sprintf("Failed to auto-install yt-dlp: %v", err))
-			} else {
-				ytdlpPath = a.ffmpegInstaller.GetYtdlpPath()
-			}
-		}
+	formats.Ceiling = a.GetQualityCeiling()
+
+	// Direct loads get their own job ID so events can be told apart from queued downloads
+	jobID := queue.NewID()
+
+	// A video downloaded before comes from the cache with the info it was
+	// resolved with, so reloading it needs no network at all
+	var info *youtube.VideoInfo
+	var dlResult *youtube.DownloadResult
+	cached := false
+	if section == nil {
+		info, dlResult, cached = a.downloader.CachedPreview(url, a.tempDir, formats)
 	}
-	runtime.LogInfo(a.ctx, fmt.Sprintf("Download paths: ffmpeg=%q yt-dlp=%q", ffmpegPath, ytdlpPath))
-	dlResult, err := a.downloader.DownloadForPreview(a.ctx, url, a.tempDir, ffmpegPath, ytdlpPath, func(progress float64) {
-		runtime.EventsEmit(a.ctx, "download:progress", progress)
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to download video: %w", err)
+
+	var err error
+	var padded youtube.Section
+	if !cached {
+		ffmpegPath, ytdlpPath := a.toolPaths(jobID)
+
+		// Get video info first
+		info, err = a.downloader.ResolveVideo(a.ctx, url, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath})
+		if err != nil {
+			a.emitDownloadError(jobID, err)
+			return nil, fmt.Errorf("failed to get video info: %w", err)
+		}
+		// Running livestreams have to be captured (see CaptureLive); upcoming ones can't be had yet
+		if err := info.Downloadable(); err != nil {
+			a.emitDownloadError(jobID, err)
+			return nil, err
+		}
+
+		// Clear any previous video
+		a.videoServer.ClearVideo()
+
+		// Download video with progress updates
+		progressFn := a.downloadProgress(jobID)
+		if section != nil {
+			padded = section.Pad(youtube.DefaultSectionMargin, info.Duration)
+			dlResult, err = a.downloader.DownloadSection(a.ctx, url, a.tempDir, ffmpegPath, ytdlpPath, padded, formats, progressFn)
+		} else {
+			dlResult, err = a.downloader.DownloadForPreview(a.ctx, url, info, a.tempDir, ffmpegPath, ytdlpPath, formats, progressFn)
+		}
+		if err != nil {
+			a.emitDownloadError(jobID, err)
+			return nil, fmt.Errorf("failed to download video: %w", err)
+		}
 	}
 
-	runtime.LogInfo(a.ctx, fmt.Sprintf("Download result: method=%s resolution=%dx%d", dlResult.Method, dlResult.Width, dlResult.Height))
+	logInfo(a.ctx, fmt.Sprintf("Download result: method=%s resolution=%dx%d", dlResult.Method, dlResult.Width, dlResult.Height))
 
 	// Warn the user if we fell back to a low-quality progressive stream
 	if dlResult.Method == "progressive" {
-		runtime.EventsEmit(a.ctx, "download:quality-warning", map[string]interface{}{
+		emitEvent(a.ctx, "download:quality-warning", map[string]interface{}{
+			"jobId":  jobID,
 			"method": dlResult.Method,
 			"width":  dlResult.Width,
 			"height": dlResult.Height,
 		})
 	}
 
+	// yt-dlp's chapter list also has chapters YouTube detected, not just the description's
+	if len(dlResult.Chapters) > 0 {
+		info.Chapters = dlResult.Chapters
+	}
+
+	// Keep the download in the library so it can be opened offline later
+	var downloaded *youtube.Section
+	if section != nil {
+		downloaded = &youtube.Section{Start: dlResult.Offset, End: dlResult.Offset + padded.Duration()}
+	}
+	videoPath := a.addToLibrary(*info, url, dlResult, downloaded)
+
 	// Set up video server
 	a.currentVideoID = info.ID
-	a.videoServer.SetCurrentVideo(dlResult.FilePath, info.ID)
+	a.currentOffset = dlResult.Offset
+	a.currentAudioOnly = dlResult.AudioOnly
+	a.setCurrentSource(info, url)
+	a.videoServer.SetCurrentVideo(videoPath, info.ID)
 
-	runtime.EventsEmit(a.ctx, "download:complete", nil)
+	emitEvent(a.ctx, "download:complete", map[string]interface{}{
+		"jobId": jobID,
+	})
 
-	return &VideoInfo{
+	result := &VideoInfo{
 		ID:           info.ID,
 		Title:        info.Title,
 		Author:       info.Author,
 		Duration:     info.Duration,
-		Thumbnail:    info.Thumbnail,
+		Thumbnail:    a.cacheThumbnail(info, videoPath),
 		VideoURL:     a.previewBaseURL + a.videoServer.GetCurrentVideoURL(),
 		SourceWidth:  info.SourceWidth,
 		SourceHeight: info.SourceHeight,
-	}, nil
+		Offset:       dlResult.Offset,
+		AudioOnly:    dlResult.AudioOnly,
+	}
+	if section != nil {
+		// The preview only covers the padded section
+		result.Duration = padded.Duration()
+		result.TrimStart = section.Start - dlResult.Offset
+		result.TrimEnd = section.End - dlResult.Offset
+		if result.TrimEnd > result.Duration {
+			result.TrimEnd = result.Duration
+		}
+	}
+	result.Chapters = a.setCurrentChapters(info.Chapters, result.Offset, result.Duration)
+	return result, nil
+}
+
+// toolPaths locates ffmpeg and yt-dlp, installing yt-dlp on first use
+func (a *App) toolPaths(jobID string) (string, string) {
+	// Serialize so concurrent downloads don't race to install yt-dlp
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	ffmpegPath := ""
+	if a.ffmpegInstaller != nil && a.ffmpegInstaller.IsInstalled() {
+		ffmpegPath = a.ffmpegInstaller.GetFFmpegPath()
+	}
+	ytdlpPath := ""
+	if a.ffmpegInstaller != nil {
+		ytdlpPath = a.ffmpegInstaller.GetYtdlpPath()
+		// Auto-download yt-dlp if not available (bundled version may fail due to Gatekeeper)
+		if ytdlpPath == "" {
+			logInfo(a.ctx, "yt-dlp not found, attempting auto-download...")
+			emitEvent(a.ctx, "download:status", map[string]interface{}{
+				"jobId":  jobID,
+				"status": "Installing yt-dlp for high-quality downloads...",
+			})
+			if err := a.ffmpegInstaller.InstallYtdlp(a.ctx); err != nil {
+				logWarning(a.ctx, fmt.Sprintf("Failed to auto-install yt-dlp: %v", err))
+			} else {
+				ytdlpPath = a.ffmpegInstaller.GetYtdlpPath()
+			}
+		}
+	}
+	logInfo(a.ctx, fmt.Sprintf("Download paths: ffmpeg=%q yt-dlp=%q", ffmpegPath, ytdlpPath))
+	return ffmpegPath, ytdlpPath
 }
 
-// GetVideoInfo gets video metadata without downloading
+// GetVideoInfo gets video metadata without downloading, from the sources loading
+// would use, so yt-dlp's live status and premiere times are reported too. Unlike a
+// download, it only uses tools that are already installed.
 func (a *App) GetVideoInfo(url string) (*youtube.VideoInfo, error) {
-	return a.downloader.GetVideoInfo(a.ctx, url)
+	var tools youtube.Tools
+	if a.ffmpegInstaller != nil {
+		if a.ffmpegInstaller.IsInstalled() {
+			tools.FFmpegPath = a.ffmpegInstaller.GetFFmpegPath()
+		}
+		tools.YtdlpPath = a.ffmpegInstaller.GetYtdlpPath()
+	}
+	return a.downloader.ResolveVideo(a.ctx, url, tools)
+}
+
+// GetDownloadWorkers returns how many parallel connections are used per stream
+func (a *App) GetDownloadWorkers() int {
+	return a.downloader.ChunkWorkers()
+}
+
+// SetDownloadWorkers sets how many parallel connections are used per stream
+func (a *App) SetDownloadWorkers(n int) error {
+	if n < 1 || n > 16 {
+		return fmt.Errorf("download workers must be between 1 and 16")
+	}
+	a.downloader.SetChunkWorkers(n)
+	return nil
+}
+
+// GetSourceStrategy returns the order download sources are tried in
+func (a *App) GetSourceStrategy() []string {
+	return a.downloader.Strategy()
+}
+
+// SetSourceStrategy sets which download sources are used and in what order, e.g.
+// ["youtube", "yt-dlp", "local"] to try the built-in downloader before yt-dlp
+func (a *App) SetSourceStrategy(names []string) error {
+	if err := a.downloader.SetStrategy(names); err != nil {
+		return err
+	}
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings.Strategy = append([]string(nil), names...)
+	return a.saveSettings()
+}
+
+// GetQualityCeiling returns the limits applied when choosing download formats automatically
+func (a *App) GetQualityCeiling() youtube.QualityCeiling {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	return a.settings.QualityCeiling
+}
+
+// SetQualityCeiling limits the resolution, frame rate and codec of future downloads
+func (a *App) SetQualityCeiling(ceiling youtube.QualityCeiling) error {
+	if err := ceiling.Validate(); err != nil {
+		return err
+	}
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings.QualityCeiling = ceiling
+	return a.saveSettings()
+}
+
+// ExpandPlaylist lists the videos of a playlist or channel URL so the user can pick which to load
+func (a *App) ExpandPlaylist(url string) ([]youtube.VideoInfo, error) {
+	entries, err := a.downloader.ExpandPlaylist(a.ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand playlist: %w", err)
+	}
+	return entries, nil
+}
+
+// IsPlaylistURL reports whether a URL points at a playlist or channel instead of a single video
+func (a *App) IsPlaylistURL(url string) bool {
+	return youtube.IsPlaylistURL(url)
 }
 
 // SelectOutputDirectory opens a native directory picker
@@ -204,6 +576,12 @@ type ExportOptions struct {
 	OutputDir     string  `json:"outputDir"`
 	QualityPreset string  `json:"qualityPreset"` // "high", "medium", "low"
 	MaxResolution string  `json:"maxResolution"` // "original", "1080p", "720p", "480p", "360p"
+	Captions      string  `json:"captions"`      // Caption language to write as .srt/.vtt next to the clip, "" for none
+
+	// Audio-only export: "m4a", "mp3", "opus", "flac" or "wav"; "" exports video (.mp4)
+	AudioFormat  string `json:"audioFormat"`
+	AudioBitrate string `json:"audioBitrate"` // e.g. "192k"; "" follows the quality preset
+	SampleRate   int    `json:"sampleRate"`   // in Hz; 0 keeps the source rate
 }
 
 func sanitizeFilename(name string) string {
@@ -228,14 +606,28 @@ func sanitizeFilename(name string) string {
 
 // ExportClip trims and saves a video clip
 func (a *App) ExportClip(opts ExportOptions) error {
+	outputPath, err := a.exportClip(opts, func(progress float64) {
+		emitEvent(a.ctx, "export:progress", progress)
+	})
+	if err != nil {
+		return err
+	}
+
+	emitEvent(a.ctx, "export:complete", outputPath)
+	return nil
+}
+
+// exportClip trims the current preview to a clip as described by opts and returns
+// the path it was saved to
+func (a *App) exportClip(opts ExportOptions, progressFn func(float64)) (string, error) {
 	if a.ffmpegInstaller == nil || !a.ffmpegInstaller.IsInstalled() {
-		return fmt.Errorf("FFmpeg is not installed")
+		return "", fmt.Errorf("FFmpeg is not installed")
 	}
 
 	// Get current video path from server
-	inputPath := a.videoServer.GetCurrentVideoPath()
+	inputPath := a.exportSource()
 	if inputPath == "" {
-		return fmt.Errorf("no video loaded")
+		return "", fmt.Errorf("no video loaded")
 	}
 
 	// Build output path
@@ -244,11 +636,23 @@ func (a *App) ExportClip(opts ExportOptions) error {
 		filename = "clip"
 	}
 	if !filepath.IsAbs(opts.OutputDir) {
-		return fmt.Errorf("output directory must be an absolute path")
+		return "", fmt.Errorf("output directory must be an absolute path")
+	}
+	audioFormat := ffmpeg.AudioFormat(opts.AudioFormat)
+	if audioFormat == "" && a.currentAudioOnly {
+		// There is no video to export
+		audioFormat = ffmpeg.AudioM4A
+	}
+	if audioFormat != "" && opts.RemoveAudio {
+		return "", fmt.Errorf("an audio export can't remove the audio")
+	}
+	ext := ".mp4"
+	if audioFormat != "" {
+		ext = audioFormat.Extension()
 	}
 	outputName := filename
-	if !strings.EqualFold(filepath.Ext(outputName), ".mp4") {
-		outputName += ".mp4"
+	if !strings.EqualFold(filepath.Ext(outputName), ext) {
+		outputName += ext
 	}
 	outputPath := filepath.Join(opts.OutputDir, outputName)
 
@@ -261,6 +665,7 @@ func (a *App) ExportClip(opts ExportOptions) error {
 		StartTime:   opts.StartTime,
 		EndTime:     opts.EndTime,
 		RemoveAudio: opts.RemoveAudio,
+		Metadata:    a.clipTags(opts.StartTime, opts.EndTime),
 	}
 
 	// Quality preset controls encoding quality (CRF & preset)
@@ -294,16 +699,57 @@ func (a *App) ExportClip(opts ExportOptions) error {
 	}
 
 	// Export with progress
-	err := processor.TrimVideoWithProgress(a.ctx, trimOpts, func(progress float64) {
-		runtime.EventsEmit(a.ctx, "export:progress", progress)
-	})
+	var err error
+	if audioFormat != "" {
+		audioBitrate := opts.AudioBitrate
+		if audioBitrate == "" {
+			audioBitrate = trimOpts.AudioBitrate
+		}
+		err = processor.ExtractAudioWithProgress(a.ctx, ffmpeg.AudioOptions{
+			InputPath:  inputPath,
+			OutputPath: outputPath,
+			StartTime:  opts.StartTime,
+			EndTime:    opts.EndTime,
+			Format:     audioFormat,
+			Bitrate:    audioBitrate,
+			SampleRate: opts.SampleRate,
+			Metadata:   trimOpts.Metadata,
+		}, progressFn)
+	} else {
+		err = processor.TrimVideoWithProgress(a.ctx, trimOpts, progressFn)
+	}
 
 	if err != nil {
-		return fmt.Errorf("failed to export clip: %w", err)
+		return "", fmt.Errorf("failed to export clip: %w", err)
 	}
 
-	runtime.EventsEmit(a.ctx, "export:complete", outputPath)
-	return nil
+	a.addClipToLibrary(outputPath, opts.StartTime, opts.EndTime)
+
+	if opts.Captions != "" {
+		if err := a.writeClipCaptions(opts.Captions, outputPath, opts.StartTime, opts.EndTime); err != nil {
+			logWarning(a.ctx, fmt.Sprintf("Failed to write captions: %v", err))
+			emitEvent(a.ctx, "export:warning", fmt.Sprintf("Clip exported without captions: %v", err))
+		}
+	}
+
+	return outputPath, nil
+}
+
+// GetWaveform returns the audio peaks (0-1) of the current preview in buckets slices,
+// for drawing audio-only previews
+func (a *App) GetWaveform(buckets int) ([]float64, error) {
+	if a.ffmpegInstaller == nil || !a.ffmpegInstaller.IsInstalled() {
+		return nil, fmt.Errorf("FFmpeg is not installed")
+	}
+	inputPath := a.videoServer.GetCurrentVideoPath()
+	if inputPath == "" {
+		return nil, fmt.Errorf("no video loaded")
+	}
+	if buckets < 1 || buckets > 10000 {
+		return nil, fmt.Errorf("buckets must be between 1 and 10000")
+	}
+	processor := ffmpeg.NewProcessor(a.ffmpegInstaller.GetFFmpegPath())
+	return processor.Waveform(a.ctx, inputPath, buckets)
 }
 
 // CheckFFmpeg checks if FFmpeg is installed
@@ -321,7 +767,7 @@ func (a *App) InstallFFmpeg() error {
 	}
 
 	return a.ffmpegInstaller.Install(a.ctx, func(progress float64, status string) {
-		runtime.EventsEmit(a.ctx, "ffmpeg:progress", map[string]interface{}{
+		emitEvent(a.ctx, "ffmpeg:progress", map[string]interface{}{
 			"progress": progress,
 			"status":   status,
 		})
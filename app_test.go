@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yt-downloader/internal/network"
+	"yt-downloader/internal/queue"
+	"yt-downloader/internal/youtube"
+	"yt-downloader/internal/youtube/youtubetest"
+)
+
+const testVideoURL = "https://youtu.be/dQw4w9WgXcQ?t=1"
+
+// eventLog records what the App emits in place of the Wails runtime
+type eventLog struct {
+	mu     sync.Mutex
+	events map[string][]interface{}
+}
+
+func (l *eventLog) emit(ctx context.Context, name string, data ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var payload interface{}
+	if len(data) > 0 {
+		payload = data[0]
+	}
+	l.events[name] = append(l.events[name], payload)
+}
+
+func (l *eventLog) get(name string) []interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]interface{}(nil), l.events[name]...)
+}
+
+// requireFFmpeg skips the test unless ffmpeg can encode the H.264/AAC fixture
+func requireFFmpeg(t *testing.T) string {
+	t.Helper()
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+	return path
+}
+
+// makeFixture encodes a small H.264/AAC MP4 from ffmpeg's test sources: 4 seconds of
+// 320x240 color bars with a tone
+func makeFixture(t *testing.T, ffmpegPath string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.mp4")
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=size=320x240:rate=25:duration=4",
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=4",
+		"-c:v", "libx264", "-pix_fmt", "yuv420p", "-c:a", "aac", "-shortest",
+		"-movflags", "+faststart", path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg can't encode the fixture: %v: %s", err, out)
+	}
+	return path
+}
+
+// startTestApp starts an App whose home directory, and so its settings, library and
+// caches, is a temporary directory, with source as its only download source
+func startTestApp(t *testing.T, source youtube.Source) (*App, *eventLog) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A yt-dlp that is never run, so the App doesn't try to install one
+	binDir := filepath.Join(home, ".cache", "yt-downloader", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "yt-dlp"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	events := &eventLog{events: make(map[string][]interface{})}
+	logf := func(ctx context.Context, message string) { t.Log(message) }
+	savedEmit, savedInfo, savedWarning, savedError := emitEvent, logInfo, logWarning, logError
+	emitEvent, logInfo, logWarning, logError = events.emit, logf, logf, logf
+	t.Cleanup(func() {
+		emitEvent, logInfo, logWarning, logError = savedEmit, savedInfo, savedWarning, savedError
+	})
+
+	app := NewApp()
+	app.downloader.AddSource(source)
+	if err := app.downloader.SetStrategy([]string{source.Name()}); err != nil {
+		t.Fatal(err)
+	}
+	app.startup(context.Background())
+	t.Cleanup(func() { app.shutdown(context.Background()) })
+
+	// Nothing may reach the network: every request goes to a proxy that refuses it
+	offline := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "offline", http.StatusBadGateway)
+	}))
+	t.Cleanup(offline.Close)
+	if err := app.downloader.SetNetwork(network.Settings{ProxyURL: offline.URL}); err != nil {
+		t.Fatal(err)
+	}
+	return app, events
+}
+
+// checkPreview checks that the preview server plays the file at path for info
+func checkPreview(t *testing.T, info *VideoInfo, path string) {
+	t.Helper()
+	resp, err := http.Get(info.VideoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	preview, _ := io.ReadAll(resp.Body)
+	want, _ := os.ReadFile(path)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(preview, want) {
+		t.Fatalf("preview server returned %d with %d bytes, want the %d-byte fixture", resp.StatusCode, len(preview), len(want))
+	}
+}
+
+// Loading a plain download doesn't need ffmpeg, so any file will do as the video
+func TestLoadVideo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.mp4")
+	if err := os.WriteFile(path, []byte("not really a video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := &youtubetest.Source{Path: path, Duration: 4, Width: 320, Height: 240}
+	app, events := startTestApp(t, source)
+
+	info, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "dQw4w9WgXcQ" || info.Title != "Test video dQw4w9WgXcQ" || info.Duration != 4 {
+		t.Errorf("LoadVideo = %+v", info)
+	}
+	if info.TrimStart != 1 || info.TrimEnd != 4 {
+		t.Errorf("trim range = %v-%v, want 1-4 from the t= in the URL", info.TrimStart, info.TrimEnd)
+	}
+	if len(events.get("download:complete")) != 1 {
+		t.Errorf("download:complete emitted %d times, want 1", len(events.get("download:complete")))
+	}
+	checkPreview(t, info, path)
+}
+
+// A video loaded before comes back from the download cache without the source
+// being asked for anything, so it opens offline
+func TestLoadVideoFromCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.mp4")
+	if err := os.WriteFile(path, []byte("not really a video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := &youtubetest.Source{Path: path, Duration: 4}
+	app, events := startTestApp(t, source)
+
+	if _, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	source.Err = fmt.Errorf("offline")
+	info, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if info.Title != "Test video dQw4w9WgXcQ" || info.Duration != 4 || info.TrimStart != 1 {
+		t.Errorf("reload = %+v", info)
+	}
+	if len(events.get("download:complete")) != 2 {
+		t.Errorf("download:complete emitted %d times, want 2", len(events.get("download:complete")))
+	}
+	checkPreview(t, info, path)
+
+	if size, err := app.GetDownloadCacheSize(); err != nil || size == 0 {
+		t.Fatalf("GetDownloadCacheSize = %d, %v", size, err)
+	}
+	if err := app.ClearDownloadCache(); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := app.GetDownloadCacheSize(); size != 0 {
+		t.Errorf("GetDownloadCacheSize after clearing = %d", size)
+	}
+	if _, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{}); err == nil {
+		t.Error("LoadVideo succeeded offline after the cache was cleared")
+	}
+}
+
+func TestLoadVideoAndExportClip(t *testing.T) {
+	ffmpegPath := requireFFmpeg(t)
+	source := &youtubetest.Source{
+		Path:     makeFixture(t, ffmpegPath),
+		Title:    "Fixture video",
+		Author:   "Test Channel",
+		Duration: 4,
+		Width:    320,
+		Height:   240,
+	}
+	app, events := startTestApp(t, source)
+
+	info, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "dQw4w9WgXcQ" || info.Title != "Fixture video" || info.Author != "Test Channel" {
+		t.Errorf("LoadVideo = %+v", info)
+	}
+	if info.TrimStart != 1 {
+		t.Errorf("TrimStart = %v, want 1 from the t= in the URL", info.TrimStart)
+	}
+	if len(events.get("download:complete")) != 1 {
+		t.Errorf("download:complete emitted %d times, want 1", len(events.get("download:complete")))
+	}
+
+	checkPreview(t, info, source.Path)
+
+	outDir := t.TempDir()
+	err = app.ExportClip(ExportOptions{
+		StartTime:     1,
+		EndTime:       3,
+		Filename:      "my clip",
+		OutputDir:     outDir,
+		QualityPreset: "low",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clipPath := filepath.Join(outDir, "my clip.mp4")
+	if got := events.get("export:complete"); len(got) != 1 || got[0] != clipPath {
+		t.Errorf("export:complete = %v, want %s", got, clipPath)
+	}
+
+	probe, err := youtube.ProbeMedia(context.Background(), ffmpegPath, clipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.VideoCodec != "h264" || probe.AudioCodec != "aac" || probe.Duration < 1.8 || probe.Duration > 2.2 {
+		t.Errorf("clip is %s/%s, %.2fs; want a 2s H.264/AAC clip", probe.VideoCodec, probe.AudioCodec, probe.Duration)
+	}
+
+	// The clip records where it came from
+	clipSource, err := app.ReadClipProvenance(clipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := clipSource.Provenance; p.VideoID != "dQw4w9WgXcQ" || p.Title != "Fixture video" || p.ClipStart != 1 || p.ClipEnd != 3 {
+		t.Errorf("ReadClipProvenance = %+v", clipSource.Provenance)
+	}
+	if want := "https://www.youtube.com/watch?v=dQw4w9WgXcQ&start=1&end=3"; clipSource.URL != want {
+		t.Errorf("clip URL = %s, want %s", clipSource.URL, want)
+	}
+}
+
+// Opening a converted local file and then a YouTube video whose download lands on the
+// same preview path must export from the YouTube video, not the old local file
+func TestExportAfterLocalFile(t *testing.T) {
+	ffmpegPath := requireFFmpeg(t)
+	source := &youtubetest.Source{Path: makeFixture(t, ffmpegPath), Duration: 4, Width: 320, Height: 240}
+	app, _ := startTestApp(t, source)
+
+	// Named so its preview copy is where the fake source puts its download
+	localPath := filepath.Join(t.TempDir(), "dQw4w9WgXcQ.mkv")
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=size=160x120:rate=25:duration=4",
+		"-c:v", "libx264", "-pix_fmt", "yuv420p", localPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg can't encode the local file: %v: %s", err, out)
+	}
+	if _, err := app.LoadLocalFile(localPath); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.exportSource(); got != localPath {
+		t.Fatalf("export source for the local file = %s, want the original", got)
+	}
+
+	if _, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	if err := app.ExportClip(ExportOptions{StartTime: 1, EndTime: 3, Filename: "clip", OutputDir: outDir, QualityPreset: "low"}); err != nil {
+		t.Fatal(err)
+	}
+	probe, err := youtube.ProbeMedia(context.Background(), ffmpegPath, filepath.Join(outDir, "clip.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.Width != 320 || probe.AudioCodec == "" {
+		t.Errorf("clip is %dx%d with audio %q; want it cut from the 320x240 YouTube fixture", probe.Width, probe.Height, probe.AudioCodec)
+	}
+}
+
+// GetVideoInfo goes through the configured sources with their tools, so what only
+// yt-dlp knows about a premiere reaches the frontend
+func TestGetVideoInfoUsesYtdlp(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake yt-dlp is a shell script")
+	}
+	app, _ := startTestApp(t, &youtubetest.Source{Err: fmt.Errorf("not this one")})
+	script := "#!/bin/sh\n" +
+		"echo '{\"id\": \"dQw4w9WgXcQ\", \"title\": \"Premiere\", \"live_status\": \"is_upcoming\", \"release_timestamp\": 1900000000}'\n"
+	ytdlpPath := filepath.Join(os.Getenv("HOME"), ".cache", "yt-downloader", "bin", "yt-dlp")
+	if err := os.WriteFile(ytdlpPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.downloader.SetStrategy([]string{youtube.SourceYtdlp}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := app.GetVideoInfo(testVideoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Premiere" || info.LiveStatus != youtube.LiveStatusUpcoming || info.ScheduledStart != 1900000000 {
+		t.Errorf("GetVideoInfo = %+v, want yt-dlp's upcoming premiere", info)
+	}
+}
+
+func TestGetVideoInfoDoesNotInstallYtdlp(t *testing.T) {
+	app, events := startTestApp(t, &youtubetest.Source{Title: "Metadata only"})
+	ytdlpPath := filepath.Join(os.Getenv("HOME"), ".cache", "yt-downloader", "bin", "yt-dlp")
+	if err := os.Remove(ytdlpPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	info, err := app.GetVideoInfo(testVideoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Metadata only" {
+		t.Errorf("GetVideoInfo = %+v, want the test source's video", info)
+	}
+	if status := events.get("download:status"); len(status) != 0 {
+		t.Errorf("download:status events for a metadata query: %v", status)
+	}
+	if _, err := os.Stat(ytdlpPath); !os.IsNotExist(err) {
+		t.Errorf("yt-dlp was installed (stat error %v)", err)
+	}
+}
+
+func TestLoadVideoSourceFailure(t *testing.T) {
+	app, events := startTestApp(t, &youtubetest.Source{Err: fmt.Errorf("video unavailable")})
+
+	if _, err := app.LoadVideo(testVideoURL, youtube.FormatOptions{}); err == nil || !strings.Contains(err.Error(), "video unavailable") {
+		t.Fatalf("LoadVideo error = %v, want the source's error", err)
+	}
+	if len(events.get("download:error")) == 0 {
+		t.Error("no download:error event")
+	}
+	if len(events.get("download:complete")) != 0 {
+		t.Error("download:complete emitted for a failed load")
+	}
+}
+
+// resolveCounter is a fake source that counts how often metadata is asked for
+type resolveCounter struct {
+	*youtubetest.Source
+	mu       sync.Mutex
+	resolves int
+}
+
+func (s *resolveCounter) Resolve(ctx context.Context, url string, tools youtube.Tools) (*youtube.VideoInfo, error) {
+	s.mu.Lock()
+	s.resolves++
+	s.mu.Unlock()
+	return s.Source.Resolve(ctx, url, tools)
+}
+
+func (s *resolveCounter) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resolves
+}
+
+// waitForJob waits for a queued job to finish, failing the test if it doesn't
+func waitForJob(t *testing.T, app *App, id string) queue.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := app.queue.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == queue.StateDone || job.State == queue.StateFailed {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("the job never finished")
+	return queue.Job{}
+}
+
+// A queued download resolves the video once, and its info goes into the download
+// cache so the same video downloads again without the source
+func TestQueuedDownloadResolvesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.mp4")
+	if err := os.WriteFile(path, []byte("not really a video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := &resolveCounter{Source: &youtubetest.Source{Path: path, Duration: 4}}
+	app, _ := startTestApp(t, source)
+
+	jobs, err := app.EnqueueVideos([]QueueItem{{URL: testVideoURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job := waitForJob(t, app, jobs[0].ID); job.State != queue.StateDone {
+		t.Fatalf("job %s: %s", job.State, job.Error)
+	}
+	if n := source.count(); n != 1 {
+		t.Errorf("the video was resolved %d times, want once", n)
+	}
+
+	source.Err = fmt.Errorf("offline")
+	jobs, err = app.EnqueueVideos([]QueueItem{{URL: testVideoURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := waitForJob(t, app, jobs[0].ID)
+	if job.State != queue.StateDone {
+		t.Fatalf("second job %s: %s", job.State, job.Error)
+	}
+	if data, _ := os.ReadFile(job.Result.FilePath); string(data) != "not really a video" {
+		t.Errorf("second download has %q", data)
+	}
+	if n := source.count(); n != 1 {
+		t.Errorf("the cached video was resolved again (%d times in all)", n)
+	}
+}
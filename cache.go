@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+)
+
+// GetDownloadCacheSize returns how many bytes the cached preview downloads take up
+func (a *App) GetDownloadCacheSize() (int64, error) {
+	if a.sourceCache == nil {
+		return 0, nil
+	}
+	return a.sourceCache.Size()
+}
+
+// ClearDownloadCache removes every cached preview download, so the next load of any
+// video downloads it again. The library and the current preview are left alone.
+func (a *App) ClearDownloadCache() error {
+	if a.sourceCache == nil {
+		return fmt.Errorf("download cache not available")
+	}
+	if err := a.sourceCache.Clear(); err != nil {
+		return err
+	}
+	logInfo(a.ctx, "Cleared the download cache")
+	return nil
+}
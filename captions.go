@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"yt-downloader/internal/captions"
+	"yt-downloader/internal/youtube"
+)
+
+// currentVideoURL returns a watch URL for the video in the editor
+func (a *App) currentVideoURL() (string, error) {
+	if a.currentVideoID == "" {
+		return "", fmt.Errorf("no video loaded")
+	}
+	if youtube.IsLocalID(a.currentVideoID) {
+		return "", fmt.Errorf("local files have no YouTube captions")
+	}
+	return "https://www.youtube.com/watch?v=" + a.currentVideoID, nil
+}
+
+// ListCaptions returns the caption tracks of the video in the editor. When YouTube
+// can't be reached, the tracks already saved locally are listed instead.
+func (a *App) ListCaptions() ([]youtube.CaptionTrack, error) {
+	if youtube.IsLocalID(a.currentVideoID) {
+		return []youtube.CaptionTrack{}, nil
+	}
+	url, err := a.currentVideoURL()
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := a.downloader.ListCaptions(a.ctx, url)
+	if err == nil {
+		return tracks, nil
+	}
+
+	local := a.localCaptionLanguages(a.currentVideoID)
+	if len(local) == 0 {
+		return nil, fmt.Errorf("failed to list captions: %w", err)
+	}
+	tracks = make([]youtube.CaptionTrack, 0, len(local))
+	for _, lang := range local {
+		tracks = append(tracks, youtube.CaptionTrack{Language: lang, Name: lang})
+	}
+	return tracks, nil
+}
+
+// DownloadCaptions saves the captions of the video in the editor so clips can be
+// exported with them later, even offline
+func (a *App) DownloadCaptions(language string) error {
+	_, err := a.captionFile(a.currentVideoID, language)
+	return err
+}
+
+// captionFile returns a local WebVTT file for a video's captions in language,
+// downloading it if it isn't in the library or cache yet
+func (a *App) captionFile(videoID string, language string) (string, error) {
+	if videoID == "" {
+		return "", fmt.Errorf("no video loaded")
+	}
+	if language == "" || strings.ContainsAny(language, `/\`) {
+		return "", fmt.Errorf("invalid caption language %q", language)
+	}
+	if path := a.findCaptionFile(videoID, language); path != "" {
+		return path, nil
+	}
+	if a.captionsDir == "" {
+		return "", fmt.Errorf("captions directory not available")
+	}
+
+	_, ytdlpPath := a.toolPaths("")
+	url := "https://www.youtube.com/watch?v=" + videoID
+	path, track, err := a.downloader.DownloadCaptions(a.ctx, url, language, a.captionsDir, ytdlpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to download captions: %w", err)
+	}
+	logInfo(a.ctx, fmt.Sprintf("Downloaded %s captions for %s (auto=%v)", track.Language, videoID, track.Auto))
+
+	// Keep a copy with the library entry so the captions are available offline
+	if lib := a.getLibrary(); lib != nil {
+		if entry, err := lib.SetCaptions(videoID, language, path); err == nil {
+			path = lib.Path(entry.Captions[language])
+		}
+	}
+	return path, nil
+}
+
+// findCaptionFile returns an existing local caption file, from the library or the cache
+func (a *App) findCaptionFile(videoID string, language string) string {
+	if lib := a.getLibrary(); lib != nil {
+		if entry, err := lib.Get(videoID); err == nil && entry.Captions[language] != "" {
+			return lib.Path(entry.Captions[language])
+		}
+	}
+	if a.captionsDir == "" {
+		return ""
+	}
+	path := filepath.Join(a.captionsDir, youtube.CaptionFileName(videoID, language))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// localCaptionLanguages lists the caption languages saved locally for a video
+func (a *App) localCaptionLanguages(videoID string) []string {
+	seen := make(map[string]bool)
+	if lib := a.getLibrary(); lib != nil {
+		if entry, err := lib.Get(videoID); err == nil {
+			for lang := range entry.Captions {
+				seen[lang] = true
+			}
+		}
+	}
+	if a.captionsDir != "" {
+		matches, _ := filepath.Glob(filepath.Join(a.captionsDir, videoID+".*.vtt"))
+		for _, match := range matches {
+			lang := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), videoID+"."), ".vtt")
+			seen[lang] = true
+		}
+	}
+	langs := make([]string, 0, len(seen))
+	for lang := range seen {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// writeClipCaptions writes the captions for start-end of the current preview next to
+// a clip as .srt and .vtt, re-timed to start at zero
+func (a *App) writeClipCaptions(language string, clipPath string, start float64, end float64) error {
+	path, err := a.captionFile(a.currentVideoID, language)
+	if err != nil {
+		return err
+	}
+	cues, err := captions.ReadVTT(path)
+	if err != nil {
+		return fmt.Errorf("failed to read captions: %w", err)
+	}
+
+	// Caption times are relative to the full video, the trim range to the preview file
+	clipped := captions.Clip(cues, start+a.currentOffset, end+a.currentOffset)
+	if len(clipped) == 0 {
+		return fmt.Errorf("no %s captions in the clip range", language)
+	}
+	return captions.WriteSidecars(strings.TrimSuffix(clipPath, filepath.Ext(clipPath)), clipped)
+}
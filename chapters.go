@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"yt-downloader/internal/youtube"
+)
+
+// setCurrentChapters makes chapters (in source video time) the chapters of the current
+// preview, which starts at offset and runs for duration, and returns them in preview
+// time. Chapters outside a section preview are dropped and ones at its edges are cut.
+func (a *App) setCurrentChapters(chapters []youtube.Chapter, offset float64, duration float64) []youtube.Chapter {
+	current := make([]youtube.Chapter, 0, len(chapters))
+	for _, c := range chapters {
+		c.Start -= offset
+		c.End -= offset
+		if c.End <= 0 || c.Start >= duration {
+			continue
+		}
+		if c.Start < 0 {
+			c.Start = 0
+		}
+		if c.End > duration {
+			c.End = duration
+		}
+		current = append(current, c)
+	}
+	a.currentChapters = current
+	return current
+}
+
+// ExportChapters exports chapters of the current video (indices into VideoInfo.Chapters)
+// as separate clips named "<filename> - <number> - <chapter title>". The other export
+// settings are taken from opts. Returns the paths of the clips written.
+func (a *App) ExportChapters(indices []int, opts ExportOptions) ([]string, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no chapters selected")
+	}
+	chapters := a.currentChapters
+	for _, i := range indices {
+		if i < 0 || i >= len(chapters) {
+			return nil, fmt.Errorf("invalid chapter %d", i)
+		}
+	}
+
+	baseName := sanitizeFilename(opts.Filename)
+	if baseName == "" {
+		baseName = "clip"
+	}
+	numberWidth := len(fmt.Sprint(len(chapters)))
+
+	paths := make([]string, 0, len(indices))
+	for n, i := range indices {
+		chapter := chapters[i]
+		clipOpts := opts
+		clipOpts.StartTime = chapter.Start
+		clipOpts.EndTime = chapter.End
+		clipOpts.Filename = fmt.Sprintf("%s - %0*d - %s", baseName, numberWidth, i+1, chapter.Title)
+
+		// Progress covers all the selected chapters
+		done := float64(n)
+		outputPath, err := a.exportClip(clipOpts, func(progress float64) {
+			emitEvent(a.ctx, "export:progress", (done+progress)/float64(len(indices)))
+		})
+		if err != nil {
+			return paths, fmt.Errorf("chapter %q: %w", chapter.Title, err)
+		}
+		paths = append(paths, outputPath)
+		emitEvent(a.ctx, "export:complete", outputPath)
+	}
+	return paths, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"yt-downloader/internal/youtube"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// cookiesFileName is where imported cookies are kept in the app data directory
+const cookiesFileName = "cookies.txt"
+
+// CookieStatus describes the imported cookies
+type CookieStatus struct {
+	Imported bool                  `json:"imported"`
+	Summary  youtube.CookieSummary `json:"summary"`
+}
+
+// CookieCheck is the result of trying a video with the imported cookies
+type CookieCheck struct {
+	Unlocked bool                  `json:"unlocked"` // The video's metadata could be fetched
+	Category youtube.ErrorCategory `json:"category,omitempty"`
+	Message  string                `json:"message,omitempty"`
+}
+
+// SelectCookiesFile opens a file dialog for choosing a cookies.txt file
+func (a *App) SelectCookiesFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Cookies",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Cookies (Netscape format)", Pattern: "*.txt"},
+			{DisplayName: "All Files", Pattern: "*"},
+		},
+	})
+}
+
+// ImportCookies copies a Netscape cookies.txt file into the app data directory, readable
+// only by the current user, and sends its cookies with every YouTube request from now on
+func (a *App) ImportCookies(path string) (CookieStatus, error) {
+	if a.cookiesPath == "" {
+		return CookieStatus{}, fmt.Errorf("app data directory not available")
+	}
+	cookies, err := youtube.ParseCookiesFile(path)
+	if err != nil {
+		return CookieStatus{}, err
+	}
+	summary := youtube.SummarizeCookies(cookies, time.Now())
+	if summary.YouTube == 0 {
+		return CookieStatus{}, fmt.Errorf("the file has no current YouTube cookies; export them while signed in to youtube.com")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CookieStatus{}, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(a.cookiesPath), 0755); err != nil {
+		return CookieStatus{}, fmt.Errorf("failed to create app data directory: %w", err)
+	}
+	tmp := a.cookiesPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return CookieStatus{}, fmt.Errorf("failed to save cookies: %w", err)
+	}
+	if err := os.Rename(tmp, a.cookiesPath); err != nil {
+		return CookieStatus{}, fmt.Errorf("failed to save cookies: %w", err)
+	}
+	if err := a.downloader.SetCookiesFile(a.cookiesPath); err != nil {
+		return CookieStatus{}, err
+	}
+	logInfo(a.ctx, fmt.Sprintf("Imported %d cookies", summary.Count))
+	return CookieStatus{Imported: true, Summary: summary}, nil
+}
+
+// ClearCookies deletes the imported cookies and stops sending them
+func (a *App) ClearCookies() error {
+	if err := a.downloader.SetCookiesFile(""); err != nil {
+		return err
+	}
+	if a.cookiesPath == "" {
+		return nil
+	}
+	if err := os.Remove(a.cookiesPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete cookies: %w", err)
+	}
+	return nil
+}
+
+// GetCookieStatus describes the cookies currently imported, if any
+func (a *App) GetCookieStatus() CookieStatus {
+	path := a.downloader.CookiesFile()
+	if path == "" {
+		return CookieStatus{}
+	}
+	cookies, err := youtube.ParseCookiesFile(path)
+	if err != nil {
+		return CookieStatus{}
+	}
+	return CookieStatus{Imported: true, Summary: youtube.SummarizeCookies(cookies, time.Now())}
+}
+
+// CheckCookies fetches a video's metadata with the imported cookies, reporting whether
+// they unlock it or why not
+func (a *App) CheckCookies(url string) (CookieCheck, error) {
+	if a.downloader.CookiesFile() == "" {
+		return CookieCheck{}, fmt.Errorf("no cookies imported")
+	}
+	ffmpegPath, ytdlpPath := a.toolPaths("")
+	if _, err := a.downloader.ResolveVideo(a.ctx, url, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath}); err != nil {
+		return CookieCheck{Category: youtube.CategoryOf(err), Message: err.Error()}, nil
+	}
+	return CookieCheck{Unlocked: true}, nil
+}
+
+// loadCookies starts sending cookies imported in a previous session
+func (a *App) loadCookies() {
+	if _, err := os.Stat(a.cookiesPath); err != nil {
+		return
+	}
+	if err := a.downloader.SetCookiesFile(a.cookiesPath); err != nil {
+		logError(a.ctx, fmt.Sprintf("Failed to load cookies: %v", err))
+	}
+}
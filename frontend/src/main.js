@@ -1,5 +1,15 @@
 import './style.css';
-import { LoadVideo, SelectOutputDirectory, ExportClip, CheckFFmpeg, InstallFFmpeg } from '../wailsjs/go/main/App';
+import {
+    LoadVideo, LoadVideoSection, SelectOutputDirectory, ExportClip, CheckFFmpeg, InstallFFmpeg,
+    IsPlaylistURL, ExpandPlaylist, EnqueueVideos, ListJobs, MoveJob, CancelJob, RetryJob, RemoveJob, OpenJob,
+    GetLibraryDirectory, SelectLibraryDirectory, SearchLibrary, OpenLibraryVideo, DeleteLibraryVideo,
+    ListCaptions, DownloadCaptions, ExportChapters, ListFormats, GetQualityCeiling, SetQualityCeiling,
+    GetWaveform, SelectLocalFile, LoadLocalFile, GetSourceStrategy, SetSourceStrategy,
+    SelectCookiesFile, ImportCookies, ClearCookies, GetCookieStatus, CheckCookies,
+    GetNetworkSettings, SetNetworkSettings, CheckNetworkSettings, SelectCABundle,
+    GetRateLimit, SetRateLimit, GetSchedule, SetSchedule, CaptureLive, StopLiveCapture,
+    ReadClipProvenance, GetDownloadCacheSize, ClearDownloadCache
+} from '../wailsjs/go/main/App';
 import { EventsOn, WindowSetDarkTheme, WindowSetLightTheme, WindowSetSystemDefaultTheme } from '../wailsjs/runtime/runtime';
 
 // State
@@ -12,6 +22,13 @@ let ffmpegInstalled = false;
 let qualityPreset = 'medium';
 let maxResolution = '720p';
 let themePreference = 'system';
+let jobs = [];
+let playlistEntries = [];
+let libraryEntries = [];
+let exportWarning = '';
+let formatsURL = '';
+let liveCaptureURL = ''; // The URL last loaded, recorded instead if it turns out to be live
+let waveformPeaks = [];
 
 // Initialize the app
 document.querySelector('#app').innerHTML = `
@@ -33,6 +50,22 @@ document.querySelector('#app').innerHTML = `
         <button class="btn btn-secondary btn-sm" id="dismissQualityWarning">Dismiss</button>
     </div>
 
+    <!-- Download Error Banner -->
+    <div class="download-error-banner" id="downloadErrorBanner">
+        <p class="download-error-guidance" id="downloadErrorGuidance"></p>
+        <ul class="download-error-attempts" id="downloadErrorAttempts"></ul>
+        <div class="live-capture" id="liveCapturePanel">
+            <div class="live-capture-start">
+                <input type="number" class="range-input" id="liveMinutesInput" min="1" value="10" title="How many minutes of the stream to record" />
+                <span>minutes</span>
+                <button class="btn btn-sm" id="captureLastBtn" title="Record this much of what has already streamed, up to now">Record last minutes</button>
+                <button class="btn btn-sm" id="captureNowBtn" title="Record from now until stopped">Record from now</button>
+            </div>
+            <button class="btn btn-sm" id="stopCaptureBtn">Stop recording</button>
+        </div>
+        <button class="btn btn-secondary btn-sm" id="dismissDownloadError">Dismiss</button>
+    </div>
+
     <!-- Landing -->
     <div class="landing" id="landing">
         <div class="landing-card">
@@ -40,6 +73,13 @@ document.querySelector('#app').innerHTML = `
             <div class="url-section landing-url">
                 <input type="text" class="url-input" id="urlInputHero" placeholder="https://www.youtube.com/watch?v=..." />
                 <button class="btn" id="loadBtnHero">Load</button>
+                <button class="btn btn-secondary" id="openFileBtnHero" title="Open a video or audio file from disk">Open file...</button>
+            </div>
+            <div class="range-section">
+                <span>Only download</span>
+                <input type="text" class="range-input" id="rangeStartHero" placeholder="from 0:00" />
+                <input type="text" class="range-input" id="rangeEndHero" placeholder="to end" />
+                <label class="audio-only-toggle"><input type="checkbox" id="audioOnlyHero" /> Audio only</label>
             </div>
             <div class="progress-container landing-progress" id="landingProgress">
                 <div class="progress-bar">
@@ -48,6 +88,10 @@ document.querySelector('#app').innerHTML = `
                 <div class="progress-text" id="landingProgressText">Downloading...</div>
             </div>
             <div class="landing-hint" id="landingHint">After loading, you can trim and export on the next screen.</div>
+            <div class="landing-library" id="landingLibrary">
+                <div class="card-title">Library</div>
+                <div class="queue-list" id="landingLibraryList"></div>
+            </div>
         </div>
     </div>
 
@@ -66,6 +110,66 @@ document.querySelector('#app').innerHTML = `
                 <div class="url-section compact">
                     <input type="text" class="url-input" id="urlInput" placeholder="Paste YouTube URL..." />
                     <button class="btn" id="loadBtn">Load</button>
+                    <button class="btn btn-secondary" id="openFileBtn" title="Open a video or audio file from disk">File</button>
+                </div>
+                <div class="range-section compact">
+                    <span>Only download</span>
+                    <input type="text" class="range-input" id="rangeStart" placeholder="from 0:00" />
+                    <input type="text" class="range-input" id="rangeEnd" placeholder="to end" />
+                    <label class="audio-only-toggle"><input type="checkbox" id="audioOnlyCheck" /> Audio</label>
+                </div>
+                <div class="format-section">
+                    <button class="btn btn-secondary btn-compact" id="listFormatsBtn">Choose formats...</button>
+                    <div class="format-selects" id="formatSelects">
+                        <select id="videoFormatSelect" class="select">
+                            <option value="0">Automatic video</option>
+                        </select>
+                        <select id="audioFormatSelect" class="select">
+                            <option value="0">Automatic audio</option>
+                        </select>
+                    </div>
+                </div>
+                <div class="range-section compact">
+                    <span>Download at most</span>
+                    <select id="ceilingHeightSelect" class="select ceiling-select" title="Maximum resolution">
+                        <option value="0">Any</option>
+                        <option value="2160">2160p</option>
+                        <option value="1440">1440p</option>
+                        <option value="1080">1080p</option>
+                        <option value="720">720p</option>
+                        <option value="480">480p</option>
+                        <option value="360">360p</option>
+                    </select>
+                    <select id="ceilingFpsSelect" class="select ceiling-select" title="Maximum frame rate">
+                        <option value="0">Any fps</option>
+                        <option value="60">60 fps</option>
+                        <option value="30">30 fps</option>
+                    </select>
+                    <select id="ceilingCodecSelect" class="select ceiling-select" title="Preferred codec">
+                        <option value="">Any codec</option>
+                        <option value="avc1">H.264</option>
+                        <option value="vp9">VP9</option>
+                        <option value="av01">AV1</option>
+                    </select>
+                </div>
+                <div class="range-section compact">
+                    <span>Download with</span>
+                    <select id="strategySelect" class="select" title="Which downloader to try first">
+                        <option value="yt-dlp,youtube,local">yt-dlp, then built-in</option>
+                        <option value="youtube,yt-dlp,local">Built-in, then yt-dlp</option>
+                        <option value="yt-dlp,local">yt-dlp only</option>
+                        <option value="youtube,local">Built-in only</option>
+                    </select>
+                </div>
+                <div class="range-section compact cookies-section">
+                    <span id="cookieStatusText">No cookies</span>
+                    <button class="btn btn-secondary btn-compact" id="importCookiesBtn" title="Import a cookies.txt file exported from a browser signed in to YouTube">Import cookies...</button>
+                    <button class="btn btn-secondary btn-compact" id="checkCookiesBtn" title="Check whether the cookies unlock the video in the URL box">Check</button>
+                    <button class="btn btn-secondary btn-compact" id="clearCookiesBtn" title="Delete the imported cookies">Clear</button>
+                </div>
+                <div class="range-section compact cookies-section">
+                    <span id="cacheSizeText">Download cache</span>
+                    <button class="btn btn-secondary btn-compact" id="clearCacheBtn" title="Delete kept downloads; videos loaded again are downloaded again">Clear cache</button>
                 </div>
                 <div class="progress-container" id="downloadProgress">
                     <div class="progress-bar">
@@ -84,6 +188,67 @@ document.querySelector('#app').innerHTML = `
                 </div>
             </div>
 
+            <div class="card queue-section" id="queueSection">
+                <div class="card-title">Download Queue</div>
+                <div class="queue-paused" id="queuePausedNote"></div>
+                <div class="queue-list" id="queueList"></div>
+            </div>
+
+            <div class="card library-section" id="librarySection">
+                <div class="card-title">Library</div>
+                <div class="form-group">
+                    <label>Folder</label>
+                    <div class="directory-select">
+                        <input type="text" id="libraryDirInput" readonly placeholder="Select folder..." />
+                        <button class="btn btn-secondary" id="selectLibraryBtn">Browse</button>
+                    </div>
+                </div>
+                <div class="form-group">
+                    <input type="text" id="librarySearch" placeholder="Search library..." />
+                </div>
+                <div class="queue-list" id="libraryList"></div>
+                <button class="btn btn-secondary btn-sm" id="findClipSourceBtn" title="Open the video an exported clip was cut from">Find clip source...</button>
+            </div>
+
+            <div class="card network-section">
+                <div class="card-title">Network</div>
+                <div class="form-group">
+                    <label>Proxy</label>
+                    <input type="text" id="proxyUrlInput" placeholder="http://proxy.example.org:8080 (empty for system)" />
+                </div>
+                <div class="form-group network-row">
+                    <input type="text" id="proxyUserInput" placeholder="Username" />
+                    <input type="password" id="proxyPasswordInput" placeholder="Password" />
+                </div>
+                <div class="form-group">
+                    <label>Extra CA certificates</label>
+                    <div class="directory-select">
+                        <input type="text" id="caBundleInput" readonly placeholder="None" />
+                        <button class="btn btn-secondary" id="selectCABundleBtn">Browse</button>
+                        <button class="btn btn-secondary" id="clearCABundleBtn">Clear</button>
+                    </div>
+                </div>
+                <div class="form-group network-row">
+                    <label>Timeouts (s)</label>
+                    <input type="number" min="0" id="connectTimeoutInput" placeholder="Connect 30" title="Connecting and the TLS handshake" />
+                    <input type="number" min="0" id="responseTimeoutInput" placeholder="Response 60" title="Waiting for a server to respond" />
+                </div>
+                <div class="form-group network-row">
+                    <label>Speed limit (KB/s)</label>
+                    <input type="number" min="0" id="rateLimitInput" placeholder="Unlimited" title="Combined speed of all downloads, except sections and live recordings" />
+                </div>
+                <div class="form-group network-row">
+                    <label class="audio-only-toggle"><input type="checkbox" id="scheduleEnabledCheck" /> Queue only</label>
+                    <input type="time" id="scheduleStartInput" value="18:00" />
+                    <span>to</span>
+                    <input type="time" id="scheduleEndInput" value="07:00" />
+                </div>
+                <div class="network-actions">
+                    <button class="btn btn-secondary btn-compact" id="checkNetworkBtn">Test</button>
+                    <button class="btn btn-compact" id="saveNetworkBtn">Save</button>
+                </div>
+            </div>
+
             <div class="card export-section" id="exportSection">
                 <div class="card-title">Export</div>
                 <div class="form-group">
@@ -99,6 +264,35 @@ document.querySelector('#app').innerHTML = `
                     </select>
                 </div>
                 <div class="form-group">
+                    <label>Format</label>
+                    <select id="exportFormatSelect" class="select">
+                        <option value="">Video (MP4)</option>
+                        <option value="m4a">Audio: M4A (AAC)</option>
+                        <option value="mp3">Audio: MP3</option>
+                        <option value="opus">Audio: Opus</option>
+                        <option value="flac">Audio: FLAC (lossless)</option>
+                        <option value="wav">Audio: WAV (lossless)</option>
+                    </select>
+                </div>
+                <div class="form-group audio-option">
+                    <label>Audio</label>
+                    <div class="directory-select">
+                        <select id="audioBitrateSelect" class="select" title="Bitrate">
+                            <option value="">Bitrate from quality</option>
+                            <option value="96k">96 kbps</option>
+                            <option value="128k">128 kbps</option>
+                            <option value="192k">192 kbps</option>
+                            <option value="256k">256 kbps</option>
+                            <option value="320k">320 kbps</option>
+                        </select>
+                        <select id="sampleRateSelect" class="select" title="Sample rate">
+                            <option value="0">Source rate</option>
+                            <option value="44100">44.1 kHz</option>
+                            <option value="48000">48 kHz</option>
+                        </select>
+                    </div>
+                </div>
+                <div class="form-group video-option">
                     <label>Max Resolution</label>
                     <select id="maxResolutionSelect" class="select">
                         <option value="original">Original</option>
@@ -115,7 +309,13 @@ document.querySelector('#app').innerHTML = `
                         <button class="btn btn-secondary" id="selectDirBtn">Browse</button>
                     </div>
                 </div>
-                <div class="form-group checkbox-group">
+                <div class="form-group">
+                    <label>Captions</label>
+                    <select id="captionsSelect" class="select">
+                        <option value="">None</option>
+                    </select>
+                </div>
+                <div class="form-group checkbox-group video-option">
                     <input type="checkbox" id="removeAudioCheck" />
                     <label for="removeAudioCheck">Remove audio</label>
                 </div>
@@ -131,8 +331,9 @@ document.querySelector('#app').innerHTML = `
 
         <div class="main">
             <div class="video-section" id="videoSection">
-                <div class="video-container">
+                <div class="video-container" id="videoContainer">
                     <video id="videoPlayer"></video>
+                    <canvas class="waveform" id="waveformCanvas"></canvas>
                     <div class="player-controls" id="playerControls">
                         <div class="player-controls-row">
                             <button class="btn player-btn" id="playPauseBtn" title="Play/Pause">Play</button>
@@ -160,11 +361,34 @@ document.querySelector('#app').innerHTML = `
                     <div class="duration-info">
                         Clip duration: <span class="clip-duration" id="clipDuration">0:00</span>
                     </div>
+                    <div class="chapters-section" id="chaptersSection">
+                        <div class="trim-header">
+                            <div class="trim-label">Chapters</div>
+                            <button class="btn btn-secondary btn-compact" id="exportChaptersBtn">Export selected</button>
+                        </div>
+                        <div class="playlist-entries" id="chapterList"></div>
+                    </div>
                 </div>
             </div>
         </div>
     </div>
 
+    <!-- Playlist Picker -->
+    <div class="playlist-picker" id="playlistPicker">
+        <div class="playlist-card">
+            <div class="card-title" id="playlistTitle">Playlist</div>
+            <div class="playlist-actions">
+                <button class="btn btn-secondary btn-compact" id="playlistSelectAll">Select all</button>
+                <button class="btn btn-secondary btn-compact" id="playlistSelectNone">Select none</button>
+            </div>
+            <div class="playlist-entries" id="playlistEntries"></div>
+            <div class="playlist-actions">
+                <button class="btn btn-secondary" id="playlistCancel">Cancel</button>
+                <button class="btn" id="playlistQueue">Queue selected</button>
+            </div>
+        </div>
+    </div>
+
     <!-- Status Messages -->
     <div class="status" id="statusMessage"></div>
 `;
@@ -173,14 +397,36 @@ document.querySelector('#app').innerHTML = `
 const landing = document.getElementById('landing');
 const urlInputHero = document.getElementById('urlInputHero');
 const loadBtnHero = document.getElementById('loadBtnHero');
+const openFileBtnHero = document.getElementById('openFileBtnHero');
 const themeToggle = document.getElementById('themeToggle');
 const landingProgress = document.getElementById('landingProgress');
 const landingProgressFill = document.getElementById('landingProgressFill');
 const landingProgressText = document.getElementById('landingProgressText');
 const landingHint = document.getElementById('landingHint');
+const rangeStartHero = document.getElementById('rangeStartHero');
+const rangeEndHero = document.getElementById('rangeEndHero');
+const audioOnlyHero = document.getElementById('audioOnlyHero');
 
 const urlInput = document.getElementById('urlInput');
 const loadBtn = document.getElementById('loadBtn');
+const openFileBtn = document.getElementById('openFileBtn');
+const rangeStart = document.getElementById('rangeStart');
+const rangeEnd = document.getElementById('rangeEnd');
+const audioOnlyCheck = document.getElementById('audioOnlyCheck');
+const listFormatsBtn = document.getElementById('listFormatsBtn');
+const formatSelects = document.getElementById('formatSelects');
+const videoFormatSelect = document.getElementById('videoFormatSelect');
+const audioFormatSelect = document.getElementById('audioFormatSelect');
+const ceilingHeightSelect = document.getElementById('ceilingHeightSelect');
+const ceilingFpsSelect = document.getElementById('ceilingFpsSelect');
+const ceilingCodecSelect = document.getElementById('ceilingCodecSelect');
+const strategySelect = document.getElementById('strategySelect');
+const cookieStatusText = document.getElementById('cookieStatusText');
+const importCookiesBtn = document.getElementById('importCookiesBtn');
+const checkCookiesBtn = document.getElementById('checkCookiesBtn');
+const clearCookiesBtn = document.getElementById('clearCookiesBtn');
+const cacheSizeText = document.getElementById('cacheSizeText');
+const clearCacheBtn = document.getElementById('clearCacheBtn');
 const downloadProgress = document.getElementById('downloadProgress');
 const downloadProgressFill = document.getElementById('downloadProgressFill');
 const downloadProgressText = document.getElementById('downloadProgressText');
@@ -198,7 +444,9 @@ const thumbnailImg = document.getElementById('thumbnailImg');
 const sourceResolution = document.getElementById('sourceResolution');
 
 const videoSection = document.getElementById('videoSection');
+const videoContainer = document.getElementById('videoContainer');
 const videoPlayer = document.getElementById('videoPlayer');
+const waveformCanvas = document.getElementById('waveformCanvas');
 const playPauseBtn = document.getElementById('playPauseBtn');
 const playbackSlider = document.getElementById('playbackSlider');
 const playbackTime = document.getElementById('playbackTime');
@@ -213,23 +461,72 @@ const endTimeDisplay = document.getElementById('endTimeDisplay');
 const clipDuration = document.getElementById('clipDuration');
 
 const previewBtn = document.getElementById('previewBtn');
+const chaptersSection = document.getElementById('chaptersSection');
+const chapterList = document.getElementById('chapterList');
+const exportChaptersBtn = document.getElementById('exportChaptersBtn');
 
 const exportSection = document.getElementById('exportSection');
 const filenameInput = document.getElementById('filenameInput');
 const qualityPresetSelect = document.getElementById('qualityPresetSelect');
 const maxResolutionSelect = document.getElementById('maxResolutionSelect');
+const exportFormatSelect = document.getElementById('exportFormatSelect');
+const audioBitrateSelect = document.getElementById('audioBitrateSelect');
+const sampleRateSelect = document.getElementById('sampleRateSelect');
 const outputDirInput = document.getElementById('outputDirInput');
 const selectDirBtn = document.getElementById('selectDirBtn');
 const removeAudioCheck = document.getElementById('removeAudioCheck');
+const captionsSelect = document.getElementById('captionsSelect');
 const exportBtn = document.getElementById('exportBtn');
 const exportProgress = document.getElementById('exportProgress');
 const exportProgressFill = document.getElementById('exportProgressFill');
 const exportProgressText = document.getElementById('exportProgressText');
 
+const queueSection = document.getElementById('queueSection');
+const queueList = document.getElementById('queueList');
+const libraryDirInput = document.getElementById('libraryDirInput');
+const selectLibraryBtn = document.getElementById('selectLibraryBtn');
+const proxyUrlInput = document.getElementById('proxyUrlInput');
+const proxyUserInput = document.getElementById('proxyUserInput');
+const proxyPasswordInput = document.getElementById('proxyPasswordInput');
+const caBundleInput = document.getElementById('caBundleInput');
+const selectCABundleBtn = document.getElementById('selectCABundleBtn');
+const clearCABundleBtn = document.getElementById('clearCABundleBtn');
+const connectTimeoutInput = document.getElementById('connectTimeoutInput');
+const responseTimeoutInput = document.getElementById('responseTimeoutInput');
+const checkNetworkBtn = document.getElementById('checkNetworkBtn');
+const rateLimitInput = document.getElementById('rateLimitInput');
+const scheduleEnabledCheck = document.getElementById('scheduleEnabledCheck');
+const scheduleStartInput = document.getElementById('scheduleStartInput');
+const scheduleEndInput = document.getElementById('scheduleEndInput');
+const queuePausedNote = document.getElementById('queuePausedNote');
+const saveNetworkBtn = document.getElementById('saveNetworkBtn');
+const librarySearch = document.getElementById('librarySearch');
+const libraryList = document.getElementById('libraryList');
+const findClipSourceBtn = document.getElementById('findClipSourceBtn');
+const landingLibrary = document.getElementById('landingLibrary');
+const landingLibraryList = document.getElementById('landingLibraryList');
+
+const playlistPicker = document.getElementById('playlistPicker');
+const playlistTitle = document.getElementById('playlistTitle');
+const playlistEntriesEl = document.getElementById('playlistEntries');
+const playlistSelectAll = document.getElementById('playlistSelectAll');
+const playlistSelectNone = document.getElementById('playlistSelectNone');
+const playlistCancel = document.getElementById('playlistCancel');
+const playlistQueue = document.getElementById('playlistQueue');
+
 const statusMessage = document.getElementById('statusMessage');
 const qualityWarningBanner = document.getElementById('qualityWarningBanner');
 const qualityWarningText = document.getElementById('qualityWarningText');
 const dismissQualityWarning = document.getElementById('dismissQualityWarning');
+const downloadErrorBanner = document.getElementById('downloadErrorBanner');
+const downloadErrorGuidance = document.getElementById('downloadErrorGuidance');
+const downloadErrorAttempts = document.getElementById('downloadErrorAttempts');
+const dismissDownloadError = document.getElementById('dismissDownloadError');
+const liveCapturePanel = document.getElementById('liveCapturePanel');
+const liveMinutesInput = document.getElementById('liveMinutesInput');
+const captureLastBtn = document.getElementById('captureLastBtn');
+const captureNowBtn = document.getElementById('captureNowBtn');
+const stopCaptureBtn = document.getElementById('stopCaptureBtn');
 
 function getEffectiveTheme() {
     if (document.documentElement.dataset.theme === 'dark') return 'dark';
@@ -345,6 +642,33 @@ function formatDuration(seconds) {
     return `${m}:${s.toString().padStart(2, '0')}`;
 }
 
+// Parse H:MM:SS, M:SS or plain seconds; returns null for empty or invalid input
+function parseTimeInput(text) {
+    const value = text.trim();
+    if (!value) return null;
+    let seconds = 0;
+    for (const part of value.split(':')) {
+        const n = Number(part);
+        if (part === '' || !Number.isFinite(n) || n < 0) return null;
+        seconds = seconds * 60 + n;
+    }
+    return seconds;
+}
+
+// Read an optional download range; returns null to download the whole video
+function readRange(startInput, endInput) {
+    const start = parseTimeInput(startInput.value);
+    const end = parseTimeInput(endInput.value);
+    if (start === null && end === null) return null;
+    if (end === null) {
+        throw new Error('Enter an end time to download part of the video');
+    }
+    if (end <= (start || 0)) {
+        throw new Error('End time must be after the start time');
+    }
+    return { start: start || 0, end };
+}
+
 function updatePlaybackControls() {
     if (!videoPlayer.src) {
         playPauseBtn.textContent = 'Play';
@@ -361,6 +685,7 @@ function updatePlaybackControls() {
 
     playbackSlider.max = total;
     playbackSlider.value = Math.min(current, total);
+    drawWaveform();
 }
 
 // Update slider range display
@@ -373,8 +698,84 @@ function updateSliderRange() {
     startTimeDisplay.textContent = formatTime(startTime);
     endTimeDisplay.textContent = formatTime(endTime);
     clipDuration.textContent = formatDuration(endTime - startTime);
+    drawWaveform();
 }
 
+// Waveform preview for audio-only videos
+async function loadWaveform() {
+    const info = videoInfo;
+    try {
+        const peaks = await GetWaveform(1000);
+        if (info === videoInfo) {
+            waveformPeaks = peaks || [];
+            drawWaveform();
+        }
+    } catch (err) {
+        console.error('Failed to load waveform:', err);
+    }
+}
+
+// Draw the waveform with the trim range highlighted and the playhead on top
+function drawWaveform() {
+    if (!videoContainer.classList.contains('audio-only')) return;
+    const ratio = window.devicePixelRatio || 1;
+    const width = waveformCanvas.clientWidth * ratio;
+    const height = waveformCanvas.clientHeight * ratio;
+    if (width === 0 || height === 0) return;
+    if (waveformCanvas.width !== width || waveformCanvas.height !== height) {
+        waveformCanvas.width = width;
+        waveformCanvas.height = height;
+    }
+
+    const ctx = waveformCanvas.getContext('2d');
+    ctx.clearRect(0, 0, width, height);
+
+    const total = duration > 0 ? duration : 1;
+    const trimLeft = (startTime / total) * width;
+    const trimRight = (endTime / total) * width;
+    ctx.fillStyle = 'rgba(255, 255, 255, 0.08)';
+    ctx.fillRect(trimLeft, 0, trimRight - trimLeft, height);
+
+    const mid = height / 2;
+    const barWidth = width / Math.max(waveformPeaks.length, 1);
+    waveformPeaks.forEach((peak, i) => {
+        const x = i * barWidth;
+        const inTrim = x >= trimLeft && x <= trimRight;
+        ctx.fillStyle = inTrim ? 'rgba(255, 255, 255, 0.85)' : 'rgba(255, 255, 255, 0.3)';
+        const barHeight = Math.max(peak * (height * 0.9), ratio);
+        ctx.fillRect(x, mid - barHeight / 2, Math.max(barWidth - ratio, ratio), barHeight);
+    });
+
+    const current = Number.isFinite(videoPlayer.currentTime) ? videoPlayer.currentTime : 0;
+    ctx.fillStyle = '#ff453a';
+    ctx.fillRect((current / total) * width - ratio / 2, 0, ratio, height);
+}
+
+// Only offer audio formats when there is no video to export
+function setExportFormatOptions() {
+    const audioOnly = !!(videoInfo && videoInfo.audioOnly);
+    exportFormatSelect.querySelector('option[value=""]').disabled = audioOnly;
+    if (audioOnly && exportFormatSelect.value === '') {
+        exportFormatSelect.value = 'm4a';
+    }
+    updateExportFormat();
+}
+
+function updateExportFormat() {
+    exportSection.classList.toggle('audio-export', exportFormatSelect.value !== '');
+}
+
+exportFormatSelect.addEventListener('change', updateExportFormat);
+
+waveformCanvas.addEventListener('click', (e) => {
+    if (!videoPlayer.src || !(duration > 0)) return;
+    const rect = waveformCanvas.getBoundingClientRect();
+    videoPlayer.currentTime = ((e.clientX - rect.left) / rect.width) * duration;
+    updatePlaybackControls();
+});
+
+window.addEventListener('resize', drawWaveform);
+
 // Seek helper (debounced for slider scrubbing)
 let seekTimer = null;
 function seekPreview(time) {
@@ -426,7 +827,128 @@ async function checkFfmpeg() {
     }
 }
 
-async function loadVideoFromURL(url) {
+function applyInitialTrim() {
+    const hasTrim = videoInfo && videoInfo.trimEnd > videoInfo.trimStart;
+    startTime = hasTrim ? Math.min(videoInfo.trimStart, duration) : 0;
+    endTime = hasTrim ? Math.min(videoInfo.trimEnd, duration) : duration;
+
+    startSlider.max = duration;
+    endSlider.max = duration;
+    startSlider.value = startTime;
+    endSlider.value = endTime;
+}
+
+function applyVideoInfo(info, url) {
+    videoInfo = info;
+
+    // Update video player; audio-only previews play through it too, behind a waveform
+    videoPlayer.src = videoInfo.videoUrl;
+    videoPlayer.load();
+    videoContainer.classList.toggle('audio-only', !!videoInfo.audioOnly);
+    waveformPeaks = [];
+    if (videoInfo.audioOnly) {
+        loadWaveform();
+    }
+    setExportFormatOptions();
+    videoTitle.textContent = videoInfo.title;
+    videoAuthor.textContent = videoInfo.author;
+
+    // Display source resolution
+    if (videoInfo.sourceHeight > 0) {
+        sourceResolution.textContent = `Source: ${videoInfo.sourceWidth}×${videoInfo.sourceHeight}`;
+    } else {
+        sourceResolution.textContent = '';
+    }
+
+    filenameInput.value = videoInfo.title;
+    if (url) {
+        urlInput.value = url;
+        urlInputHero.value = url;
+    }
+    if (videoInfo.thumbnail) {
+        thumbnailImg.src = videoInfo.thumbnail;
+        thumbRow.classList.add('visible');
+    } else {
+        thumbRow.classList.remove('visible');
+    }
+
+    // Reset trim controls, starting from the requested range if there is one
+    duration = videoInfo.duration;
+    applyInitialTrim();
+
+    updateSliderRange();
+    updatePlaybackControls();
+    renderChapters();
+
+    // Show sections
+    showLayout();
+    setSidebarCollapsed(false);
+    videoSection.classList.add('visible');
+    exportSection.classList.add('visible');
+    qualityPresetSelect.value = qualityPreset;
+    maxResolutionSelect.value = maxResolution;
+    refreshCaptions();
+}
+
+// List the chapters of the loaded video; clicking one sets the trim range to it
+function renderChapters() {
+    const chapters = (videoInfo && videoInfo.chapters) || [];
+    chapterList.innerHTML = '';
+    chaptersSection.classList.toggle('visible', chapters.length > 0);
+    chapters.forEach((chapter, i) => {
+        const row = document.createElement('div');
+        row.className = 'playlist-entry chapter-entry';
+        const check = document.createElement('input');
+        check.type = 'checkbox';
+        check.dataset.index = i;
+        const title = document.createElement('span');
+        title.className = 'playlist-entry-title chapter-title';
+        title.textContent = chapter.title;
+        title.title = 'Set trim to this chapter';
+        title.addEventListener('click', () => {
+            startTime = chapter.start;
+            endTime = chapter.end;
+            startSlider.value = startTime;
+            endSlider.value = endTime;
+            updateSliderRange();
+            seekPreview(startTime);
+        });
+        const length = document.createElement('span');
+        length.className = 'playlist-entry-duration';
+        length.textContent = `${formatDuration(chapter.start)} (${formatDuration(chapter.end - chapter.start)})`;
+        row.append(check, title, length);
+        chapterList.appendChild(row);
+    });
+}
+
+// Fill the captions picker with the tracks of the loaded video
+async function refreshCaptions() {
+    const previous = captionsSelect.value;
+    captionsSelect.innerHTML = '<option value="">None</option>';
+    let tracks = [];
+    try {
+        tracks = await ListCaptions();
+    } catch (err) {
+        console.error('Failed to list captions:', err);
+        return;
+    }
+    // One entry per language; uploaded tracks are preferred when downloading
+    const seen = new Set();
+    tracks.forEach((track) => {
+        if (seen.has(track.language)) return;
+        seen.add(track.language);
+        const option = document.createElement('option');
+        option.value = track.language;
+        const hasManual = tracks.some((t) => t.language === track.language && !t.auto);
+        option.textContent = hasManual ? track.name || track.language : `${track.name || track.language} (auto-generated)`;
+        captionsSelect.appendChild(option);
+    });
+    if (seen.has(previous)) {
+        captionsSelect.value = previous;
+    }
+}
+
+async function loadVideoFromURL(url, range, audioOnly) {
     if (!url) {
         showStatus('Please enter a YouTube URL', 'error');
         return;
@@ -435,6 +957,13 @@ async function loadVideoFromURL(url) {
     try {
         loadBtn.disabled = true;
         loadBtnHero.disabled = true;
+        downloadErrorBanner.classList.remove('visible');
+        liveCaptureURL = url;
+
+        if (await IsPlaylistURL(url)) {
+            await showPlaylistPicker(url);
+            return;
+        }
 
         // Show progress on both landing and sidebar
         downloadProgress.classList.add('visible');
@@ -446,53 +975,81 @@ async function loadVideoFromURL(url) {
         landingProgressText.textContent = 'Downloading...';
         landingHint.style.display = 'none';
 
-        videoInfo = await LoadVideo(url);
-
-        // Update video player
-        videoPlayer.src = videoInfo.videoUrl;
-        videoPlayer.load();
-        videoTitle.textContent = videoInfo.title;
-        videoAuthor.textContent = videoInfo.author;
+        const formats = { ...selectedFormats(url), audioOnly: !!audioOnly };
+        const info = range
+            ? await LoadVideoSection(url, range.start, range.end, formats)
+            : await LoadVideo(url, formats);
+        applyVideoInfo(info, url);
+        loadCacheSize();
+    } catch (err) {
+        showStatus(`Failed to load video: ${err}`, 'error');
+    } finally {
+        loadBtn.disabled = false;
+        loadBtnHero.disabled = false;
+        downloadProgress.classList.remove('visible');
+        landingProgress.classList.remove('visible');
+        landingHint.style.display = '';
+    }
+}
 
-        // Display source resolution
-        if (videoInfo.sourceHeight > 0) {
-            sourceResolution.textContent = `Source: ${videoInfo.sourceWidth}×${videoInfo.sourceHeight}`;
-        } else {
-            sourceResolution.textContent = '';
-        }
+// Record a running livestream into the preview (see CaptureLive). capture is
+// { lastMinutes } to record what already streamed, or { maxMinutes: 0 } to record
+// until stopped.
+async function captureLiveStream(url, capture) {
+    try {
+        loadBtn.disabled = true;
+        loadBtnHero.disabled = true;
+        liveCapturePanel.classList.add('recording');
 
-        filenameInput.value = videoInfo.title;
-        urlInput.value = url;
-        urlInputHero.value = url;
-        if (videoInfo.thumbnail) {
-            thumbnailImg.src = videoInfo.thumbnail;
-            thumbRow.classList.add('visible');
-        } else {
-            thumbRow.classList.remove('visible');
-        }
+        downloadProgress.classList.add('visible');
+        downloadProgressFill.style.width = '0%';
+        downloadProgressText.textContent = 'Recording...';
+        landingProgress.classList.add('visible');
+        landingProgressFill.style.width = '0%';
+        landingProgressText.textContent = 'Recording...';
+        landingHint.style.display = 'none';
 
-        // Reset trim controls
-        duration = videoInfo.duration;
-        startTime = 0;
-        endTime = duration;
+        const info = await CaptureLive(url, capture);
+        downloadErrorBanner.classList.remove('visible');
+        applyVideoInfo(info, url);
+    } catch (err) {
+        showStatus(`Failed to record livestream: ${err}`, 'error');
+    } finally {
+        loadBtn.disabled = false;
+        loadBtnHero.disabled = false;
+        liveCapturePanel.classList.remove('recording');
+        downloadProgress.classList.remove('visible');
+        landingProgress.classList.remove('visible');
+        landingHint.style.display = '';
+    }
+}
 
-        startSlider.max = duration;
-        endSlider.max = duration;
-        startSlider.value = 0;
-        endSlider.value = duration;
+// Open a video or audio file from disk
+async function openLocalFile() {
+    let path;
+    try {
+        path = await SelectLocalFile();
+    } catch (err) {
+        showStatus(`Failed to open file: ${err}`, 'error');
+        return;
+    }
+    if (!path) return;
 
-        updateSliderRange();
-        updatePlaybackControls();
+    try {
+        loadBtn.disabled = true;
+        loadBtnHero.disabled = true;
+        downloadProgress.classList.add('visible');
+        downloadProgressFill.style.width = '0%';
+        downloadProgressText.textContent = 'Opening file...';
+        landingProgress.classList.add('visible');
+        landingProgressFill.style.width = '0%';
+        landingProgressText.textContent = 'Opening file...';
+        landingHint.style.display = 'none';
 
-        // Show sections
-        showLayout();
-        setSidebarCollapsed(false);
-        videoSection.classList.add('visible');
-        exportSection.classList.add('visible');
-        qualityPresetSelect.value = qualityPreset;
-        maxResolutionSelect.value = maxResolution;
+        const info = await LoadLocalFile(path);
+        applyVideoInfo(info, '');
     } catch (err) {
-        showStatus(`Failed to load video: ${err}`, 'error');
+        showStatus(`${err}`, 'error');
     } finally {
         loadBtn.disabled = false;
         loadBtnHero.disabled = false;
@@ -502,6 +1059,416 @@ async function loadVideoFromURL(url) {
     }
 }
 
+// Playlist picker
+async function showPlaylistPicker(url) {
+    landingProgress.classList.add('visible');
+    landingProgressFill.style.width = '0%';
+    landingProgressText.textContent = 'Reading playlist...';
+    try {
+        playlistEntries = await ExpandPlaylist(url);
+    } finally {
+        landingProgress.classList.remove('visible');
+    }
+
+    playlistTitle.textContent = `Playlist (${playlistEntries.length} videos)`;
+    playlistEntriesEl.innerHTML = '';
+    playlistEntries.forEach((entry, i) => {
+        const row = document.createElement('label');
+        row.className = 'playlist-entry';
+        const check = document.createElement('input');
+        check.type = 'checkbox';
+        check.checked = true;
+        check.dataset.index = i;
+        const title = document.createElement('span');
+        title.className = 'playlist-entry-title';
+        title.textContent = `${i + 1}. ${entry.title}`;
+        const length = document.createElement('span');
+        length.className = 'playlist-entry-duration';
+        length.textContent = formatDuration(entry.duration);
+        row.append(check, title, length);
+        playlistEntriesEl.appendChild(row);
+    });
+    playlistPicker.classList.add('visible');
+}
+
+function setPlaylistSelection(checked) {
+    playlistEntriesEl.querySelectorAll('input[type=checkbox]').forEach((c) => {
+        c.checked = checked;
+    });
+}
+
+playlistSelectAll.addEventListener('click', () => setPlaylistSelection(true));
+playlistSelectNone.addEventListener('click', () => setPlaylistSelection(false));
+playlistCancel.addEventListener('click', () => playlistPicker.classList.remove('visible'));
+
+playlistQueue.addEventListener('click', async () => {
+    const items = [];
+    playlistEntriesEl.querySelectorAll('input[type=checkbox]').forEach((c) => {
+        if (!c.checked) return;
+        const entry = playlistEntries[Number(c.dataset.index)];
+        items.push({ url: `https://www.youtube.com/watch?v=${entry.id}`, title: entry.title });
+    });
+    if (items.length === 0) {
+        showStatus('Select at least one video', 'error');
+        return;
+    }
+    try {
+        playlistQueue.disabled = true;
+        await EnqueueVideos(items);
+        playlistPicker.classList.remove('visible');
+        showLayout();
+        setSidebarCollapsed(false);
+        showStatus(`Queued ${items.length} video${items.length === 1 ? '' : 's'}`, 'success');
+    } catch (err) {
+        showStatus(`Failed to queue videos: ${err}`, 'error');
+    } finally {
+        playlistQueue.disabled = false;
+    }
+});
+
+// Download queue
+// What to tell the user for each kind of download failure
+const errorGuidance = {
+    'unavailable': 'This video is unavailable. It may be private, removed, or the link may be wrong.',
+    'age-restricted': 'This video is age-restricted. YouTube only shows it to signed-in adults; import cookies from a signed-in browser to download it.',
+    'members-only': 'This video is for channel members only. Import cookies from a browser signed in to a member account to download it.',
+    'geo-blocked': 'This video is not available in your country.',
+    'live-not-ended': 'This is a live stream or premiere that has not finished yet. Try again once it has ended, or record it below while it is live.',
+    'network': 'YouTube could not be reached. Check your internet connection and try again.',
+    'throttled': 'YouTube is limiting requests right now. Wait a few minutes and try again.',
+    'extractor-outdated': 'YouTube has changed and the downloader needs an update. Updating yt-dlp usually fixes this.',
+    'disk-full': 'The disk is full. Free up some space and try again.',
+};
+
+function describeError(category, message) {
+    return errorGuidance[category] || message;
+}
+
+const jobStateLabels = {
+    queued: 'Queued',
+    downloading: 'Downloading',
+    muxing: 'Merging',
+    done: 'Done',
+    failed: 'Failed',
+    cancelled: 'Cancelled',
+};
+
+function isJobFinished(job) {
+    return job.state === 'done' || job.state === 'failed' || job.state === 'cancelled';
+}
+
+// Format bytes as a short human-readable size
+function formatBytes(bytes) {
+    if (!bytes) return '0 B';
+    const units = ['B', 'KB', 'MB', 'GB'];
+    let i = 0;
+    while (bytes >= 1024 && i < units.length - 1) {
+        bytes /= 1024;
+        i++;
+    }
+    return `${bytes.toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
+// Describe a download progress report, e.g. "Downloading video: 42% · 3.1 MB/s · 0:25 left".
+// While a failed stream waits to be retried, report carries retry, maxRetries, retryDelay
+// and retryReason.
+function describeProgress(stage, progress, speed, eta, report = {}) {
+    const label = stage ? stage.charAt(0).toUpperCase() + stage.slice(1) : 'Downloading';
+    if (stage === 'recording' && !(progress > 0)) {
+        // Recording until stopped has no end to measure against
+        const parts = [`${label}: ${formatDuration(report.elapsed || 0)}`];
+        if (report.downloadedBytes > 0) parts.push(formatBytes(report.downloadedBytes));
+        return parts.join(' · ');
+    }
+    const parts = [`${label}: ${Math.round(progress * 100)}%`];
+    if (report.retry > 0) {
+        const when = report.retryDelay > 0 ? ` in ${Math.ceil(report.retryDelay)}s` : '';
+        parts.push(`Retrying (${report.retry}/${report.maxRetries})${when}…`);
+    } else if (stage !== 'merging') {
+        if (speed > 0) parts.push(`${formatBytes(speed)}/s`);
+        if (eta >= 0 && speed > 0) parts.push(`${formatDuration(eta)} left`);
+    }
+    return parts.join(' · ');
+}
+
+function renderQueue() {
+    queueSection.classList.toggle('visible', jobs.length > 0);
+    queueList.innerHTML = '';
+    jobs.forEach((job, i) => {
+        const row = document.createElement('div');
+        row.className = `queue-job ${job.state}`;
+
+        const meta = document.createElement('div');
+        meta.className = 'queue-job-meta';
+        const title = document.createElement('div');
+        title.className = 'queue-job-title';
+        title.textContent = job.title;
+        title.title = job.error ? describeError(job.errorCategory, job.error) : job.url;
+        const state = document.createElement('div');
+        state.className = 'queue-job-state';
+        const label = jobStateLabels[job.state] || job.state;
+        state.textContent = job.state === 'downloading' || job.state === 'muxing'
+            ? describeProgress(job.stage || job.state, job.progress, job.speed, job.eta, job)
+            : label;
+        if (job.retryReason) state.title = job.retryReason;
+        meta.append(title, state);
+
+        const actions = document.createElement('div');
+        actions.className = 'queue-job-actions';
+        const addAction = (text, titleText, handler) => {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-secondary btn-compact';
+            btn.textContent = text;
+            btn.title = titleText;
+            btn.addEventListener('click', async () => {
+                try {
+                    await handler();
+                } catch (err) {
+                    showStatus(`${err}`, 'error');
+                }
+            });
+            actions.appendChild(btn);
+        };
+
+        if (job.state === 'done') {
+            addAction('Open', 'Open in editor', async () => applyVideoInfo(await OpenJob(job.id), job.url));
+        }
+        if (job.state === 'failed' || job.state === 'cancelled') {
+            addAction('Retry', 'Retry download', () => RetryJob(job.id));
+        }
+        if (job.state === 'queued') {
+            if (i > 0) addAction('↑', 'Move up', () => MoveJob(job.id, i - 1));
+            if (i < jobs.length - 1) addAction('↓', 'Move down', () => MoveJob(job.id, i + 1));
+        }
+        if (isJobFinished(job)) {
+            addAction('✕', 'Remove', async () => {
+                await RemoveJob(job.id);
+                await refreshQueue();
+            });
+        } else {
+            addAction('Cancel', 'Cancel download', () => CancelJob(job.id));
+        }
+
+        row.append(meta, actions);
+        queueList.appendChild(row);
+    });
+}
+
+async function refreshQueue() {
+    try {
+        jobs = await ListJobs();
+        renderQueue();
+    } catch (err) {
+        console.error('Failed to list jobs:', err);
+    }
+}
+
+// Media library
+function describeLibraryEntry(entry) {
+    const parts = [];
+    if (entry.info.author) parts.push(entry.info.author);
+    if (entry.height > 0) parts.push(`${entry.height}p`);
+    if (entry.section) parts.push(`${formatDuration(entry.section.start)}–${formatDuration(entry.section.end)} only`);
+    if (entry.clips.length > 0) parts.push(`${entry.clips.length} clip${entry.clips.length === 1 ? '' : 's'}`);
+    parts.push(formatBytes(entry.size));
+    return parts.join(' · ');
+}
+
+function renderLibraryList(container, entries) {
+    container.innerHTML = '';
+    entries.forEach((entry) => {
+        const row = document.createElement('div');
+        row.className = 'queue-job';
+
+        const meta = document.createElement('div');
+        meta.className = 'queue-job-meta';
+        const title = document.createElement('div');
+        title.className = 'queue-job-title';
+        title.textContent = entry.info.title;
+        title.title = entry.sourceUrl;
+        const details = document.createElement('div');
+        details.className = 'queue-job-state';
+        details.textContent = describeLibraryEntry(entry);
+        meta.append(title, details);
+
+        const actions = document.createElement('div');
+        actions.className = 'queue-job-actions';
+        const openBtn = document.createElement('button');
+        openBtn.className = 'btn btn-secondary btn-compact';
+        openBtn.textContent = 'Open';
+        openBtn.title = 'Open in editor';
+        openBtn.addEventListener('click', async () => {
+            try {
+                applyVideoInfo(await OpenLibraryVideo(entry.info.id), entry.sourceUrl);
+            } catch (err) {
+                showStatus(`Failed to open video: ${err}`, 'error');
+            }
+        });
+        const deleteBtn = document.createElement('button');
+        deleteBtn.className = 'btn btn-secondary btn-compact';
+        deleteBtn.textContent = '✕';
+        deleteBtn.title = 'Delete from library';
+        deleteBtn.addEventListener('click', async () => {
+            if (!confirm(`Delete "${entry.info.title}" and its clips from the library?`)) return;
+            try {
+                await DeleteLibraryVideo(entry.info.id);
+                await refreshLibrary();
+            } catch (err) {
+                showStatus(`Failed to delete video: ${err}`, 'error');
+            }
+        });
+        actions.append(openBtn, deleteBtn);
+
+        row.append(meta, actions);
+        container.appendChild(row);
+    });
+}
+
+async function refreshLibrary() {
+    try {
+        libraryEntries = await SearchLibrary(librarySearch.value.trim());
+        renderLibraryList(libraryList, libraryEntries);
+        renderLibraryList(landingLibraryList, libraryEntries);
+        landingLibrary.classList.toggle('visible', libraryEntries.length > 0);
+    } catch (err) {
+        console.error('Failed to list library:', err);
+    }
+}
+
+async function loadLibraryDirectory() {
+    try {
+        libraryDirInput.value = await GetLibraryDirectory();
+    } catch (err) {
+        console.error('Failed to get library directory:', err);
+    }
+    await refreshLibrary();
+}
+
+selectLibraryBtn.addEventListener('click', async () => {
+    try {
+        const dir = await SelectLibraryDirectory();
+        if (dir) {
+            libraryDirInput.value = dir;
+            await refreshLibrary();
+        }
+    } catch (err) {
+        showStatus(`Failed to set library folder: ${err}`, 'error');
+    }
+});
+
+librarySearch.addEventListener('input', () => refreshLibrary());
+
+// Open the source of an exported clip, with the trim range set to the clip
+findClipSourceBtn.addEventListener('click', async () => {
+    let source;
+    try {
+        const path = await SelectLocalFile();
+        if (!path) return;
+        source = await ReadClipProvenance(path);
+    } catch (err) {
+        showStatus(`${err}`, 'error');
+        return;
+    }
+    const p = source.provenance;
+    showStatus(`Clip ${formatDuration(p.clipStart)}-${formatDuration(p.clipEnd)} of "${p.title}"`, 'success');
+    urlInput.value = source.url;
+    await loadVideoFromURL(source.url);
+});
+
+// Proxy, certificates and timeouts
+function networkSettingsFromInputs() {
+    return {
+        proxyUrl: proxyUrlInput.value.trim(),
+        proxyUsername: proxyUserInput.value.trim(),
+        proxyPassword: proxyPasswordInput.value,
+        caBundle: caBundleInput.value,
+        connectTimeout: parseInt(connectTimeoutInput.value, 10) || 0,
+        responseTimeout: parseInt(responseTimeoutInput.value, 10) || 0,
+    };
+}
+
+async function loadNetworkSettings() {
+    try {
+        const settings = await GetNetworkSettings();
+        proxyUrlInput.value = settings.proxyUrl || '';
+        proxyUserInput.value = settings.proxyUsername || '';
+        proxyPasswordInput.value = settings.proxyPassword || '';
+        caBundleInput.value = settings.caBundle || '';
+        connectTimeoutInput.value = settings.connectTimeout || '';
+        responseTimeoutInput.value = settings.responseTimeout || '';
+        const rateLimit = await GetRateLimit();
+        rateLimitInput.value = rateLimit > 0 ? Math.round(rateLimit / 1024) : '';
+        showSchedule(await GetSchedule());
+    } catch (err) {
+        console.error('Failed to get network settings:', err);
+    }
+}
+
+function showSchedule(status) {
+    const schedule = status.schedule || {};
+    scheduleEnabledCheck.checked = !!schedule.enabled;
+    if (schedule.start) scheduleStartInput.value = schedule.start;
+    if (schedule.end) scheduleEndInput.value = schedule.end;
+    showQueuePaused(status.paused, status.resumeAt);
+}
+
+function showQueuePaused(paused, resumeAt) {
+    queuePausedNote.textContent = paused
+        ? `Paused by the schedule${resumeAt ? ` until ${resumeAt}` : ''}`
+        : '';
+    queuePausedNote.classList.toggle('visible', !!paused);
+}
+
+selectCABundleBtn.addEventListener('click', async () => {
+    try {
+        const path = await SelectCABundle();
+        if (path) caBundleInput.value = path;
+    } catch (err) {
+        showStatus(`Failed to select certificates: ${err}`, 'error');
+    }
+});
+
+clearCABundleBtn.addEventListener('click', () => {
+    caBundleInput.value = '';
+});
+
+checkNetworkBtn.addEventListener('click', async () => {
+    checkNetworkBtn.disabled = true;
+    try {
+        await CheckNetworkSettings(networkSettingsFromInputs());
+        showStatus('YouTube is reachable with these settings');
+    } catch (err) {
+        showStatus(`Network test failed: ${err}`, 'error');
+    } finally {
+        checkNetworkBtn.disabled = false;
+    }
+});
+
+saveNetworkBtn.addEventListener('click', async () => {
+    try {
+        await SetNetworkSettings(networkSettingsFromInputs());
+        await SetRateLimit((parseInt(rateLimitInput.value, 10) || 0) * 1024);
+        showSchedule(await SetSchedule({
+            enabled: scheduleEnabledCheck.checked,
+            start: scheduleStartInput.value,
+            end: scheduleEndInput.value,
+        }));
+        showStatus('Network settings saved');
+    } catch (err) {
+        showStatus(`Failed to save network settings: ${err}`, 'error');
+    }
+});
+
+// Fetch the chosen captions right away so they're saved for offline exports
+captionsSelect.addEventListener('change', async () => {
+    if (!captionsSelect.value) return;
+    try {
+        await DownloadCaptions(captionsSelect.value);
+    } catch (err) {
+        showStatus(`Failed to download captions: ${err}`, 'error');
+    }
+});
+
 // Install FFmpeg
 installFfmpegBtn.addEventListener('click', async () => {
     try {
@@ -520,17 +1487,227 @@ installFfmpegBtn.addEventListener('click', async () => {
 });
 
 // Load video
-loadBtn.addEventListener('click', async () => loadVideoFromURL(urlInput.value.trim()));
-loadBtnHero.addEventListener('click', async () => loadVideoFromURL(urlInputHero.value.trim()));
+// Load from the sidebar or landing inputs, with the optional range next to them
+function loadFromInputs(input, startInput, endInput, audioCheck) {
+    let range;
+    try {
+        range = readRange(startInput, endInput);
+    } catch (err) {
+        showStatus(err.message, 'error');
+        return;
+    }
+    loadVideoFromURL(input.value.trim(), range, audioCheck.checked);
+}
+
+// Describe a stream for the format pickers
+function describeFormat(format) {
+    const size = format.size ? ` · ${formatBytes(format.size)}` : '';
+    if (format.audioOnly) {
+        return `${Math.round(format.bitrate / 1000)} kbps ${format.container} ${format.audioCodec}${size}`;
+    }
+    const label = format.qualityLabel || `${format.height}p`;
+    const fps = format.fps ? ` ${format.fps}fps` : '';
+    const audio = format.videoOnly ? '' : ' (with audio)';
+    return `${label}${fps} ${format.container} ${format.videoCodec}${audio}${size}`;
+}
+
+// List the streams of the URL in the sidebar so specific ones can be downloaded
+listFormatsBtn.addEventListener('click', async () => {
+    const url = urlInput.value.trim();
+    if (!url) {
+        showStatus('Please enter a YouTube URL', 'error');
+        return;
+    }
+    let formats;
+    try {
+        listFormatsBtn.disabled = true;
+        formats = await ListFormats(url);
+    } catch (err) {
+        showStatus(`Failed to list formats: ${err}`, 'error');
+        return;
+    } finally {
+        listFormatsBtn.disabled = false;
+    }
+
+    videoFormatSelect.innerHTML = '<option value="0">Automatic video</option>';
+    audioFormatSelect.innerHTML = '<option value="0">Automatic audio</option>';
+    const byQuality = (a, b) => b.height - a.height || b.bitrate - a.bitrate;
+    formats.slice().sort(byQuality).forEach((format) => {
+        const option = document.createElement('option');
+        option.value = format.itag;
+        option.textContent = describeFormat(format);
+        (format.audioOnly ? audioFormatSelect : videoFormatSelect).appendChild(option);
+    });
+    formatsURL = url;
+    formatSelects.classList.add('visible');
+});
+
+// The streams chosen for url, or automatic if the pickers were filled for another video
+function selectedFormats(url) {
+    if (url !== formatsURL) {
+        return { videoItag: 0, audioItag: 0 };
+    }
+    return { videoItag: Number(videoFormatSelect.value), audioItag: Number(audioFormatSelect.value) };
+}
+
+// Download quality ceiling, applied to automatic format choices
+async function loadQualityCeiling() {
+    try {
+        const ceiling = await GetQualityCeiling();
+        ceilingHeightSelect.value = String(ceiling.maxHeight || 0);
+        ceilingFpsSelect.value = String(ceiling.maxFps || 0);
+        ceilingCodecSelect.value = ceiling.codec || '';
+    } catch (err) {
+        console.error('Failed to get quality ceiling:', err);
+    }
+}
+
+async function saveQualityCeiling() {
+    try {
+        await SetQualityCeiling({
+            maxHeight: Number(ceilingHeightSelect.value),
+            maxFps: Number(ceilingFpsSelect.value),
+            codec: ceilingCodecSelect.value
+        });
+    } catch (err) {
+        showStatus(`Failed to save download quality: ${err}`, 'error');
+    }
+}
+
+[ceilingHeightSelect, ceilingFpsSelect, ceilingCodecSelect].forEach((select) => {
+    select.addEventListener('change', saveQualityCeiling);
+});
+
+// Download source order
+async function loadSourceStrategy() {
+    try {
+        const value = (await GetSourceStrategy()).join(',');
+        if (![...strategySelect.options].some((o) => o.value === value)) {
+            // A custom order set outside this menu
+            const option = document.createElement('option');
+            option.value = value;
+            option.textContent = value.split(',').join(', ');
+            strategySelect.appendChild(option);
+        }
+        strategySelect.value = value;
+    } catch (err) {
+        console.error('Failed to get download strategy:', err);
+    }
+}
+
+strategySelect.addEventListener('change', async () => {
+    try {
+        await SetSourceStrategy(strategySelect.value.split(','));
+    } catch (err) {
+        showStatus(`Failed to save download strategy: ${err}`, 'error');
+    }
+});
+
+// Browser cookies for age-restricted and members-only videos
+function showCookieStatus(status) {
+    const imported = !!(status && status.imported);
+    checkCookiesBtn.disabled = !imported;
+    clearCookiesBtn.disabled = !imported;
+    if (!imported) {
+        cookieStatusText.textContent = 'No cookies';
+        return;
+    }
+    const summary = status.summary;
+    let text = `${summary.youtube} cookies`;
+    if (summary.signedIn) text += ', signed in';
+    if (summary.expired > 0) text += `, ${summary.expired} expired`;
+    cookieStatusText.textContent = text;
+}
+
+async function loadCookieStatus() {
+    try {
+        showCookieStatus(await GetCookieStatus());
+    } catch (err) {
+        console.error('Failed to get cookie status:', err);
+    }
+}
+
+importCookiesBtn.addEventListener('click', async () => {
+    try {
+        const path = await SelectCookiesFile();
+        if (!path) return;
+        const status = await ImportCookies(path);
+        showCookieStatus(status);
+        if (status.summary.signedIn) {
+            showStatus('Cookies imported');
+        } else {
+            showStatus('Cookies imported, but they have no signed-in YouTube session', 'warning');
+        }
+    } catch (err) {
+        showStatus(`Failed to import cookies: ${err}`, 'error');
+    }
+});
+
+checkCookiesBtn.addEventListener('click', async () => {
+    const url = urlInput.value.trim() || urlInputHero.value.trim();
+    if (!url) {
+        showStatus('Enter the URL of a video to check the cookies with', 'error');
+        return;
+    }
+    checkCookiesBtn.disabled = true;
+    try {
+        const check = await CheckCookies(url);
+        if (check.unlocked) {
+            showStatus('The cookies unlock this video');
+        } else {
+            showStatus(`The cookies do not unlock this video: ${describeError(check.category, check.message)}`, 'error');
+        }
+    } catch (err) {
+        showStatus(`Failed to check cookies: ${err}`, 'error');
+    } finally {
+        checkCookiesBtn.disabled = false;
+    }
+});
+
+clearCookiesBtn.addEventListener('click', async () => {
+    try {
+        await ClearCookies();
+        showCookieStatus(null);
+        showStatus('Cookies cleared');
+    } catch (err) {
+        showStatus(`Failed to clear cookies: ${err}`, 'error');
+    }
+});
+
+// Downloads kept so loading a video again is instant
+async function loadCacheSize() {
+    try {
+        const size = await GetDownloadCacheSize();
+        cacheSizeText.textContent = `Download cache: ${size > 0 ? formatBytes(size) : 'empty'}`;
+        clearCacheBtn.disabled = size === 0;
+    } catch (err) {
+        console.error('Failed to get download cache size:', err);
+    }
+}
+
+clearCacheBtn.addEventListener('click', async () => {
+    try {
+        await ClearDownloadCache();
+        showStatus('Download cache cleared');
+    } catch (err) {
+        showStatus(`Failed to clear download cache: ${err}`, 'error');
+    }
+    loadCacheSize();
+});
+
+loadBtn.addEventListener('click', () => loadFromInputs(urlInput, rangeStart, rangeEnd, audioOnlyCheck));
+loadBtnHero.addEventListener('click', () => loadFromInputs(urlInputHero, rangeStartHero, rangeEndHero, audioOnlyHero));
+openFileBtn.addEventListener('click', openLocalFile);
+openFileBtnHero.addEventListener('click', openLocalFile);
 
 urlInput.addEventListener('keydown', (e) => {
     if (e.key === 'Enter') {
-        loadVideoFromURL(urlInput.value.trim());
+        loadFromInputs(urlInput, rangeStart, rangeEnd, audioOnlyCheck);
     }
 });
 urlInputHero.addEventListener('keydown', (e) => {
     if (e.key === 'Enter') {
-        loadVideoFromURL(urlInputHero.value.trim());
+        loadFromInputs(urlInputHero, rangeStartHero, rangeEndHero, audioOnlyHero);
     }
 });
 
@@ -539,10 +1716,7 @@ videoPlayer.addEventListener('loadedmetadata', () => {
     // Update duration from actual video if different
     if (videoPlayer.duration && videoPlayer.duration !== Infinity) {
         duration = videoPlayer.duration;
-        endTime = duration;
-        startSlider.max = duration;
-        endSlider.max = duration;
-        endSlider.value = duration;
+        applyInitialTrim();
         updateSliderRange();
         updatePlaybackControls();
     }
@@ -662,60 +1836,101 @@ selectDirBtn.addEventListener('click', async () => {
     }
 });
 
-// Export clip
-exportBtn.addEventListener('click', async () => {
+// Check that a clip can be exported and return the export settings, or null
+function exportOptions() {
     if (!videoInfo) {
         showStatus('Please load a video first', 'error');
-        return;
+        return null;
     }
 
     if (!outputDir) {
         showStatus('Please select an output directory', 'error');
-        return;
+        return null;
     }
 
     if (!ffmpegInstalled) {
         showStatus('FFmpeg is not installed. Please install it first.', 'error');
-        return;
+        return null;
     }
 
-    const filename = filenameInput.value.trim() || 'clip';
     qualityPreset = qualityPresetSelect.value;
     maxResolution = maxResolutionSelect.value;
+    return {
+        startTime: startTime,
+        endTime: endTime,
+        removeAudio: removeAudioCheck.checked,
+        filename: filenameInput.value.trim() || 'clip',
+        outputDir: outputDir,
+        qualityPreset: qualityPreset,
+        maxResolution: maxResolution,
+        captions: captionsSelect.value,
+        audioFormat: exportFormatSelect.value,
+        audioBitrate: audioBitrateSelect.value,
+        sampleRate: Number(sampleRateSelect.value)
+    };
+}
 
+// Run an export, showing its progress; returns true on success
+async function runExport(button, exportFn, successMessage) {
     try {
-        exportBtn.disabled = true;
+        button.disabled = true;
+        exportWarning = '';
         exportProgress.classList.add('visible');
         exportProgressFill.style.width = '0%';
 
-        await ExportClip({
-            startTime: startTime,
-            endTime: endTime,
-            removeAudio: removeAudioCheck.checked,
-            filename: filename,
-            outputDir: outputDir,
-            qualityPreset: qualityPreset,
-            maxResolution: maxResolution
-        });
+        await exportFn();
 
-        showStatus('Clip exported successfully!', 'success');
+        if (exportWarning) {
+            showStatus(exportWarning, 'error');
+        } else {
+            showStatus(successMessage, 'success');
+        }
     } catch (err) {
         showStatus(`Failed to export clip: ${err}`, 'error');
     } finally {
-        exportBtn.disabled = false;
+        button.disabled = false;
         exportProgress.classList.remove('visible');
     }
+}
+
+// Export clip
+exportBtn.addEventListener('click', async () => {
+    const opts = exportOptions();
+    if (!opts) return;
+    await runExport(exportBtn, () => ExportClip(opts), 'Clip exported successfully!');
+});
+
+// Export the checked chapters, one clip each
+exportChaptersBtn.addEventListener('click', async () => {
+    const indices = [];
+    chapterList.querySelectorAll('input[type=checkbox]').forEach((c) => {
+        if (c.checked) indices.push(Number(c.dataset.index));
+    });
+    if (indices.length === 0) {
+        showStatus('Select at least one chapter', 'error');
+        return;
+    }
+    const opts = exportOptions();
+    if (!opts) return;
+    const message = `Exported ${indices.length} chapter${indices.length === 1 ? '' : 's'}`;
+    await runExport(exportChaptersBtn, () => ExportChapters(indices, opts), message);
 });
 
 // Event listeners for progress updates
-EventsOn('download:progress', (progress) => {
-    const percent = Math.round(progress * 100);
+EventsOn('download:progress', (data) => {
+    const percent = Math.round(data.progress * 100);
+    const text = describeProgress(data.stage, data.progress, data.speed, data.eta, data);
     downloadProgressFill.style.width = `${percent}%`;
-    downloadProgressText.textContent = `Downloading: ${percent}%`;
+    downloadProgressText.textContent = text;
+    downloadProgressText.title = data.retryReason || '';
 
     // Update landing page progress too
     landingProgressFill.style.width = `${percent}%`;
-    landingProgressText.textContent = `Downloading: ${percent}%`;
+    landingProgressText.textContent = text;
+});
+
+EventsOn('export:warning', (message) => {
+    exportWarning = message;
 });
 
 EventsOn('export:progress', (progress) => {
@@ -730,11 +1945,26 @@ EventsOn('ffmpeg:progress', (data) => {
     ffmpegProgressText.textContent = data.status;
 });
 
-EventsOn('download:status', (status) => {
-    downloadProgressText.textContent = status;
-    landingProgressText.textContent = status;
+EventsOn('download:status', (data) => {
+    downloadProgressText.textContent = data.status;
+    landingProgressText.textContent = data.status;
 });
 
+EventsOn('queue:job', (job) => {
+    const idx = jobs.findIndex((j) => j.id === job.id);
+    if (idx >= 0 && job.state === jobs[idx].state) {
+        // Progress-only update: avoid reloading the whole list
+        jobs[idx] = job;
+        renderQueue();
+        return;
+    }
+    refreshQueue();
+});
+
+EventsOn('library:changed', () => refreshLibrary());
+
+EventsOn('queue:paused', (data) => showQueuePaused(data.paused, data.resumeAt));
+
 EventsOn('download:quality-warning', (data) => {
     const height = data.height || 0;
     const label = height > 0 ? `${height}p` : 'unknown resolution';
@@ -748,7 +1978,56 @@ dismissQualityWarning.addEventListener('click', () => {
     qualityWarningBanner.classList.remove('visible');
 });
 
+EventsOn('download:error', (data) => {
+    downloadErrorGuidance.textContent = data.liveStatus === 'upcoming'
+        ? data.message
+        : describeError(data.category, data.message);
+    liveCapturePanel.classList.toggle('visible', data.liveStatus === 'live' || liveCapturePanel.classList.contains('recording'));
+    downloadErrorAttempts.innerHTML = '';
+    (data.attempts || []).forEach((attempt) => {
+        const item = document.createElement('li');
+        item.textContent = attempt.skipped
+            ? `${attempt.source}: skipped (${attempt.message})`
+            : `${attempt.source}: ${attempt.message}`;
+        downloadErrorAttempts.appendChild(item);
+    });
+    downloadErrorBanner.classList.add('visible');
+});
+
+dismissDownloadError.addEventListener('click', () => {
+    downloadErrorBanner.classList.remove('visible');
+});
+
+captureLastBtn.addEventListener('click', () => {
+    const minutes = parseFloat(liveMinutesInput.value);
+    if (!(minutes > 0)) {
+        showStatus('Enter how many minutes to record', 'error');
+        return;
+    }
+    captureLiveStream(liveCaptureURL, { lastMinutes: minutes, maxMinutes: 0 });
+});
+
+captureNowBtn.addEventListener('click', () => {
+    captureLiveStream(liveCaptureURL, { lastMinutes: 0, maxMinutes: 0 });
+});
+
+stopCaptureBtn.addEventListener('click', async () => {
+    stopCaptureBtn.disabled = true;
+    try {
+        await StopLiveCapture();
+    } finally {
+        stopCaptureBtn.disabled = false;
+    }
+});
+
 // Initialize
 checkFfmpeg();
+refreshQueue();
+loadLibraryDirectory();
+loadQualityCeiling();
+loadSourceStrategy();
+loadCookieStatus();
+loadCacheSize();
+loadNetworkSettings();
 thumbRow.classList.remove('visible');
 showLanding();
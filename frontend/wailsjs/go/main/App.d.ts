@@ -1,19 +1,122 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
-import {main} from '../models';
 import {youtube} from '../models';
+import {main} from '../models';
+import {network} from '../models';
+import {queue} from '../models';
 import {video} from '../models';
+import {library} from '../models';
+
+export function CancelJob(arg1:string):Promise<void>;
+
+export function CaptureLive(arg1:string,arg2:youtube.LiveCapture):Promise<main.VideoInfo>;
+
+export function CheckCookies(arg1:string):Promise<main.CookieCheck>;
 
 export function CheckFFmpeg():Promise<boolean>;
 
+export function CheckNetworkSettings(arg1:network.Settings):Promise<void>;
+
+export function ClearCookies():Promise<void>;
+
+export function ClearDownloadCache():Promise<void>;
+
+export function DeleteLibraryVideo(arg1:string):Promise<void>;
+
+export function DownloadCaptions(arg1:string):Promise<void>;
+
+export function EnqueueVideos(arg1:Array<main.QueueItem>):Promise<Array<queue.Job>>;
+
+export function ExpandPlaylist(arg1:string):Promise<Array<youtube.VideoInfo>>;
+
+export function ExportChapters(arg1:Array<number>,arg2:main.ExportOptions):Promise<Array<string>>;
+
 export function ExportClip(arg1:main.ExportOptions):Promise<void>;
 
+export function GetCookieStatus():Promise<main.CookieStatus>;
+
+export function GetDownloadCacheSize():Promise<number>;
+
+export function GetDownloadWorkers():Promise<number>;
+
+export function GetLibraryDirectory():Promise<string>;
+
+export function GetMaxConcurrentDownloads():Promise<number>;
+
+export function GetNetworkSettings():Promise<network.Settings>;
+
+export function GetQualityCeiling():Promise<youtube.QualityCeiling>;
+
+export function GetRateLimit():Promise<number>;
+
+export function GetSchedule():Promise<main.ScheduleStatus>;
+
+export function GetSourceStrategy():Promise<Array<string>>;
+
 export function GetVideoInfo(arg1:string):Promise<youtube.VideoInfo>;
 
 export function GetVideoServer():Promise<video.Server>;
 
+export function GetWaveform(arg1:number):Promise<Array<number>>;
+
+export function ImportCookies(arg1:string):Promise<main.CookieStatus>;
+
 export function InstallFFmpeg():Promise<void>;
 
-export function LoadVideo(arg1:string):Promise<main.VideoInfo>;
+export function IsPlaylistURL(arg1:string):Promise<boolean>;
+
+export function ListCaptions():Promise<Array<youtube.CaptionTrack>>;
+
+export function ListFormats(arg1:string):Promise<Array<youtube.FormatInfo>>;
+
+export function ListJobs():Promise<Array<queue.Job>>;
+
+export function ListLibrary():Promise<Array<library.Entry>>;
+
+export function LoadLocalFile(arg1:string):Promise<main.VideoInfo>;
+
+export function LoadVideo(arg1:string,arg2:youtube.FormatOptions):Promise<main.VideoInfo>;
+
+export function LoadVideoSection(arg1:string,arg2:number,arg3:number,arg4:youtube.FormatOptions):Promise<main.VideoInfo>;
+
+export function MoveJob(arg1:string,arg2:number):Promise<void>;
+
+export function OpenJob(arg1:string):Promise<main.VideoInfo>;
+
+export function OpenLibraryVideo(arg1:string):Promise<main.VideoInfo>;
+
+export function ReadClipProvenance(arg1:string):Promise<main.ClipSource>;
+
+export function RemoveJob(arg1:string):Promise<void>;
+
+export function RetryJob(arg1:string):Promise<void>;
+
+export function SearchLibrary(arg1:string):Promise<Array<library.Entry>>;
+
+export function SelectCABundle():Promise<string>;
+
+export function SelectCookiesFile():Promise<string>;
+
+export function SelectLibraryDirectory():Promise<string>;
+
+export function SelectLocalFile():Promise<string>;
 
 export function SelectOutputDirectory():Promise<string>;
+
+export function SetDownloadWorkers(arg1:number):Promise<void>;
+
+export function SetLibraryDirectory(arg1:string):Promise<void>;
+
+export function SetMaxConcurrentDownloads(arg1:number):Promise<void>;
+
+export function SetNetworkSettings(arg1:network.Settings):Promise<void>;
+
+export function SetQualityCeiling(arg1:youtube.QualityCeiling):Promise<void>;
+
+export function SetRateLimit(arg1:number):Promise<void>;
+
+export function SetSchedule(arg1:queue.Schedule):Promise<main.ScheduleStatus>;
+
+export function SetSourceStrategy(arg1:Array<string>):Promise<void>;
+
+export function StopLiveCapture():Promise<void>;
@@ -2,14 +2,98 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelJob(arg1) {
+  return window['go']['main']['App']['CancelJob'](arg1);
+}
+
+export function CaptureLive(arg1, arg2) {
+  return window['go']['main']['App']['CaptureLive'](arg1, arg2);
+}
+
+export function CheckCookies(arg1) {
+  return window['go']['main']['App']['CheckCookies'](arg1);
+}
+
 export function CheckFFmpeg() {
   return window['go']['main']['App']['CheckFFmpeg']();
 }
 
+export function CheckNetworkSettings(arg1) {
+  return window['go']['main']['App']['CheckNetworkSettings'](arg1);
+}
+
+export function ClearCookies() {
+  return window['go']['main']['App']['ClearCookies']();
+}
+
+export function ClearDownloadCache() {
+  return window['go']['main']['App']['ClearDownloadCache']();
+}
+
+export function DeleteLibraryVideo(arg1) {
+  return window['go']['main']['App']['DeleteLibraryVideo'](arg1);
+}
+
+export function DownloadCaptions(arg1) {
+  return window['go']['main']['App']['DownloadCaptions'](arg1);
+}
+
+export function EnqueueVideos(arg1) {
+  return window['go']['main']['App']['EnqueueVideos'](arg1);
+}
+
+export function ExpandPlaylist(arg1) {
+  return window['go']['main']['App']['ExpandPlaylist'](arg1);
+}
+
+export function ExportChapters(arg1, arg2) {
+  return window['go']['main']['App']['ExportChapters'](arg1, arg2);
+}
+
 export function ExportClip(arg1) {
   return window['go']['main']['App']['ExportClip'](arg1);
 }
 
+export function GetCookieStatus() {
+  return window['go']['main']['App']['GetCookieStatus']();
+}
+
+export function GetDownloadCacheSize() {
+  return window['go']['main']['App']['GetDownloadCacheSize']();
+}
+
+export function GetDownloadWorkers() {
+  return window['go']['main']['App']['GetDownloadWorkers']();
+}
+
+export function GetLibraryDirectory() {
+  return window['go']['main']['App']['GetLibraryDirectory']();
+}
+
+export function GetMaxConcurrentDownloads() {
+  return window['go']['main']['App']['GetMaxConcurrentDownloads']();
+}
+
+export function GetNetworkSettings() {
+  return window['go']['main']['App']['GetNetworkSettings']();
+}
+
+export function GetQualityCeiling() {
+  return window['go']['main']['App']['GetQualityCeiling']();
+}
+
+export function GetRateLimit() {
+  return window['go']['main']['App']['GetRateLimit']();
+}
+
+export function GetSchedule() {
+  return window['go']['main']['App']['GetSchedule']();
+}
+
+export function GetSourceStrategy() {
+  return window['go']['main']['App']['GetSourceStrategy']();
+}
+
 export function GetVideoInfo(arg1) {
   return window['go']['main']['App']['GetVideoInfo'](arg1);
 }
@@ -18,14 +102,130 @@ export function GetVideoServer() {
   return window['go']['main']['App']['GetVideoServer']();
 }
 
+export function GetWaveform(arg1) {
+  return window['go']['main']['App']['GetWaveform'](arg1);
+}
+
+export function ImportCookies(arg1) {
+  return window['go']['main']['App']['ImportCookies'](arg1);
+}
+
 export function InstallFFmpeg() {
   return window['go']['main']['App']['InstallFFmpeg']();
 }
 
-export function LoadVideo(arg1) {
-  return window['go']['main']['App']['LoadVideo'](arg1);
+export function IsPlaylistURL(arg1) {
+  return window['go']['main']['App']['IsPlaylistURL'](arg1);
+}
+
+export function ListCaptions() {
+  return window['go']['main']['App']['ListCaptions']();
+}
+
+export function ListFormats(arg1) {
+  return window['go']['main']['App']['ListFormats'](arg1);
+}
+
+export function ListJobs() {
+  return window['go']['main']['App']['ListJobs']();
+}
+
+export function ListLibrary() {
+  return window['go']['main']['App']['ListLibrary']();
+}
+
+export function LoadLocalFile(arg1) {
+  return window['go']['main']['App']['LoadLocalFile'](arg1);
+}
+
+export function LoadVideo(arg1, arg2) {
+  return window['go']['main']['App']['LoadVideo'](arg1, arg2);
+}
+
+export function LoadVideoSection(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['LoadVideoSection'](arg1, arg2, arg3, arg4);
+}
+
+export function MoveJob(arg1, arg2) {
+  return window['go']['main']['App']['MoveJob'](arg1, arg2);
+}
+
+export function OpenJob(arg1) {
+  return window['go']['main']['App']['OpenJob'](arg1);
+}
+
+export function OpenLibraryVideo(arg1) {
+  return window['go']['main']['App']['OpenLibraryVideo'](arg1);
+}
+
+export function ReadClipProvenance(arg1) {
+  return window['go']['main']['App']['ReadClipProvenance'](arg1);
+}
+
+export function RemoveJob(arg1) {
+  return window['go']['main']['App']['RemoveJob'](arg1);
+}
+
+export function RetryJob(arg1) {
+  return window['go']['main']['App']['RetryJob'](arg1);
+}
+
+export function SearchLibrary(arg1) {
+  return window['go']['main']['App']['SearchLibrary'](arg1);
+}
+
+export function SelectCABundle() {
+  return window['go']['main']['App']['SelectCABundle']();
+}
+
+export function SelectCookiesFile() {
+  return window['go']['main']['App']['SelectCookiesFile']();
+}
+
+export function SelectLibraryDirectory() {
+  return window['go']['main']['App']['SelectLibraryDirectory']();
+}
+
+export function SelectLocalFile() {
+  return window['go']['main']['App']['SelectLocalFile']();
 }
 
 export function SelectOutputDirectory() {
   return window['go']['main']['App']['SelectOutputDirectory']();
 }
+
+export function SetDownloadWorkers(arg1) {
+  return window['go']['main']['App']['SetDownloadWorkers'](arg1);
+}
+
+export function SetLibraryDirectory(arg1) {
+  return window['go']['main']['App']['SetLibraryDirectory'](arg1);
+}
+
+export function SetMaxConcurrentDownloads(arg1) {
+  return window['go']['main']['App']['SetMaxConcurrentDownloads'](arg1);
+}
+
+export function SetNetworkSettings(arg1) {
+  return window['go']['main']['App']['SetNetworkSettings'](arg1);
+}
+
+export function SetQualityCeiling(arg1) {
+  return window['go']['main']['App']['SetQualityCeiling'](arg1);
+}
+
+export function SetRateLimit(arg1) {
+  return window['go']['main']['App']['SetRateLimit'](arg1);
+}
+
+export function SetSchedule(arg1) {
+  return window['go']['main']['App']['SetSchedule'](arg1);
+}
+
+export function SetSourceStrategy(arg1) {
+  return window['go']['main']['App']['SetSourceStrategy'](arg1);
+}
+
+export function StopLiveCapture() {
+  return window['go']['main']['App']['StopLiveCapture']();
+}
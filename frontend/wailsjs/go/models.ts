@@ -1,5 +1,227 @@
+export namespace library {
+	
+	export class Clip {
+	    name: string;
+	    path: string;
+	    start: number;
+	    end: number;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Clip(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Entry {
+	    info: youtube.VideoInfo;
+	    sourceUrl: string;
+	    method: string;
+	    width: number;
+	    height: number;
+	    section?: youtube.Section;
+	    audioOnly?: boolean;
+	    videoPath: string;
+	    thumbnail?: string;
+	    captions?: Record<string, string>;
+	    clips: Clip[];
+	    size: number;
+	    // Go type: time
+	    addedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.info = this.convertValues(source["info"], youtube.VideoInfo);
+	        this.sourceUrl = source["sourceUrl"];
+	        this.method = source["method"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.section = this.convertValues(source["section"], youtube.Section);
+	        this.audioOnly = source["audioOnly"];
+	        this.videoPath = source["videoPath"];
+	        this.thumbnail = source["thumbnail"];
+	        this.captions = source["captions"];
+	        this.clips = this.convertValues(source["clips"], Clip);
+	        this.size = source["size"];
+	        this.addedAt = this.convertValues(source["addedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Provenance {
+	    title: string;
+	    author: string;
+	    sourceUrl: string;
+	    videoId: string;
+	    clipStart: number;
+	    clipEnd: number;
+	    // Go type: time
+	    exportedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Provenance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.author = source["author"];
+	        this.sourceUrl = source["sourceUrl"];
+	        this.videoId = source["videoId"];
+	        this.clipStart = source["clipStart"];
+	        this.clipEnd = source["clipEnd"];
+	        this.exportedAt = this.convertValues(source["exportedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace main {
 	
+	export class ClipSource {
+	    provenance: library.Provenance;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipSource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provenance = this.convertValues(source["provenance"], library.Provenance);
+	        this.url = source["url"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CookieCheck {
+	    unlocked: boolean;
+	    category?: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CookieCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.unlocked = source["unlocked"];
+	        this.category = source["category"];
+	        this.message = source["message"];
+	    }
+	}
+	export class CookieStatus {
+	    imported: boolean;
+	    summary: youtube.CookieSummary;
+	
+	    static createFrom(source: any = {}) {
+	        return new CookieStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.imported = source["imported"];
+	        this.summary = this.convertValues(source["summary"], youtube.CookieSummary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ExportOptions {
 	    startTime: number;
 	    endTime: number;
@@ -8,6 +230,10 @@ export namespace main {
 	    outputDir: string;
 	    qualityPreset: string;
 	    maxResolution: string;
+	    captions: string;
+	    audioFormat: string;
+	    audioBitrate: string;
+	    sampleRate: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new ExportOptions(source);
@@ -22,8 +248,60 @@ export namespace main {
 	        this.outputDir = source["outputDir"];
 	        this.qualityPreset = source["qualityPreset"];
 	        this.maxResolution = source["maxResolution"];
+	        this.captions = source["captions"];
+	        this.audioFormat = source["audioFormat"];
+	        this.audioBitrate = source["audioBitrate"];
+	        this.sampleRate = source["sampleRate"];
 	    }
 	}
+	export class QueueItem {
+	    url: string;
+	    title: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.title = source["title"];
+	    }
+	}
+	export class ScheduleStatus {
+	    schedule: queue.Schedule;
+	    paused: boolean;
+	    resumeAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScheduleStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.schedule = this.convertValues(source["schedule"], queue.Schedule);
+	        this.paused = source["paused"];
+	        this.resumeAt = source["resumeAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class VideoInfo {
 	    id: string;
 	    title: string;
@@ -33,6 +311,11 @@ export namespace main {
 	    videoUrl: string;
 	    sourceWidth: number;
 	    sourceHeight: number;
+	    offset: number;
+	    trimStart: number;
+	    trimEnd: number;
+	    audioOnly: boolean;
+	    chapters: youtube.Chapter[];
 	
 	    static createFrom(source: any = {}) {
 	        return new VideoInfo(source);
@@ -48,6 +331,139 @@ export namespace main {
 	        this.videoUrl = source["videoUrl"];
 	        this.sourceWidth = source["sourceWidth"];
 	        this.sourceHeight = source["sourceHeight"];
+	        this.offset = source["offset"];
+	        this.trimStart = source["trimStart"];
+	        this.trimEnd = source["trimEnd"];
+	        this.audioOnly = source["audioOnly"];
+	        this.chapters = this.convertValues(source["chapters"], youtube.Chapter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace network {
+	
+	export class Settings {
+	    proxyUrl?: string;
+	    proxyUsername?: string;
+	    proxyPassword?: string;
+	    caBundle?: string;
+	    connectTimeout?: number;
+	    responseTimeout?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxyUrl = source["proxyUrl"];
+	        this.proxyUsername = source["proxyUsername"];
+	        this.proxyPassword = source["proxyPassword"];
+	        this.caBundle = source["caBundle"];
+	        this.connectTimeout = source["connectTimeout"];
+	        this.responseTimeout = source["responseTimeout"];
+	    }
+	}
+
+}
+
+export namespace queue {
+	
+	export class Job {
+	    id: string;
+	    url: string;
+	    title: string;
+	    state: string;
+	    progress: number;
+	    stage?: string;
+	    speed?: number;
+	    eta?: number;
+	    retry?: number;
+	    maxRetries?: number;
+	    retryReason?: string;
+	    error?: string;
+	    errorCategory?: string;
+	    result?: youtube.DownloadResult;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.state = source["state"];
+	        this.progress = source["progress"];
+	        this.stage = source["stage"];
+	        this.speed = source["speed"];
+	        this.eta = source["eta"];
+	        this.retry = source["retry"];
+	        this.maxRetries = source["maxRetries"];
+	        this.retryReason = source["retryReason"];
+	        this.error = source["error"];
+	        this.errorCategory = source["errorCategory"];
+	        this.result = this.convertValues(source["result"], youtube.DownloadResult);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Schedule {
+	    enabled: boolean;
+	    start: string;
+	    end: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Schedule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.start = source["start"];
+	        this.end = source["end"];
 	    }
 	}
 
@@ -72,6 +488,215 @@ export namespace video {
 
 export namespace youtube {
 	
+	export class CaptionTrack {
+	    language: string;
+	    name: string;
+	    auto: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptionTrack(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.language = source["language"];
+	        this.name = source["name"];
+	        this.auto = source["auto"];
+	    }
+	}
+	export class Chapter {
+	    title: string;
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Chapter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class CookieSummary {
+	    count: number;
+	    youtube: number;
+	    expired: number;
+	    signedIn: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CookieSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.count = source["count"];
+	        this.youtube = source["youtube"];
+	        this.expired = source["expired"];
+	        this.signedIn = source["signedIn"];
+	    }
+	}
+	export class DownloadResult {
+	    filePath: string;
+	    method: string;
+	    width: number;
+	    height: number;
+	    offset?: number;
+	    duration?: number;
+	    chapters?: Chapter[];
+	    audioOnly?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filePath = source["filePath"];
+	        this.method = source["method"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.offset = source["offset"];
+	        this.duration = source["duration"];
+	        this.chapters = this.convertValues(source["chapters"], Chapter);
+	        this.audioOnly = source["audioOnly"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FormatInfo {
+	    itag: number;
+	    container: string;
+	    videoCodec: string;
+	    audioCodec: string;
+	    width: number;
+	    height: number;
+	    fps: number;
+	    bitrate: number;
+	    size: number;
+	    qualityLabel: string;
+	    audioOnly: boolean;
+	    videoOnly: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.itag = source["itag"];
+	        this.container = source["container"];
+	        this.videoCodec = source["videoCodec"];
+	        this.audioCodec = source["audioCodec"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.fps = source["fps"];
+	        this.bitrate = source["bitrate"];
+	        this.size = source["size"];
+	        this.qualityLabel = source["qualityLabel"];
+	        this.audioOnly = source["audioOnly"];
+	        this.videoOnly = source["videoOnly"];
+	    }
+	}
+	export class QualityCeiling {
+	    maxHeight: number;
+	    maxFps: number;
+	    codec: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QualityCeiling(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxHeight = source["maxHeight"];
+	        this.maxFps = source["maxFps"];
+	        this.codec = source["codec"];
+	    }
+	}
+	export class FormatOptions {
+	    videoItag: number;
+	    audioItag: number;
+	    ceiling: QualityCeiling;
+	    audioOnly: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.videoItag = source["videoItag"];
+	        this.audioItag = source["audioItag"];
+	        this.ceiling = this.convertValues(source["ceiling"], QualityCeiling);
+	        this.audioOnly = source["audioOnly"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LiveCapture {
+	    lastMinutes: number;
+	    maxMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LiveCapture(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lastMinutes = source["lastMinutes"];
+	        this.maxMinutes = source["maxMinutes"];
+	    }
+	}
+	
+	export class Section {
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Section(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
 	export class VideoInfo {
 	    id: string;
 	    title: string;
@@ -81,6 +706,10 @@ export namespace youtube {
 	    description: string;
 	    sourceWidth: number;
 	    sourceHeight: number;
+	    chapters?: Chapter[];
+	    liveStatus?: string;
+	    scheduledStart?: number;
+	    liveMessage?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new VideoInfo(source);
@@ -96,7 +725,29 @@ export namespace youtube {
 	        this.description = source["description"];
 	        this.sourceWidth = source["sourceWidth"];
 	        this.sourceHeight = source["sourceHeight"];
+	        this.chapters = this.convertValues(source["chapters"], Chapter);
+	        this.liveStatus = source["liveStatus"];
+	        this.scheduledStart = source["scheduledStart"];
+	        this.liveMessage = source["liveMessage"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
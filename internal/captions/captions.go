@@ -0,0 +1,216 @@
+package captions
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Cue is one caption shown from Start to End (seconds)
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+var (
+	// timingPattern matches a WebVTT cue timing line; hours are optional
+	timingPattern = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[.,]\d{3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{2}[.,]\d{3})`)
+
+	// tagPattern matches inline markup such as <c>, </c>, <i> and the per-word
+	// <00:00:01.500> timestamps in YouTube's auto-generated captions
+	tagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ParseVTT parses a WebVTT document. Markup is stripped, and the rolling lines that
+// YouTube's auto-generated captions repeat from one cue to the next are removed so
+// each line appears once.
+func ParseVTT(data []byte) ([]Cue, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !bytes.HasPrefix(data, []byte("WEBVTT")) {
+		return nil, fmt.Errorf("not a WebVTT file")
+	}
+
+	var cues []Cue
+	var prevLines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *Cue
+	var lines []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		text := dedupeRollingLines(prevLines, lines)
+		if len(lines) > 0 {
+			prevLines = lines
+		}
+		if text != "" && current.End > current.Start {
+			current.Text = text
+			cues = append(cues, *current)
+		}
+		current, lines = nil, nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := timingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			start, err := parseTimestamp(m[1])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimestamp(m[2])
+			if err != nil {
+				return nil, err
+			}
+			current = &Cue{Start: start, End: end}
+			continue
+		}
+		// Only a truly empty line ends a cue; auto-generated captions contain lines of just a space
+		if line == "" {
+			flush()
+			continue
+		}
+		if current != nil {
+			text := strings.TrimSpace(tagPattern.ReplaceAllString(line, ""))
+			if text != "" {
+				lines = append(lines, unescapeVTT(text))
+			}
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+// ReadVTT parses the WebVTT file at path
+func ReadVTT(path string) ([]Cue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVTT(data)
+}
+
+// dedupeRollingLines drops the leading lines of a cue that were already shown by the
+// previous cue and returns what is left as the cue text
+func dedupeRollingLines(prev []string, lines []string) string {
+	for len(lines) > 0 && len(prev) > 0 && containsLine(prev, lines[0]) {
+		lines = lines[1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// Clip returns the cues overlapping start-end, cut to that range and re-timed so the
+// range starts at zero
+func Clip(cues []Cue, start float64, end float64) []Cue {
+	var clipped []Cue
+	for _, cue := range cues {
+		if cue.End <= start || cue.Start >= end {
+			continue
+		}
+		c := cue
+		if c.Start < start {
+			c.Start = start
+		}
+		if c.End > end {
+			c.End = end
+		}
+		c.Start -= start
+		c.End -= start
+		clipped = append(clipped, c)
+	}
+	return clipped
+}
+
+// WriteSRT writes cues in SubRip format
+func WriteSRT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	for i, cue := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(cue.Start, ","), formatTimestamp(cue.End, ","), cue.Text)
+	}
+	return bw.Flush()
+}
+
+// WriteVTT writes cues in WebVTT format
+func WriteVTT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n", formatTimestamp(cue.Start, "."), formatTimestamp(cue.End, "."), escapeVTT(cue.Text))
+	}
+	return bw.Flush()
+}
+
+// WriteSidecars writes cues next to a video as basePath+".srt" and basePath+".vtt"
+func WriteSidecars(basePath string, cues []Cue) error {
+	for ext, write := range map[string]func(io.Writer, []Cue) error{".srt": WriteSRT, ".vtt": WriteVTT} {
+		file, err := os.Create(basePath + ext)
+		if err != nil {
+			return fmt.Errorf("failed to create captions file: %w", err)
+		}
+		if err := write(file, cues); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write captions file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write captions file: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseTimestamp parses [hh:]mm:ss.ttt (a comma is accepted for the decimal point)
+func parseTimestamp(s string) (float64, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var seconds float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+// formatTimestamp formats seconds as hh:mm:ss followed by sep and milliseconds
+func formatTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+var (
+	vttUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ", "&lrm;", "", "&rlm;", "")
+	vttEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+func unescapeVTT(s string) string {
+	return vttUnescaper.Replace(s)
+}
+
+func escapeVTT(s string) string {
+	return vttEscaper.Replace(s)
+}
@@ -0,0 +1,142 @@
+package captions
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// An excerpt of YouTube's auto-generated captions: each cue repeats the line before
+// it, with per-word timestamps and lines of a single space
+const autoCaptions = "WEBVTT\n" +
+	"Kind: captions\n" +
+	"Language: en\n" +
+	"\n" +
+	"00:00:00.000 --> 00:00:02.500 align:start position:0%\n" +
+	" \n" +
+	"hello<00:00:00.500><c> everyone</c>\n" +
+	"\n" +
+	"00:00:02.500 --> 00:00:02.510 align:start position:0%\n" +
+	"hello everyone\n" +
+	" \n" +
+	"\n" +
+	"00:00:02.510 --> 00:00:05.000 align:start position:0%\n" +
+	"hello everyone\n" +
+	"welcome<00:00:03.000><c> to</c><00:00:03.500><c> class</c>\n" +
+	"\n" +
+	"00:00:05.000 --> 00:00:05.010 align:start position:0%\n" +
+	"welcome to class\n" +
+	" \n" +
+	"\n" +
+	"00:00:05.010 --> 00:00:08.000 align:start position:0%\n" +
+	"welcome to class\n" +
+	"today<00:00:05.500><c> cells</c>\n"
+
+func TestParseVTTAutoCaptions(t *testing.T) {
+	cues, err := ParseVTT([]byte(autoCaptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Start: 0, End: 2.5, Text: "hello everyone"},
+		{Start: 2.51, End: 5, Text: "welcome to class"},
+		{Start: 5.01, End: 8, Text: "today cells"},
+	}
+	if !reflect.DeepEqual(cues, want) {
+		t.Errorf("ParseVTT =\n%+v\nwant\n%+v", cues, want)
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+	data := "\xef\xbb\xbfWEBVTT - lecture\r\n" +
+		"\r\n" +
+		"NOTE written by hand\r\n" +
+		"\r\n" +
+		"intro\r\n" +
+		"00:01.500 --> 00:04.000 line:90% align:center\r\n" +
+		"<v Teacher>Fish &amp; chips</v>\r\n" +
+		"are <i>&lt;tasty&gt;</i>\r\n" +
+		"\r\n" +
+		"1:00:00.000 --> 1:00:02,250\r\n" +
+		"An hour in\r\n" +
+		"\r\n" +
+		"00:00:10.000 --> 00:00:10.000\r\n" +
+		"Never shown\r\n"
+	cues, err := ParseVTT([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Start: 1.5, End: 4, Text: "Fish & chips\nare <tasty>"},
+		{Start: 3600, End: 3602.25, Text: "An hour in"},
+	}
+	if !reflect.DeepEqual(cues, want) {
+		t.Errorf("ParseVTT =\n%+v\nwant\n%+v", cues, want)
+	}
+
+	if _, err := ParseVTT([]byte("1\n00:00:01,000 --> 00:00:02,000\nSRT\n")); err == nil {
+		t.Error("ParseVTT accepted an SRT file")
+	}
+}
+
+func TestClip(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 4, Text: "before"},
+		{Start: 8, End: 12, Text: "straddles the start"},
+		{Start: 12, End: 15, Text: "inside"},
+		{Start: 18, End: 25, Text: "straddles the end"},
+		{Start: 20, End: 30, Text: "at the end"},
+		{Start: 30, End: 32, Text: "after"},
+	}
+	got := Clip(cues, 10, 20)
+	want := []Cue{
+		{Start: 0, End: 2, Text: "straddles the start"},
+		{Start: 2, End: 5, Text: "inside"},
+		{Start: 8, End: 10, Text: "straddles the end"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Clip =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := Clip(cues, 40, 50); got != nil {
+		t.Errorf("Clip past the end = %+v", got)
+	}
+}
+
+var writeCues = []Cue{
+	{Start: 1.5, End: 4, Text: "Fish & chips\nare <tasty>"},
+	{Start: 3723.004, End: 3725.9996, Text: "An hour in"},
+}
+
+func TestWriteSRT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSRT(&buf, writeCues); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,500 --> 00:00:04,000\nFish & chips\nare <tasty>\n\n" +
+		"2\n01:02:03,004 --> 01:02:06,000\nAn hour in\n\n"
+	if buf.String() != want {
+		t.Errorf("WriteSRT wrote\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestWriteVTT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVTT(&buf, writeCues); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n" +
+		"00:00:01.500 --> 00:00:04.000\nFish &amp; chips\nare &lt;tasty&gt;\n\n" +
+		"01:02:03.004 --> 01:02:06.000\nAn hour in\n\n"
+	if buf.String() != want {
+		t.Errorf("WriteVTT wrote\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	// What it writes reads back the same
+	cues, err := ParseVTT(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 2 || cues[0].Text != writeCues[0].Text || cues[1].Start != 3723.004 {
+		t.Errorf("read back %+v", cues)
+	}
+}
@@ -0,0 +1,230 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// AudioFormat is the container and codec of an audio-only export
+type AudioFormat string
+
+const (
+	AudioM4A  AudioFormat = "m4a"  // AAC
+	AudioMP3  AudioFormat = "mp3"  // LAME
+	AudioOpus AudioFormat = "opus" // Opus in Ogg
+	AudioFLAC AudioFormat = "flac" // Lossless
+	AudioWAV  AudioFormat = "wav"  // 16-bit PCM
+)
+
+// opusSampleRates are the only rates the Opus encoder accepts
+var opusSampleRates = []int{8000, 12000, 16000, 24000, 48000}
+
+// waveformRate is the sample rate audio is decoded at for waveforms; plenty for peaks
+const waveformRate = 8000
+
+// Extension returns the file extension for the format, including the dot
+func (f AudioFormat) Extension() string {
+	return "." + string(f)
+}
+
+// Lossless reports whether the format ignores a bitrate
+func (f AudioFormat) Lossless() bool {
+	return f == AudioFLAC || f == AudioWAV
+}
+
+// codecArgs returns the ffmpeg encoder arguments for the format
+func (f AudioFormat) codecArgs(bitrate string) ([]string, error) {
+	switch f {
+	case AudioM4A:
+		return []string{"-c:a", "aac", "-b:a", defaultString(bitrate, "192k"), "-movflags", "+faststart"}, nil
+	case AudioMP3:
+		return []string{"-c:a", "libmp3lame", "-b:a", defaultString(bitrate, "192k")}, nil
+	case AudioOpus:
+		return []string{"-c:a", "libopus", "-b:a", defaultString(bitrate, "128k")}, nil
+	case AudioFLAC:
+		return []string{"-c:a", "flac"}, nil
+	case AudioWAV:
+		return []string{"-c:a", "pcm_s16le"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audio format %q", string(f))
+	}
+}
+
+func defaultString(s string, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// AudioOptions specifies options for extracting a trimmed audio clip
+type AudioOptions struct {
+	InputPath  string
+	OutputPath string
+	StartTime  float64 // in seconds
+	EndTime    float64 // in seconds
+	Format     AudioFormat
+	Bitrate    string // e.g. "192k"; default depends on the format, ignored for lossless formats
+	SampleRate int    // in Hz; 0 keeps the input's rate
+
+	// Tags written to the clip, as for TrimOptions
+	Metadata map[string]string
+}
+
+// ExtractAudioWithProgress trims the audio of a video or audio file into an audio-only
+// file and reports progress
+func (p *Processor) ExtractAudioWithProgress(ctx context.Context, opts AudioOptions, progressCb func(float64)) error {
+	if p.ffmpegPath == "" {
+		return fmt.Errorf("ffmpeg path not set")
+	}
+
+	// Validate input
+	if _, err := os.Stat(opts.InputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.InputPath)
+	}
+
+	if opts.EndTime <= opts.StartTime {
+		return fmt.Errorf("end time must be greater than start time")
+	}
+
+	codecArgs, err := opts.Format.codecArgs(opts.Bitrate)
+	if err != nil {
+		return err
+	}
+	if opts.SampleRate < 0 {
+		return fmt.Errorf("invalid sample rate %d", opts.SampleRate)
+	}
+	if opts.Format == AudioOpus && opts.SampleRate > 0 && !containsInt(opusSampleRates, opts.SampleRate) {
+		return fmt.Errorf("opus does not support a sample rate of %d Hz", opts.SampleRate)
+	}
+
+	// Ensure output directory exists
+	outputDir := filepath.Dir(opts.OutputPath)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	duration := opts.EndTime - opts.StartTime
+
+	args := []string{
+		"-y",
+		"-hide_banner",
+		"-nostats",
+		"-loglevel", "error",
+		"-progress", "pipe:1",
+		"-ss", formatTime(opts.StartTime),
+		"-i", opts.InputPath,
+		"-t", formatTime(duration),
+		"-vn", "-sn", "-dn", // Audio only
+	}
+	args = append(args, codecArgs...)
+	if opts.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(opts.SampleRate))
+	}
+	args = append(args, metadataArgs(opts.Metadata)...)
+	if opts.Format == AudioM4A {
+		// Replaces the codec's movflags so custom tags are kept
+		args = append(args, "-movflags", mp4Movflags(opts.Metadata))
+	}
+	args = append(args, opts.OutputPath)
+
+	return p.runWithProgress(ctx, args, duration, progressCb)
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Waveform returns the peak level (0-1) of the audio of a file in each of buckets
+// equal slices of its length, for drawing a waveform
+func (p *Processor) Waveform(ctx context.Context, inputPath string, buckets int) ([]float64, error) {
+	if p.ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path not set")
+	}
+	if buckets <= 0 {
+		return nil, fmt.Errorf("invalid number of buckets %d", buckets)
+	}
+
+	// Decode to mono 16-bit samples at a low rate and read them as they come
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-loglevel", "error",
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", strconv.Itoa(waveformRate),
+		"-f", "s16le",
+		"pipe:1",
+	}
+	cmd := exec.CommandContext(ctx, p.ffmpegPath, args...)
+	stderrBuf := &limitedBuffer{limit: 64 * 1024}
+	cmd.Stderr = stderrBuf
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// Keep one peak per 10ms window so long files don't have to be held in memory
+	const window = waveformRate / 100
+	var windows []float64
+	var peak float64
+	n := 0
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	var sample [2]byte
+	for {
+		if _, err := io.ReadFull(reader, sample[:]); err != nil {
+			break
+		}
+		level := float64(int16(binary.LittleEndian.Uint16(sample[:]))) / 32768.0
+		if level < 0 {
+			level = -level
+		}
+		if level > peak {
+			peak = level
+		}
+		n++
+		if n == window {
+			windows = append(windows, peak)
+			peak, n = 0, 0
+		}
+	}
+	if n > 0 {
+		windows = append(windows, peak)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %w\nCommand: %s\nOutput: %s", err, formatCmdForDisplay(p.ffmpegPath, args), stderrBuf.String())
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no audio found in %s", filepath.Base(inputPath))
+	}
+
+	peaks := make([]float64, buckets)
+	for i := range peaks {
+		from := i * len(windows) / buckets
+		to := (i + 1) * len(windows) / buckets
+		if to <= from {
+			to = from + 1
+		}
+		for _, w := range windows[from:min(to, len(windows))] {
+			peaks[i] = max(peaks[i], w)
+		}
+	}
+	return peaks, nil
+}
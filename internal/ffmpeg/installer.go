@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 const (
@@ -28,6 +29,9 @@ type ProgressCallback func(progress float64, status string)
 // Installer handles FFmpeg installation
 type Installer struct {
 	cacheDir string
+
+	mu     sync.Mutex
+	client *http.Client // Set with SetHTTPClient
 }
 
 // NewInstaller creates a new FFmpeg installer
@@ -166,13 +170,30 @@ func (i *Installer) Install(ctx context.Context, progressCb ProgressCallback) er
 	return nil
 }
 
+// SetHTTPClient sets the client used for downloads, e.g. to go through a proxy. nil
+// restores the default.
+func (i *Installer) SetHTTPClient(client *http.Client) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.client = client
+}
+
+func (i *Installer) httpClient() *http.Client {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.client != nil {
+		return i.client
+	}
+	return http.DefaultClient
+}
+
 func (i *Installer) downloadFile(ctx context.Context, url, destPath string, progressCb ProgressCallback) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := i.httpClient().Do(req)
 	if err != nil {
 		return err
 	}
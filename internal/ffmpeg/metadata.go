@@ -0,0 +1,128 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// mp4Tags are the metadata keys MP4's iTunes-style tags have a place for. Other keys
+// are only kept with QuickTime-style tags (movflags use_metadata_tags), which most
+// players also read.
+var mp4Tags = map[string]bool{
+	"title": true, "artist": true, "album": true, "album_artist": true, "comment": true,
+	"description": true, "date": true, "genre": true, "copyright": true, "composer": true,
+}
+
+// metadataArgs returns the ffmpeg arguments that replace the output's metadata with
+// meta. The input's own tags are dropped so they can't be mistaken for ours.
+func metadataArgs(meta map[string]string) []string {
+	if len(meta) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{"-map_metadata", "-1"}
+	for _, k := range keys {
+		args = append(args, "-metadata", k+"="+meta[k])
+	}
+	return args
+}
+
+// mp4Movflags returns the -movflags value for an MP4 or M4A output with metadata meta
+func mp4Movflags(meta map[string]string) string {
+	for k := range meta {
+		if !mp4Tags[k] {
+			return "+faststart+use_metadata_tags"
+		}
+	}
+	return "+faststart"
+}
+
+// ReadMetadata returns the global metadata tags of a media file, such as those
+// written from TrimOptions.Metadata
+func (p *Processor) ReadMetadata(ctx context.Context, path string) (map[string]string, error) {
+	if p.ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path not set")
+	}
+	cmd := exec.CommandContext(ctx, p.ffmpegPath, "-hide_banner", "-loglevel", "error", "-i", path, "-f", "ffmetadata", "-")
+	stderr := &limitedBuffer{limit: 64 * 1024}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseFFMetadata(string(out)), nil
+}
+
+// parseFFMetadata reads the global section of ffmpeg's FFMETADATA format, where
+// '=', ';', '#', '\' and newlines in keys and values are escaped with a backslash
+func parseFFMetadata(s string) map[string]string {
+	meta := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var entry strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if entry.Len() == 0 {
+			if strings.HasPrefix(line, "[") {
+				// Stream and chapter sections follow the global tags
+				break
+			}
+			if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+				continue
+			}
+		}
+		entry.WriteString(line)
+		if escapedNewline(line) {
+			entry.WriteString("\n")
+			continue
+		}
+		if key, value, ok := splitFFMetadata(entry.String()); ok {
+			meta[key] = value
+		}
+		entry.Reset()
+	}
+	return meta
+}
+
+// escapedNewline reports whether line ends in a backslash that escapes the newline
+func escapedNewline(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitFFMetadata splits an escaped "key=value" entry at its first unescaped '='
+// and unescapes both halves
+func splitFFMetadata(entry string) (string, string, bool) {
+	var key, value strings.Builder
+	cur := &key
+	found := false
+	for i := 0; i < len(entry); i++ {
+		c := entry[i]
+		switch {
+		case c == '\\' && i+1 < len(entry):
+			i++
+			cur.WriteByte(entry[i])
+		case c == '=' && !found:
+			found = true
+			cur = &value
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if !found || key.Len() == 0 {
+		return "", "", false
+	}
+	return key.String(), value.String(), true
+}
@@ -0,0 +1,65 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFFMetadata(t *testing.T) {
+	in := `;FFMETADATA1
+title=a\=b\;c\#d
+# a comment
+artist=AC\\DC
+dir=C:\\
+description=line one\
+line two\
+[not a section]
+key\=with\=equals=v=w
+no value
+
+encoder=Lavf61.7.100
+[STREAM]
+title=stream title
+`
+	want := map[string]string{
+		"title":           "a=b;c#d",
+		"artist":          `AC\DC`,
+		"dir":             `C:\`,
+		"description":     "line one\nline two\n[not a section]",
+		"key=with=equals": "v=w",
+		"encoder":         "Lavf61.7.100",
+	}
+	if got := parseFFMetadata(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFFMetadata =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParseFFMetadataEmpty(t *testing.T) {
+	if got := parseFFMetadata(""); len(got) != 0 {
+		t.Errorf("empty input = %q, want no tags", got)
+	}
+	// A trailing escaped newline at the end of the input drops the unfinished entry
+	if got := parseFFMetadata(";FFMETADATA1\ntitle=x\\\n"); len(got) != 0 {
+		t.Errorf("unfinished entry = %q, want no tags", got)
+	}
+}
+
+func TestMetadataArgs(t *testing.T) {
+	if args := metadataArgs(nil); args != nil {
+		t.Errorf("metadataArgs(nil) = %q, want nil", args)
+	}
+	got := metadataArgs(map[string]string{"title": "a=b", "source_video_id": "abc"})
+	want := []string{"-map_metadata", "-1", "-metadata", "source_video_id=abc", "-metadata", "title=a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metadataArgs = %q, want %q", got, want)
+	}
+}
+
+func TestMP4Movflags(t *testing.T) {
+	if got := mp4Movflags(map[string]string{"title": "x", "comment": "y"}); got != "+faststart" {
+		t.Errorf("standard tags = %q", got)
+	}
+	if got := mp4Movflags(map[string]string{"title": "x", "source_video_id": "y"}); got != "+faststart+use_metadata_tags" {
+		t.Errorf("custom tags = %q", got)
+	}
+}
@@ -65,6 +65,8 @@ type TrimOptions struct {
 	CRF          int    // Default 23
 	Preset       string // Default "medium"
 	AudioBitrate string // Default "128k" (ignored if RemoveAudio)
+	// Tags written to the clip, e.g. "title", "artist", "comment"; see ReadMetadata
+	Metadata map[string]string
 }
 
 // Processor handles video processing with FFmpeg
@@ -131,12 +133,14 @@ func (p *Processor) TrimVideo(ctx context.Context, opts TrimOptions) error {
 		crf = 23
 	}
 
+	args = append(args, metadataArgs(opts.Metadata)...)
+
 	// Video encoding settings
 	args = append(args,
 		"-c:v", "libx264", // H.264 codec
 		"-preset", preset, // Balance between speed and quality
 		"-crf", strconv.Itoa(crf), // Quality (lower = better)
-		"-movflags", "+faststart", // Enable progressive download
+		"-movflags", mp4Movflags(opts.Metadata), // Enable progressive download
 		opts.OutputPath,
 	)
 
@@ -210,14 +214,21 @@ func (p *Processor) TrimVideoWithProgress(ctx context.Context, opts TrimOptions,
 		crf = 23
 	}
 
+	args = append(args, metadataArgs(opts.Metadata)...)
 	args = append(args,
 		"-c:v", "libx264",
 		"-preset", preset,
 		"-crf", strconv.Itoa(crf),
-		"-movflags", "+faststart",
+		"-movflags", mp4Movflags(opts.Metadata),
 		opts.OutputPath,
 	)
 
+	return p.runWithProgress(ctx, args, duration, progressCb)
+}
+
+// runWithProgress runs ffmpeg with args, which must include "-progress pipe:1", and
+// reports progress through an output of the given duration
+func (p *Processor) runWithProgress(ctx context.Context, args []string, duration float64, progressCb func(float64)) error {
 	cmd := exec.CommandContext(ctx, p.ffmpegPath, args...)
 	stderrBuf := &limitedBuffer{limit: 64 * 1024}
 	cmd.Stderr = stderrBuf
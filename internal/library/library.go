@@ -0,0 +1,479 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"yt-downloader/internal/youtube"
+)
+
+const (
+	// indexName is the on-disk index at the root of a library
+	indexName = "library.json"
+
+	// sourceName is the file name (without extension) of an entry's source video
+	sourceName = "source"
+
+	// thumbnailName is the file name (without extension) of an entry's thumbnail
+	thumbnailName = "thumbnail"
+
+	// clipsDir holds the clips exported from an entry's source video
+	clipsDir = "clips"
+
+	// captionsDir holds an entry's caption tracks as <language>.vtt
+	captionsDir = "captions"
+)
+
+// ErrNotFound is returned when no entry has the requested video ID
+var ErrNotFound = errors.New("video not in library")
+
+// Entry is a downloaded video stored in the library. Paths are relative to the
+// library root so the whole library can be moved or copied to another machine.
+type Entry struct {
+	Info      youtube.VideoInfo `json:"info"`
+	SourceURL string            `json:"sourceUrl"`
+	Method    string            `json:"method"` // Download method from youtube.DownloadResult
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Section   *youtube.Section  `json:"section,omitempty"`   // Set when only part of the video was downloaded
+	AudioOnly bool              `json:"audioOnly,omitempty"` // The source file has no video
+	VideoPath string            `json:"videoPath"`
+	Thumbnail string            `json:"thumbnail,omitempty"`
+	Captions  map[string]string `json:"captions,omitempty"` // Language -> WebVTT file
+	Clips     []Clip            `json:"clips"`
+	Size      int64             `json:"size"` // Bytes used by the entry's files
+	AddedAt   time.Time         `json:"addedAt"`
+}
+
+// Clip is a clip exported from a library video
+type Clip struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Start     float64   `json:"start"`
+	End       float64   `json:"end"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Covers reports whether the entry's source file contains the given range of the video
+func (e Entry) Covers(section *youtube.Section) bool {
+	if e.Section == nil {
+		return true
+	}
+	return section != nil && section.Start >= e.Section.Start && section.End <= e.Section.End
+}
+
+// Library stores source videos, their metadata, thumbnails and derived clips under
+// a root directory, with an index so it can be browsed without network access.
+type Library struct {
+	mu      sync.Mutex
+	root    string
+	entries map[string]*Entry
+}
+
+// Open opens the library at root, creating it if needed
+func Open(root string) (*Library, error) {
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("library directory must be an absolute path")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create library directory: %w", err)
+	}
+	l := &Library{root: root, entries: make(map[string]*Entry)}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Root returns the library directory
+func (l *Library) Root() string {
+	return l.root
+}
+
+// Path resolves a path stored in an entry to an absolute path
+func (l *Library) Path(rel string) string {
+	if rel == "" {
+		return ""
+	}
+	return filepath.Join(l.root, filepath.FromSlash(rel))
+}
+
+// Add moves a downloaded video into the library, replacing any earlier copy of the
+// same video. section is nil when the whole video was downloaded.
+func (l *Library) Add(info youtube.VideoInfo, sourceURL string, result *youtube.DownloadResult, section *youtube.Section) (Entry, error) {
+	if info.ID == "" {
+		return Entry{}, fmt.Errorf("video ID is required")
+	}
+	if result == nil || result.FilePath == "" {
+		return Entry{}, fmt.Errorf("download result has no file")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dir := filepath.Join(l.root, info.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Entry{}, fmt.Errorf("failed to create entry directory: %w", err)
+	}
+
+	// Move the new source file in first, so the old one is still there if that fails
+	name := sourceName + filepath.Ext(result.FilePath)
+	dest := filepath.Join(dir, name)
+	if err := moveFile(result.FilePath, dest+".new"); err != nil {
+		return Entry{}, fmt.Errorf("failed to store video: %w", err)
+	}
+	if err := os.Rename(dest+".new", dest); err != nil {
+		_ = os.Remove(dest + ".new")
+		return Entry{}, fmt.Errorf("failed to store video: %w", err)
+	}
+
+	// Keep the thumbnail and clips of an earlier copy
+	previous := l.entries[info.ID]
+	entry := &Entry{Clips: []Clip{}}
+	if previous != nil {
+		*entry = *previous
+	}
+	oldPath := ""
+	if previous != nil && previous.VideoPath != "" && previous.VideoPath != info.ID+"/"+name {
+		oldPath = l.Path(previous.VideoPath)
+	}
+
+	entry.Info = info
+	entry.SourceURL = sourceURL
+	entry.Method = result.Method
+	entry.Width = result.Width
+	entry.Height = result.Height
+	entry.Section = section
+	entry.AudioOnly = result.AudioOnly
+	entry.VideoPath = info.ID + "/" + name
+	entry.AddedAt = time.Now()
+	entry.Size = dirSize(dir) - fileSize(oldPath)
+	l.entries[info.ID] = entry
+
+	if err := l.save(); err != nil {
+		if previous != nil {
+			l.entries[info.ID] = previous
+		} else {
+			delete(l.entries, info.ID)
+		}
+		return Entry{}, err
+	}
+	// Only now that the index no longer refers to it
+	if oldPath != "" {
+		_ = os.Remove(oldPath)
+	}
+	return *entry, nil
+}
+
+// SetThumbnail copies an image into the library as the thumbnail of a video
+func (l *Library) SetThumbnail(id string, imagePath string) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+
+	name := thumbnailName + filepath.Ext(imagePath)
+	dest := filepath.Join(l.root, id, name)
+	if err := copyFile(imagePath, dest); err != nil {
+		return Entry{}, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+	if entry.Thumbnail != "" && entry.Thumbnail != id+"/"+name {
+		_ = os.Remove(l.Path(entry.Thumbnail))
+	}
+	entry.Thumbnail = id + "/" + name
+	entry.Size = dirSize(filepath.Join(l.root, id))
+
+	if err := l.save(); err != nil {
+		return Entry{}, err
+	}
+	return *entry, nil
+}
+
+// SetCaptions copies a WebVTT caption track into the library for a video
+func (l *Library) SetCaptions(id string, language string, vttPath string) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+
+	dir := filepath.Join(l.root, id, captionsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Entry{}, fmt.Errorf("failed to create captions directory: %w", err)
+	}
+	name := filepath.Base(language) + ".vtt"
+	if err := copyFile(vttPath, filepath.Join(dir, name)); err != nil {
+		return Entry{}, fmt.Errorf("failed to store captions: %w", err)
+	}
+	if entry.Captions == nil {
+		entry.Captions = make(map[string]string)
+	}
+	entry.Captions[language] = id + "/" + captionsDir + "/" + name
+	entry.Size = dirSize(filepath.Join(l.root, id))
+
+	if err := l.save(); err != nil {
+		return Entry{}, err
+	}
+	return *entry, nil
+}
+
+// AddClip copies an exported clip into the library next to its source video
+func (l *Library) AddClip(id string, clipPath string, start float64, end float64) (Clip, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[id]
+	if !ok {
+		return Clip{}, ErrNotFound
+	}
+
+	dir := filepath.Join(l.root, id, clipsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Clip{}, fmt.Errorf("failed to create clips directory: %w", err)
+	}
+	name := uniqueName(dir, filepath.Base(clipPath))
+	if err := copyFile(clipPath, filepath.Join(dir, name)); err != nil {
+		return Clip{}, fmt.Errorf("failed to store clip: %w", err)
+	}
+
+	clip := Clip{
+		Name:      strings.TrimSuffix(name, filepath.Ext(name)),
+		Path:      id + "/" + clipsDir + "/" + name,
+		Start:     start,
+		End:       end,
+		CreatedAt: time.Now(),
+	}
+	entry.Clips = append(entry.Clips, clip)
+	entry.Size = dirSize(filepath.Join(l.root, id))
+
+	if err := l.save(); err != nil {
+		return Clip{}, err
+	}
+	return clip, nil
+}
+
+// Get returns the entry for a video ID
+func (l *Library) Get(id string) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return *entry, nil
+}
+
+// List returns all entries, most recently added first
+func (l *Library) List() []Entry {
+	return l.Search("")
+}
+
+// Search returns the entries whose title, author, description or ID contain every
+// word of query (case-insensitive), most recently added first. An empty query
+// matches everything.
+func (l *Library) Search(query string) []Entry {
+	terms := strings.Fields(strings.ToLower(query))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	results := make([]Entry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		text := strings.ToLower(strings.Join([]string{
+			entry.Info.ID, entry.Info.Title, entry.Info.Author, entry.Info.Description,
+		}, "\n"))
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(text, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, *entry)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].AddedAt.After(results[j].AddedAt)
+	})
+	return results
+}
+
+// Delete removes a video and everything derived from it
+func (l *Library) Delete(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.entries[id]; !ok {
+		return ErrNotFound
+	}
+	if err := os.RemoveAll(filepath.Join(l.root, id)); err != nil {
+		return fmt.Errorf("failed to delete video files: %w", err)
+	}
+	delete(l.entries, id)
+	return l.save()
+}
+
+// DeleteClip removes one clip of a video
+func (l *Library) DeleteClip(id string, clipPath string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for i, clip := range entry.Clips {
+		if clip.Path != clipPath {
+			continue
+		}
+		if err := os.Remove(l.Path(clip.Path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete clip: %w", err)
+		}
+		entry.Clips = append(entry.Clips[:i], entry.Clips[i+1:]...)
+		entry.Size = dirSize(filepath.Join(l.root, id))
+		return l.save()
+	}
+	return fmt.Errorf("clip not found")
+}
+
+// load reads the index. Entries whose source file has gone missing are dropped.
+func (l *Library) load() error {
+	data, err := os.ReadFile(filepath.Join(l.root, indexName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read library index: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse library index: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Info.ID == "" {
+			continue
+		}
+		if _, err := os.Stat(l.Path(entry.VideoPath)); err != nil {
+			fmt.Printf("[DEBUG] Dropping library entry %s: %v\n", entry.Info.ID, err)
+			continue
+		}
+		if entry.Clips == nil {
+			entry.Clips = []Clip{}
+		}
+		l.entries[entry.Info.ID] = entry
+	}
+	return nil
+}
+
+// save writes the index atomically. Callers must hold l.mu.
+func (l *Library) save() error {
+	entries := make([]*Entry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt.Before(entries[j].AddedAt)
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode library index: %w", err)
+	}
+	path := filepath.Join(l.root, indexName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write library index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write library index: %w", err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying when they are on different filesystems
+func moveFile(src string, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to dst through a temporary file so dst is never left half-written
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// uniqueName returns name, or name with a numeric suffix if it already exists in dir
+func uniqueName(dir string, name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+// fileSize returns the size of the file at path, 0 if there is none
+func fileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,222 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"yt-downloader/internal/youtube"
+)
+
+// download writes a file standing in for a finished download and returns its result
+func download(t *testing.T, name string, content string) *youtube.DownloadResult {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &youtube.DownloadResult{FilePath: path, Method: "mux", Width: 1280, Height: 720}
+}
+
+func openTestLibrary(t *testing.T) *Library {
+	t.Helper()
+	l, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// readSource returns the contents of an entry's source file
+func readSource(t *testing.T, l *Library, id string) string {
+	t.Helper()
+	entry, err := l.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(l.Path(entry.VideoPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAdd(t *testing.T) {
+	l := openTestLibrary(t)
+	result := download(t, "dQw4w9WgXcQ-preview.mp4", "first")
+
+	entry, err := l.Add(youtube.VideoInfo{ID: "dQw4w9WgXcQ", Title: "Video"}, "https://youtu.be/dQw4w9WgXcQ", result, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.VideoPath != "dQw4w9WgXcQ/source.mp4" || entry.Width != 1280 || entry.Size != int64(len("first")) {
+		t.Errorf("Add = %+v", entry)
+	}
+	if _, err := os.Stat(result.FilePath); !os.IsNotExist(err) {
+		t.Error("the download was left where it was")
+	}
+	if got := readSource(t, l, "dQw4w9WgXcQ"); got != "first" {
+		t.Errorf("source file has %q", got)
+	}
+
+	// A new download replaces the source but keeps the clips
+	clipPath := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(clipPath, []byte("clip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.AddClip("dQw4w9WgXcQ", clipPath, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	entry, err = l.Add(youtube.VideoInfo{ID: "dQw4w9WgXcQ", Title: "Video"}, "https://youtu.be/dQw4w9WgXcQ", download(t, "audio.m4a", "second"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.VideoPath != "dQw4w9WgXcQ/source.m4a" || len(entry.Clips) != 1 {
+		t.Errorf("Add over an earlier copy = %+v", entry)
+	}
+	if entry.Size != int64(len("second")+len("clip")) {
+		t.Errorf("Size = %d, want the new source and the clip", entry.Size)
+	}
+	if _, err := os.Stat(filepath.Join(l.Root(), "dQw4w9WgXcQ", "source.mp4")); !os.IsNotExist(err) {
+		t.Error("the old source file was kept")
+	}
+}
+
+// When the new file can't be stored, the entry still opens the old one
+func TestAddFailureKeepsOldSource(t *testing.T) {
+	l := openTestLibrary(t)
+	info := youtube.VideoInfo{ID: "dQw4w9WgXcQ", Title: "Video"}
+	if _, err := l.Add(info, "", download(t, "video.mp4", "old"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := &youtube.DownloadResult{FilePath: filepath.Join(t.TempDir(), "gone.mp4")}
+	if _, err := l.Add(info, "", missing, nil); err == nil {
+		t.Fatal("Add succeeded without a file")
+	}
+	if got := readSource(t, l, "dQw4w9WgXcQ"); got != "old" {
+		t.Errorf("source file has %q, want the old one", got)
+	}
+
+	reopened, err := Open(l.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readSource(t, reopened, "dQw4w9WgXcQ"); got != "old" {
+		t.Errorf("after reopening, source file has %q", got)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	whole := Entry{}
+	part := Entry{Section: &youtube.Section{Start: 10, End: 20}}
+	tests := []struct {
+		entry   Entry
+		section *youtube.Section
+		want    bool
+	}{
+		{whole, nil, true},
+		{whole, &youtube.Section{Start: 5, End: 8}, true},
+		{part, nil, false},
+		{part, &youtube.Section{Start: 10, End: 20}, true},
+		{part, &youtube.Section{Start: 12, End: 15}, true},
+		{part, &youtube.Section{Start: 5, End: 15}, false},
+		{part, &youtube.Section{Start: 15, End: 25}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.Covers(tt.section); got != tt.want {
+			t.Errorf("%+v.Covers(%+v) = %v, want %v", tt.entry.Section, tt.section, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAndDelete(t *testing.T) {
+	l := openTestLibrary(t)
+	videos := []youtube.VideoInfo{
+		{ID: "aaaaaaaaaaa", Title: "Cell Biology Lecture", Author: "Science Channel"},
+		{ID: "bbbbbbbbbbb", Title: "Fractions", Author: "Math Channel", Description: "Adding fractions with unlike denominators"},
+		{ID: "ccccccccccc", Title: "Photosynthesis", Author: "Science Channel"},
+	}
+	for _, info := range videos {
+		if _, err := l.Add(info, "", download(t, "video.mp4", info.Title), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(entries []Entry) []string {
+		var ids []string
+		for _, e := range entries {
+			ids = append(ids, e.Info.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"ccccccccccc", "bbbbbbbbbbb", "aaaaaaaaaaa"}},
+		{"science", []string{"ccccccccccc", "aaaaaaaaaaa"}},
+		{"SCIENCE lecture", []string{"aaaaaaaaaaa"}},
+		{"denominators", []string{"bbbbbbbbbbb"}},
+		{"bbbbbbbbbbb", []string{"bbbbbbbbbbb"}},
+		{"history", nil},
+	}
+	for _, tt := range tests {
+		if got := ids(l.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	if err := l.Delete("aaaaaaaaaaa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Root(), "aaaaaaaaaaa")); !os.IsNotExist(err) {
+		t.Error("Delete left the video's files")
+	}
+	if _, err := l.Get("aaaaaaaaaaa"); err != ErrNotFound {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := l.Delete("aaaaaaaaaaa"); err != ErrNotFound {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+// The index comes back when the library is opened again, without entries whose file
+// has gone
+func TestReopen(t *testing.T) {
+	l := openTestLibrary(t)
+	for _, id := range []string{"aaaaaaaaaaa", "bbbbbbbbbbb"} {
+		if _, err := l.Add(youtube.VideoInfo{ID: id, Title: "Video " + id}, "https://youtu.be/"+id, download(t, "video.mp4", id), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	section := &youtube.Section{Start: 10, End: 20}
+	if _, err := l.Add(youtube.VideoInfo{ID: "ccccccccccc"}, "", download(t, "video.mp4", "part"), section); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(l.Root(), "bbbbbbbbbbb", "source.mp4")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(l.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := reopened.Get("aaaaaaaaaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Info.Title != "Video aaaaaaaaaaa" || entry.SourceURL != "https://youtu.be/aaaaaaaaaaa" || entry.Clips == nil {
+		t.Errorf("reloaded entry = %+v", entry)
+	}
+	if got := readSource(t, reopened, "aaaaaaaaaaa"); got != "aaaaaaaaaaa" {
+		t.Errorf("reloaded source has %q", got)
+	}
+	if _, err := reopened.Get("bbbbbbbbbbb"); err != ErrNotFound {
+		t.Errorf("entry without its file = %v, want ErrNotFound", err)
+	}
+	if entry, err := reopened.Get("ccccccccccc"); err != nil || entry.Section == nil || *entry.Section != *section {
+		t.Errorf("reloaded section entry = %+v, %v", entry, err)
+	}
+}
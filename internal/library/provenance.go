@@ -0,0 +1,111 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"yt-downloader/internal/youtube"
+)
+
+// ErrNoProvenance is returned for files that weren't exported with provenance tags
+var ErrNoProvenance = errors.New("file has no record of its source video")
+
+// Custom tags written next to the standard title, artist, comment and description
+const (
+	tagSourceURL  = "source_url"
+	tagVideoID    = "source_video_id"
+	tagClipStart  = "clip_start"
+	tagClipEnd    = "clip_end"
+	tagExportedAt = "exported_at"
+)
+
+// Provenance records where an exported clip came from. Tags turns it into metadata
+// tags for the clip and ParseProvenance reads them back.
+type Provenance struct {
+	Title      string    `json:"title"`
+	Author     string    `json:"author"`
+	SourceURL  string    `json:"sourceUrl"`
+	VideoID    string    `json:"videoId"`
+	ClipStart  float64   `json:"clipStart"` // Seconds into the source video
+	ClipEnd    float64   `json:"clipEnd"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// Tags returns the metadata tags to write to a clip. The standard tags are for people
+// and players; the custom ones are for ParseProvenance.
+func (p Provenance) Tags() map[string]string {
+	tags := map[string]string{
+		"title":       p.Title,
+		"artist":      p.Author,
+		"comment":     p.SourceURL,
+		"description": p.describe(),
+		tagSourceURL:  p.SourceURL,
+		tagVideoID:    p.VideoID,
+		tagClipStart:  strconv.FormatFloat(p.ClipStart, 'f', 3, 64),
+		tagClipEnd:    strconv.FormatFloat(p.ClipEnd, 'f', 3, 64),
+		tagExportedAt: p.ExportedAt.UTC().Format(time.RFC3339),
+	}
+	for k, v := range tags {
+		if v == "" {
+			delete(tags, k)
+		}
+	}
+	return tags
+}
+
+// describe says in words where the clip came from
+func (p Provenance) describe() string {
+	s := fmt.Sprintf("Clip %s-%s of %q", clipTime(p.ClipStart), clipTime(p.ClipEnd), p.Title)
+	if p.Author != "" {
+		s += " by " + p.Author
+	}
+	if p.SourceURL != "" {
+		s += " (" + p.SourceURL + ")"
+	}
+	return s + ", exported " + p.ExportedAt.Format("2006-01-02")
+}
+
+// ClipURL returns a URL that loads the source video with the trim range preset to
+// the clip (see youtube.ParseURL), or the source itself if it isn't a YouTube video
+func (p Provenance) ClipURL() string {
+	parsed, err := youtube.ParseURL(p.SourceURL)
+	if err != nil {
+		return p.SourceURL
+	}
+	return fmt.Sprintf("%s&start=%d&end=%d", parsed.URL, int(p.ClipStart), int(p.ClipEnd+0.999))
+}
+
+// ParseProvenance recovers a clip's provenance from tags written by Tags
+func ParseProvenance(tags map[string]string) (*Provenance, error) {
+	if tags[tagVideoID] == "" {
+		return nil, ErrNoProvenance
+	}
+	p := &Provenance{
+		Title:     tags["title"],
+		Author:    tags["artist"],
+		SourceURL: tags[tagSourceURL],
+		VideoID:   tags[tagVideoID],
+	}
+	var err error
+	if p.ClipStart, err = strconv.ParseFloat(tags[tagClipStart], 64); err != nil {
+		return nil, fmt.Errorf("invalid clip start %q", tags[tagClipStart])
+	}
+	if p.ClipEnd, err = strconv.ParseFloat(tags[tagClipEnd], 64); err != nil {
+		return nil, fmt.Errorf("invalid clip end %q", tags[tagClipEnd])
+	}
+	// The export date is informational; a file edited elsewhere may have lost it
+	p.ExportedAt, _ = time.Parse(time.RFC3339, tags[tagExportedAt])
+	return p, nil
+}
+
+// clipTime formats seconds as M:SS or H:MM:SS
+func clipTime(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,71 @@
+package library
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProvenanceRoundTrip(t *testing.T) {
+	p := Provenance{
+		Title:      "A video; with = signs",
+		Author:     "Someone",
+		SourceURL:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		VideoID:    "dQw4w9WgXcQ",
+		ClipStart:  61.5,
+		ClipEnd:    3725.25,
+		ExportedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+	}
+	tags := p.Tags()
+	if want := `Clip 1:01-1:02:05 of "A video; with = signs" by Someone (https://www.youtube.com/watch?v=dQw4w9WgXcQ), exported 2024-05-06`; tags["description"] != want {
+		t.Errorf("description = %q, want %q", tags["description"], want)
+	}
+	if tags["comment"] != p.SourceURL {
+		t.Errorf("comment = %q, want the source URL", tags["comment"])
+	}
+
+	got, err := ParseProvenance(tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != p {
+		t.Errorf("ParseProvenance = %+v, want %+v", *got, p)
+	}
+	if want := "https://www.youtube.com/watch?v=dQw4w9WgXcQ&start=61&end=3726"; got.ClipURL() != want {
+		t.Errorf("ClipURL = %q, want %q", got.ClipURL(), want)
+	}
+}
+
+func TestTagsOmitEmpty(t *testing.T) {
+	tags := Provenance{Title: "t", VideoID: "id", ClipEnd: 10}.Tags()
+	for _, k := range []string{"artist", "comment", tagSourceURL} {
+		if _, ok := tags[k]; ok {
+			t.Errorf("empty %s was written", k)
+		}
+	}
+}
+
+func TestParseProvenanceErrors(t *testing.T) {
+	if _, err := ParseProvenance(map[string]string{"title": "not ours"}); !errors.Is(err, ErrNoProvenance) {
+		t.Errorf("untagged file: err = %v, want ErrNoProvenance", err)
+	}
+	tags := map[string]string{tagVideoID: "id", tagClipStart: "x", tagClipEnd: "2"}
+	if _, err := ParseProvenance(tags); err == nil {
+		t.Error("invalid clip start: expected an error")
+	}
+	tags[tagClipStart] = "1"
+	delete(tags, tagClipEnd)
+	if _, err := ParseProvenance(tags); err == nil {
+		t.Error("missing clip end: expected an error")
+	}
+
+	// The export date may have been lost by an editor
+	tags[tagClipEnd] = "2"
+	p, err := ParseProvenance(tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.ExportedAt.IsZero() || p.ClipStart != 1 || p.ClipEnd != 2 {
+		t.Errorf("ParseProvenance = %+v", *p)
+	}
+}
@@ -0,0 +1,239 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default timeouts, used when Settings leaves them at zero
+const (
+	DefaultConnectTimeout  = 30 * time.Second
+	DefaultResponseTimeout = 60 * time.Second
+)
+
+// Settings configures how the app reaches the internet: the YouTube client, the
+// installer's downloads, yt-dlp and ffmpeg all use the same settings.
+type Settings struct {
+	ProxyURL      string `json:"proxyUrl,omitempty"` // http://, https:// or socks5://; empty uses the system proxy
+	ProxyUsername string `json:"proxyUsername,omitempty"`
+	ProxyPassword string `json:"proxyPassword,omitempty"`
+	CABundle      string `json:"caBundle,omitempty"` // PEM file of extra trusted certificates, e.g. for an inspecting proxy
+
+	// In seconds; zero for the defaults
+	ConnectTimeout  int `json:"connectTimeout,omitempty"`  // Connecting and the TLS handshake
+	ResponseTimeout int `json:"responseTimeout,omitempty"` // Waiting for a response to start
+}
+
+// Validate checks the settings without connecting anywhere
+func (s Settings) Validate() error {
+	if _, err := s.proxy(); err != nil {
+		return err
+	}
+	if s.CABundle != "" {
+		if _, err := s.certPool(); err != nil {
+			return err
+		}
+	}
+	if s.ConnectTimeout < 0 || s.ResponseTimeout < 0 {
+		return fmt.Errorf("timeouts can't be negative")
+	}
+	return nil
+}
+
+// proxy returns the proxy URL with the credentials filled in, or nil for none
+func (s Settings) proxy() (*url.URL, error) {
+	if s.ProxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", s.ProxyURL)
+	}
+	if s.ProxyUsername != "" {
+		u.User = url.UserPassword(s.ProxyUsername, s.ProxyPassword)
+	}
+	return u, nil
+}
+
+// certPool returns the system certificates plus those in CABundle
+func (s Settings) certPool() (*x509.CertPool, error) {
+	pem, err := os.ReadFile(s.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", s.CABundle)
+	}
+	return pool, nil
+}
+
+func (s Settings) connectTimeout() time.Duration {
+	if s.ConnectTimeout > 0 {
+		return time.Duration(s.ConnectTimeout) * time.Second
+	}
+	return DefaultConnectTimeout
+}
+
+func (s Settings) responseTimeout() time.Duration {
+	if s.ResponseTimeout > 0 {
+		return time.Duration(s.ResponseTimeout) * time.Second
+	}
+	return DefaultResponseTimeout
+}
+
+// Transport returns an HTTP transport that follows the settings. There is no overall
+// request timeout, so long downloads aren't cut off.
+func (s Settings) Transport() (*http.Transport, error) {
+	proxyURL, err := s.proxy()
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != nil {
+		// Unlike the system proxy this is used for every host, loopback included
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	t.DialContext = (&net.Dialer{
+		Timeout:   s.connectTimeout(),
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.TLSHandshakeTimeout = s.connectTimeout()
+	t.ResponseHeaderTimeout = s.responseTimeout()
+	if s.CABundle != "" {
+		pool, err := s.certPool()
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return t, nil
+}
+
+// Client returns an HTTP client that follows the settings
+func (s Settings) Client() (*http.Client, error) {
+	t, err := s.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+// YtdlpArgs returns the yt-dlp options for the settings. The proxy isn't among them
+// because its credentials would show up in the process list; see YtdlpEnv.
+func (s Settings) YtdlpArgs() []string {
+	var args []string
+	if s.ConnectTimeout > 0 || s.ResponseTimeout > 0 {
+		// yt-dlp has one timeout for connecting and for waiting on data, so it gets
+		// the longer of the two
+		timeout := max(s.connectTimeout(), s.responseTimeout())
+		args = append(args, "--socket-timeout", strconv.Itoa(int(timeout/time.Second)))
+	}
+	if s.CABundle != "" {
+		// Have yt-dlp load certificates from SSL_CERT_FILE (see YtdlpEnv) rather than
+		// the list it bundles
+		args = append(args, "--compat-options", "no-certifi")
+	}
+	return args
+}
+
+// YtdlpEnv returns the environment variables yt-dlp needs for the settings. yt-dlp
+// uses the proxy variables when it isn't given --proxy.
+func (s Settings) YtdlpEnv() []string {
+	var env []string
+	if proxyURL, err := s.proxy(); err == nil && proxyURL != nil {
+		env = append(env, proxyEnv("HTTP_PROXY", proxyURL)...)
+		env = append(env, proxyEnv("HTTPS_PROXY", proxyURL)...)
+		env = append(env, proxyEnv("ALL_PROXY", proxyURL)...)
+		env = append(env, noProxyEnv()...)
+	}
+	if s.CABundle != "" {
+		env = append(env, "SSL_CERT_FILE="+s.CABundle, "REQUESTS_CA_BUNDLE="+s.CABundle)
+	}
+	return env
+}
+
+// FFmpegArgs returns the ffmpeg options for the settings, to go before each -i that
+// reads from the network
+func (s Settings) FFmpegArgs() []string {
+	if s.CABundle == "" {
+		return nil
+	}
+	return []string{"-ca_file", s.CABundle}
+}
+
+// FFmpegEnv returns the environment variables ffmpeg needs for the settings. ffmpeg
+// takes the proxy, for https URLs too, from http_proxy and can only use an http://
+// one; other proxies are an error rather than being quietly bypassed.
+func (s Settings) FFmpegEnv() ([]string, error) {
+	proxyURL, err := s.proxy()
+	if err != nil || proxyURL == nil {
+		return nil, err
+	}
+	if proxyURL.Scheme != "http" {
+		return nil, fmt.Errorf("ffmpeg can't use a %s proxy; set an http:// proxy to record live streams and download sections", proxyURL.Scheme)
+	}
+	return append(proxyEnv("http_proxy", proxyURL), noProxyEnv()...), nil
+}
+
+// proxyEnv sets a proxy variable in both cases, since programs differ in which they read
+func proxyEnv(name string, proxyURL *url.URL) []string {
+	return []string{
+		strings.ToUpper(name) + "=" + proxyURL.String(),
+		strings.ToLower(name) + "=" + proxyURL.String(),
+	}
+}
+
+// noProxyEnv clears the inherited proxy exceptions: like the Transport's, the proxy
+// is used for every host
+func noProxyEnv() []string {
+	return []string{"NO_PROXY=", "no_proxy="}
+}
+
+// Check makes a request to url with the settings, to tell whether they work before
+// they are saved
+func (s Settings) Check(ctx context.Context, url string) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	client, err := s.Client()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", req.URL.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return fmt.Errorf("the proxy rejected the username or password")
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("failed to reach %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,619 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"yt-downloader/internal/youtube"
+)
+
+// State is the lifecycle state of a queued job
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateDownloading State = "downloading"
+	StateMuxing      State = "muxing"
+	StateDone        State = "done"
+	StateFailed      State = "failed"
+	StateCancelled   State = "cancelled"
+)
+
+// DefaultConcurrency is the number of downloads run at once unless configured otherwise
+const DefaultConcurrency = 2
+
+// ErrJobNotFound is returned when no job has the requested ID
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is a single download in the queue
+type Job struct {
+	ID            string                  `json:"id"`
+	URL           string                  `json:"url"`
+	Title         string                  `json:"title"`
+	State         State                   `json:"state"`
+	Progress      float64                 `json:"progress"`
+	Stage         youtube.Stage           `json:"stage,omitempty"`
+	Speed         float64                 `json:"speed,omitempty"` // Bytes per second while downloading
+	ETA           float64                 `json:"eta,omitempty"`   // Seconds remaining, -1 when unknown
+	Retry         int                     `json:"retry,omitempty"` // Set while waiting to retry a failed stream
+	MaxRetries    int                     `json:"maxRetries,omitempty"`
+	RetryReason   string                  `json:"retryReason,omitempty"`
+	Error         string                  `json:"error,omitempty"`
+	ErrorCategory youtube.ErrorCategory   `json:"errorCategory,omitempty"` // Why the job failed, for guidance
+	Result        *youtube.DownloadResult `json:"result,omitempty"`
+	CreatedAt     time.Time               `json:"createdAt"`
+	UpdatedAt     time.Time               `json:"updatedAt"`
+}
+
+// Finished reports whether the job has reached a terminal state
+func (j Job) Finished() bool {
+	return j.State == StateDone || j.State == StateFailed || j.State == StateCancelled
+}
+
+// RunFunc performs the download for a job. It reports progress and stage changes
+// through progressFn and must return promptly once ctx is cancelled.
+type RunFunc func(ctx context.Context, job Job, progressFn youtube.ProgressFunc) (*youtube.DownloadResult, error)
+
+// ChangeFunc is called with a snapshot of a job whenever it changes
+type ChangeFunc func(job Job)
+
+// PauseFunc is called when the schedule pauses or resumes the queue. resumeAt is when
+// a pause ends.
+type PauseFunc func(paused bool, resumeAt time.Time)
+
+// persistedQueue is the on-disk representation of the queue
+type persistedQueue struct {
+	Concurrency int      `json:"concurrency"`
+	Schedule    Schedule `json:"schedule"`
+	Jobs        []*Job   `json:"jobs"`
+}
+
+// runningJob tracks an in-flight job so it can be cancelled
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+	paused    bool // Interrupted by the schedule, to be resumed later
+}
+
+// Queue runs downloads in order with a bounded number of concurrent jobs.
+// The job list is saved to disk on every state change so it survives restarts.
+type Queue struct {
+	mu          sync.Mutex
+	path        string
+	jobs        []*Job
+	running     map[string]*runningJob
+	concurrency int
+	run         RunFunc
+	onChange    ChangeFunc
+	schedule    Schedule
+	clock       Clock
+	paused      bool
+	onPause     PauseFunc
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wake chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a queue persisted at path. Jobs that were in flight when the
+// queue was last saved are put back in the queued state.
+func New(path string, run RunFunc, onChange ChangeFunc) (*Queue, error) {
+	q := &Queue{
+		path:        path,
+		running:     make(map[string]*runningJob),
+		concurrency: DefaultConcurrency,
+		run:         run,
+		onChange:    onChange,
+		clock:       realClock{},
+		wake:        make(chan struct{}, 1),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// NewID returns a random job identifier
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Start begins processing queued jobs until ctx is cancelled or Stop is called
+func (q *Queue) Start(ctx context.Context) {
+	q.mu.Lock()
+	q.ctx, q.stop = context.WithCancel(ctx)
+	runCtx := q.ctx
+	q.mu.Unlock()
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		for {
+			// Wake up again when the schedule next opens or closes, checking at least
+			// every minute in case the computer slept or its clock was changed
+			var scheduleChange <-chan time.Time
+			if wait := q.dispatch(); wait > 0 {
+				scheduleChange = q.getClock().After(min(wait, time.Minute))
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-q.wake:
+			case <-scheduleChange:
+			}
+		}
+	}()
+}
+
+// Stop interrupts running jobs, waits for them to exit and saves the queue.
+// Interrupted jobs are left queued so they run again on the next start.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	stop := q.stop
+	q.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	q.wg.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_ = q.save()
+}
+
+// Enqueue adds a job to the end of the queue
+func (q *Queue) Enqueue(url string, title string) (Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:        NewID(),
+		URL:       url,
+		Title:     title,
+		State:     StateQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	err := q.save()
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.poke()
+	return snapshot, err
+}
+
+// List returns a snapshot of all jobs in queue order
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, *j)
+	}
+	return jobs
+}
+
+// Get returns a snapshot of the job with the given ID
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		return Job{}, ErrJobNotFound
+	}
+	return *q.jobs[idx], nil
+}
+
+// Move places a job at the given position in the queue (0 is the front)
+func (q *Queue) Move(id string, index int) error {
+	q.mu.Lock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		q.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(q.jobs) {
+		index = len(q.jobs) - 1
+	}
+	job := q.jobs[idx]
+	q.jobs = append(q.jobs[:idx], q.jobs[idx+1:]...)
+	q.jobs = append(q.jobs[:index], append([]*Job{job}, q.jobs[index:]...)...)
+	err := q.save()
+	q.mu.Unlock()
+
+	q.poke()
+	return err
+}
+
+// Cancel stops a queued or running job
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		q.mu.Unlock()
+		return ErrJobNotFound
+	}
+	job := q.jobs[idx]
+	if job.Finished() {
+		q.mu.Unlock()
+		return fmt.Errorf("job is already %s", job.State)
+	}
+
+	if r, ok := q.running[id]; ok {
+		// The worker records the final state once the download unwinds
+		r.cancelled = true
+		r.cancel()
+		q.mu.Unlock()
+		return nil
+	}
+
+	job.State = StateCancelled
+	job.UpdatedAt = time.Now()
+	err := q.save()
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	return err
+}
+
+// Retry puts a failed or cancelled job back in the queue. Any partial download it
+// left behind is resumed rather than started over.
+func (q *Queue) Retry(id string) error {
+	q.mu.Lock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		q.mu.Unlock()
+		return ErrJobNotFound
+	}
+	job := q.jobs[idx]
+	if job.State != StateFailed && job.State != StateCancelled {
+		q.mu.Unlock()
+		return fmt.Errorf("only failed or cancelled jobs can be retried")
+	}
+	job.State = StateQueued
+	job.Progress = 0
+	job.Error = ""
+	job.ErrorCategory = ""
+	job.UpdatedAt = time.Now()
+	err := q.save()
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.poke()
+	return err
+}
+
+// Remove deletes a job that is not currently running from the queue
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		return ErrJobNotFound
+	}
+	if _, ok := q.running[id]; ok {
+		return fmt.Errorf("cannot remove a running job")
+	}
+	q.jobs = append(q.jobs[:idx], q.jobs[idx+1:]...)
+	return q.save()
+}
+
+// Concurrency returns the maximum number of jobs run at once
+func (q *Queue) Concurrency() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.concurrency
+}
+
+// SetConcurrency changes the maximum number of jobs run at once.
+// Lowering it lets running jobs finish rather than interrupting them.
+func (q *Queue) SetConcurrency(n int) error {
+	if n < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	q.mu.Lock()
+	q.concurrency = n
+	err := q.save()
+	q.mu.Unlock()
+
+	q.poke()
+	return err
+}
+
+// SetSchedule limits when downloads run. Jobs running when the window closes are
+// interrupted and resumed when it opens again.
+func (q *Queue) SetSchedule(s Schedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.schedule = s
+	err := q.save()
+	q.mu.Unlock()
+
+	q.poke()
+	return err
+}
+
+// Schedule returns the window set with SetSchedule
+func (q *Queue) Schedule() Schedule {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.schedule
+}
+
+// Paused reports whether the schedule is holding jobs back, and until when
+func (q *Queue) Paused() (bool, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.paused {
+		return false, time.Time{}
+	}
+	return true, q.schedule.NextChange(q.clock.Now())
+}
+
+// OnPause sets the function called when the schedule pauses or resumes the queue
+func (q *Queue) OnPause(fn PauseFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onPause = fn
+}
+
+// SetClock replaces the clock the schedule is checked against. Call it before Start.
+func (q *Queue) SetClock(c Clock) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.clock = c
+}
+
+func (q *Queue) getClock() Clock {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.clock
+}
+
+// dispatch starts queued jobs until the concurrency limit is reached, or interrupts
+// running ones if the schedule doesn't allow downloads now. It returns how long until
+// the schedule next changes, 0 if it never does.
+func (q *Queue) dispatch() time.Duration {
+	q.mu.Lock()
+	now := q.clock.Now()
+	var wait time.Duration
+	if next := q.schedule.NextChange(now); !next.IsZero() {
+		wait = next.Sub(now)
+	}
+
+	paused := !q.schedule.Allows(now)
+	pauseChanged := paused != q.paused
+	q.paused = paused
+	onPause := q.onPause
+	if paused {
+		for _, r := range q.running {
+			if !r.cancelled && !r.paused {
+				r.paused = true
+				r.cancel()
+			}
+		}
+		q.mu.Unlock()
+		if pauseChanged && onPause != nil {
+			onPause(true, now.Add(wait))
+		}
+		return wait
+	}
+
+	var started []Job
+	for _, job := range q.jobs {
+		if len(q.running) >= q.concurrency || q.ctx.Err() != nil {
+			break
+		}
+		if job.State != StateQueued {
+			continue
+		}
+		jobCtx, cancel := context.WithCancel(q.ctx)
+		q.running[job.ID] = &runningJob{cancel: cancel}
+		job.State = StateDownloading
+		job.Progress = 0
+		job.Error = ""
+		job.ErrorCategory = ""
+		job.UpdatedAt = time.Now()
+		snapshot := *job
+		started = append(started, snapshot)
+
+		q.wg.Add(1)
+		go q.work(jobCtx, snapshot)
+	}
+	if len(started) > 0 {
+		_ = q.save()
+	}
+	q.mu.Unlock()
+
+	if pauseChanged && onPause != nil {
+		onPause(false, time.Time{})
+	}
+	for _, job := range started {
+		q.notify(job)
+	}
+	return wait
+}
+
+// work runs a single job and records its outcome
+func (q *Queue) work(ctx context.Context, job Job) {
+	defer q.wg.Done()
+
+	progressFn := func(p youtube.Progress) {
+		q.update(job.ID, func(j *Job) bool {
+			j.Progress = p.Fraction
+			j.Stage = p.Stage
+			j.Speed = p.Speed
+			j.ETA = p.ETA
+			j.Retry, j.MaxRetries, j.RetryReason = p.Retry, p.MaxRetries, p.RetryReason
+			state := StateDownloading
+			if p.Stage == youtube.StageMerging {
+				state = StateMuxing
+			}
+			if j.State == state {
+				return false
+			}
+			j.State = state
+			return true
+		})
+	}
+
+	result, err := q.run(ctx, job, progressFn)
+
+	q.mu.Lock()
+	r := q.running[job.ID]
+	delete(q.running, job.ID)
+	r.cancel()
+
+	idx := q.indexOf(job.ID)
+	if idx < 0 {
+		q.mu.Unlock()
+		q.poke()
+		return
+	}
+	j := q.jobs[idx]
+	switch {
+	case err == nil:
+		j.State = StateDone
+		j.Progress = 1
+		j.Result = result
+	case r.cancelled:
+		j.State = StateCancelled
+	case r.paused:
+		// Paused by the schedule: the partial download is resumed when it reopens
+		j.State = StateQueued
+		j.Progress = 0
+	case q.ctx.Err() != nil:
+		// Interrupted by shutdown: run it again next time
+		j.State = StateQueued
+		j.Progress = 0
+	default:
+		j.State = StateFailed
+		j.Error = err.Error()
+		j.ErrorCategory = youtube.CategoryOf(err)
+	}
+	j.Stage, j.Speed, j.ETA = "", 0, 0
+	j.Retry, j.MaxRetries, j.RetryReason = 0, 0, ""
+	j.UpdatedAt = time.Now()
+	_ = q.save()
+	snapshot := *j
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+	q.poke()
+}
+
+// update applies fn to a job and notifies listeners. Progress-only updates are
+// not persisted; fn returns true when the change should be saved.
+func (q *Queue) update(id string, fn func(j *Job) bool) {
+	q.mu.Lock()
+	idx := q.indexOf(id)
+	if idx < 0 {
+		q.mu.Unlock()
+		return
+	}
+	j := q.jobs[idx]
+	if fn(j) {
+		j.UpdatedAt = time.Now()
+		_ = q.save()
+	}
+	snapshot := *j
+	q.mu.Unlock()
+
+	q.notify(snapshot)
+}
+
+func (q *Queue) notify(job Job) {
+	if q.onChange != nil {
+		q.onChange(job)
+	}
+}
+
+// poke wakes the dispatcher without blocking
+func (q *Queue) poke() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) indexOf(id string) int {
+	for i, j := range q.jobs {
+		if j.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read queue: %w", err)
+	}
+
+	var pq persistedQueue
+	if err := json.Unmarshal(data, &pq); err != nil {
+		return fmt.Errorf("failed to parse queue: %w", err)
+	}
+	if pq.Concurrency > 0 {
+		q.concurrency = pq.Concurrency
+	}
+	if pq.Schedule.Validate() == nil {
+		q.schedule = pq.Schedule
+	}
+	for _, j := range pq.Jobs {
+		if j == nil || j.ID == "" {
+			continue
+		}
+		if j.State == StateDownloading || j.State == StateMuxing {
+			j.State = StateQueued
+			j.Progress = 0
+		}
+		q.jobs = append(q.jobs, j)
+	}
+	return nil
+}
+
+// save writes the queue to disk atomically. Callers must hold q.mu.
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(persistedQueue{Concurrency: q.concurrency, Schedule: q.schedule, Jobs: q.jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("failed to write queue: %w", err)
+	}
+	return nil
+}
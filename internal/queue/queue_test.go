@@ -0,0 +1,407 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yt-downloader/internal/youtube"
+)
+
+const testURL = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+// stubRunner is a RunFunc whose jobs run until finish is called for them or they
+// are cancelled. It records how many ran at once.
+type stubRunner struct {
+	started chan string // IDs of jobs as they start
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	results map[string]chan error
+}
+
+func newStubRunner() *stubRunner {
+	return &stubRunner{started: make(chan string, 16), results: make(map[string]chan error)}
+}
+
+func (s *stubRunner) result(id string) chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.results[id] == nil {
+		s.results[id] = make(chan error, 1)
+	}
+	return s.results[id]
+}
+
+// finish ends the job's current run with err, or successfully if err is nil
+func (s *stubRunner) finish(id string, err error) {
+	s.result(id) <- err
+}
+
+func (s *stubRunner) run(ctx context.Context, job Job, progressFn youtube.ProgressFunc) (*youtube.DownloadResult, error) {
+	s.mu.Lock()
+	s.running++
+	s.peak = max(s.peak, s.running)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	s.started <- job.ID
+	progressFn(youtube.Progress{Fraction: 0.5, Stage: youtube.StageDownloading})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-s.result(job.ID):
+		if err != nil {
+			return nil, err
+		}
+		return &youtube.DownloadResult{FilePath: job.ID + ".mp4"}, nil
+	}
+}
+
+func (s *stubRunner) peakRunning() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+// waitStarted waits for the next job to start and returns its ID
+func (s *stubRunner) waitStarted(t *testing.T) string {
+	t.Helper()
+	select {
+	case id := <-s.started:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+		return ""
+	}
+}
+
+// noneStarted checks that no job starts for a moment
+func (s *stubRunner) noneStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case id := <-s.started:
+		t.Fatalf("job %s started", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func enqueue(t *testing.T, q *Queue, title string) Job {
+	t.Helper()
+	job, err := q.Enqueue(testURL, title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func titles(q *Queue) string {
+	var titles []string
+	for _, j := range q.List() {
+		titles = append(titles, j.Title)
+	}
+	return strings.Join(titles, ",")
+}
+
+func TestQueueReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	saved := persistedQueue{
+		Concurrency: 3,
+		Schedule:    Schedule{Enabled: true, Start: "01:00", End: "06:00"},
+		Jobs: []*Job{
+			{ID: "a", Title: "downloading", State: StateDownloading, Progress: 0.4},
+			{ID: "b", Title: "muxing", State: StateMuxing, Progress: 0.9},
+			{ID: "c", Title: "queued", State: StateQueued},
+			{ID: "d", Title: "done", State: StateDone, Progress: 1, Result: &youtube.DownloadResult{FilePath: "d.mp4"}},
+			{ID: "e", Title: "failed", State: StateFailed, Error: "video unavailable"},
+			nil,
+			{Title: "no ID"},
+		},
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := New(path, newStubRunner().run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(q); got != "downloading,muxing,queued,done,failed" {
+		t.Fatalf("reloaded jobs = %s", got)
+	}
+	// Jobs that were in flight come back queued, to run again from the start
+	want := map[string]State{"a": StateQueued, "b": StateQueued, "c": StateQueued, "d": StateDone, "e": StateFailed}
+	for _, j := range q.List() {
+		if j.State != want[j.ID] {
+			t.Errorf("job %s is %s, want %s", j.ID, j.State, want[j.ID])
+		}
+		if j.State == StateQueued && j.Progress != 0 {
+			t.Errorf("requeued job %s kept progress %v", j.ID, j.Progress)
+		}
+	}
+	if d, _ := q.Get("d"); d.Result == nil || d.Result.FilePath != "d.mp4" {
+		t.Errorf("finished job lost its result: %+v", d.Result)
+	}
+	if e, _ := q.Get("e"); e.Error != "video unavailable" {
+		t.Errorf("failed job lost its error: %q", e.Error)
+	}
+	if q.Concurrency() != 3 || q.Schedule() != saved.Schedule {
+		t.Errorf("reloaded concurrency %d and schedule %+v, want %d and %+v", q.Concurrency(), q.Schedule(), saved.Concurrency, saved.Schedule)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path, newStubRunner().run, nil); err == nil {
+		t.Error("expected an error for a corrupt queue file")
+	}
+}
+
+func TestQueueStopRequeuesRunningJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	runner := newStubRunner()
+	q, err := New(path, runner.run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start(context.Background())
+	job := enqueue(t, q, "interrupted")
+	runner.waitStarted(t)
+	waitFor(t, q, job.ID, "started", func(j Job) bool { return j.State == StateDownloading && j.Progress == 0.5 })
+	q.Stop()
+
+	if j, _ := q.Get(job.ID); j.State != StateQueued || j.Progress != 0 {
+		t.Errorf("after Stop the job is %s at %v, want queued at 0", j.State, j.Progress)
+	}
+	reloaded, err := New(path, runner.run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j, err := reloaded.Get(job.ID); err != nil || j.State != StateQueued {
+		t.Errorf("reloaded job = %+v, %v; want it queued", j, err)
+	}
+}
+
+func TestQueueMove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := New(path, newStubRunner().run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := enqueue(t, q, "a"), enqueue(t, q, "b"), enqueue(t, q, "c")
+
+	tests := []struct {
+		id    string
+		index int
+		want  string
+	}{
+		{c.ID, 0, "c,a,b"},
+		{c.ID, 1, "a,c,b"},
+		{a.ID, 2, "c,b,a"},
+		{a.ID, -5, "a,c,b"}, // Before the front
+		{b.ID, 99, "a,c,b"}, // Past the end
+		{a.ID, 99, "c,b,a"},
+	}
+	for _, tt := range tests {
+		if err := q.Move(tt.id, tt.index); err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(q); got != tt.want {
+			t.Errorf("Move(%s, %d) = %s, want %s", tt.id, tt.index, got, tt.want)
+		}
+	}
+	if err := q.Move("missing", 0); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Move of a missing job: err = %v, want ErrJobNotFound", err)
+	}
+
+	reloaded, err := New(path, newStubRunner().run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(reloaded); got != "c,b,a" {
+		t.Errorf("reloaded order = %s, want c,b,a", got)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	runner := newStubRunner()
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), runner.run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetConcurrency(1); err != nil {
+		t.Fatal(err)
+	}
+	q.Start(context.Background())
+	defer q.Stop()
+
+	running, waiting := enqueue(t, q, "running"), enqueue(t, q, "waiting")
+	if id := runner.waitStarted(t); id != running.ID {
+		t.Fatalf("job %s started first, want %s", id, running.ID)
+	}
+
+	// A queued job is cancelled at once and never runs
+	if err := q.Cancel(waiting.ID); err != nil {
+		t.Fatal(err)
+	}
+	if j, _ := q.Get(waiting.ID); j.State != StateCancelled {
+		t.Errorf("cancelled queued job is %s", j.State)
+	}
+
+	// A running one is stopped, and ends cancelled rather than failed
+	if err := q.Cancel(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, q, running.ID, "cancelled", func(j Job) bool { return j.State == StateCancelled })
+	if j, _ := q.Get(running.ID); j.Error != "" {
+		t.Errorf("cancelled job has error %q", j.Error)
+	}
+	runner.noneStarted(t)
+
+	if err := q.Cancel(running.ID); err == nil || !strings.Contains(err.Error(), "already cancelled") {
+		t.Errorf("second Cancel: err = %v, want already cancelled", err)
+	}
+	if err := q.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Cancel of a missing job: err = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestQueueRetryAndRemove(t *testing.T) {
+	runner := newStubRunner()
+	var mu sync.Mutex
+	var changes []Job
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), runner.run, func(j Job) {
+		mu.Lock()
+		changes = append(changes, j)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start(context.Background())
+	defer q.Stop()
+
+	job := enqueue(t, q, "flaky")
+	runner.waitStarted(t)
+
+	// Running jobs can't be retried or removed
+	if err := q.Retry(job.ID); err == nil {
+		t.Error("Retry of a running job succeeded")
+	}
+	if err := q.Remove(job.ID); err == nil {
+		t.Error("Remove of a running job succeeded")
+	}
+
+	runner.finish(job.ID, errors.New("connection reset"))
+	waitFor(t, q, job.ID, "failed", func(j Job) bool { return j.State == StateFailed })
+	if j, _ := q.Get(job.ID); j.Error != "connection reset" {
+		t.Errorf("failed job error = %q", j.Error)
+	}
+
+	// A failed job runs again, and its error is cleared
+	if err := q.Retry(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id := runner.waitStarted(t); id != job.ID {
+		t.Fatalf("job %s started, want the retried one", id)
+	}
+	runner.finish(job.ID, nil)
+	waitFor(t, q, job.ID, "done", func(j Job) bool { return j.State == StateDone })
+	if j, _ := q.Get(job.ID); j.Error != "" || j.Progress != 1 || j.Result == nil || j.Result.FilePath != job.ID+".mp4" {
+		t.Errorf("finished job = %+v", j)
+	}
+
+	// Finished jobs can't be retried, but can be removed
+	if err := q.Retry(job.ID); err == nil {
+		t.Error("Retry of a finished job succeeded")
+	}
+	if err := q.Remove(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Get(job.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("removed job: err = %v, want ErrJobNotFound", err)
+	}
+	for name, fn := range map[string]func(string) error{"Retry": q.Retry, "Remove": q.Remove} {
+		if err := fn(job.ID); !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("%s of a removed job: err = %v, want ErrJobNotFound", name, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var states []string
+	for _, c := range changes {
+		if len(states) == 0 || states[len(states)-1] != string(c.State) {
+			states = append(states, string(c.State))
+		}
+	}
+	if got := strings.Join(states, ","); got != "queued,downloading,failed,queued,downloading,done" {
+		t.Errorf("reported states = %s", got)
+	}
+}
+
+func TestQueueConcurrency(t *testing.T) {
+	runner := newStubRunner()
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), runner.run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Concurrency() != DefaultConcurrency {
+		t.Errorf("Concurrency = %d, want %d", q.Concurrency(), DefaultConcurrency)
+	}
+	if err := q.SetConcurrency(0); err == nil {
+		t.Error("SetConcurrency(0) succeeded")
+	}
+	q.Start(context.Background())
+	defer q.Stop()
+
+	var jobs []Job
+	for _, title := range []string{"1", "2", "3", "4"} {
+		jobs = append(jobs, enqueue(t, q, title))
+	}
+	// Jobs start in queue order, two at a time
+	first, second := runner.waitStarted(t), runner.waitStarted(t)
+	if first != jobs[0].ID && first != jobs[1].ID || second != jobs[0].ID && second != jobs[1].ID {
+		t.Errorf("started %s and %s, want the first two jobs", first, second)
+	}
+	runner.noneStarted(t)
+
+	// Raising the limit starts the next job at once
+	if err := q.SetConcurrency(3); err != nil {
+		t.Fatal(err)
+	}
+	if id := runner.waitStarted(t); id != jobs[2].ID {
+		t.Errorf("job %s started, want the third", id)
+	}
+	runner.noneStarted(t)
+
+	// Finishing one makes room for the last
+	runner.finish(jobs[0].ID, nil)
+	if id := runner.waitStarted(t); id != jobs[3].ID {
+		t.Errorf("job %s started, want the fourth", id)
+	}
+	for _, j := range jobs[1:] {
+		runner.finish(j.ID, nil)
+	}
+	for _, j := range jobs {
+		waitFor(t, q, j.ID, "done", func(j Job) bool { return j.State == StateDone })
+	}
+	if peak := runner.peakRunning(); peak != 3 {
+		t.Errorf("%d jobs ran at once, want 3", peak)
+	}
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule restricts downloads to a daily window of local time, such as 18:00 to
+// 07:00. A window whose end is before its start runs past midnight.
+type Schedule struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"` // "HH:MM"
+	End     string `json:"end"`   // "HH:MM"; the same as Start for all day
+}
+
+// Clock tells the queue the time, so schedules can be tested without waiting
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// parseClockTime parses "HH:MM" into minutes after midnight
+func parseClockTime(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks the window's times
+func (s Schedule) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if _, err := parseClockTime(s.Start); err != nil {
+		return err
+	}
+	if _, err := parseClockTime(s.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Allows reports whether downloads may run at t
+func (s Schedule) Allows(t time.Time) bool {
+	if !s.Enabled {
+		return true
+	}
+	start, err1 := parseClockTime(s.Start)
+	end, err2 := parseClockTime(s.End)
+	if err1 != nil || err2 != nil || start == end {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// NextChange returns when Allows next changes after t, or the zero time if it never does
+func (s Schedule) NextChange(t time.Time) time.Time {
+	if !s.Enabled {
+		return time.Time{}
+	}
+	start, err1 := parseClockTime(s.Start)
+	end, err2 := parseClockTime(s.End)
+	if err1 != nil || err2 != nil || start == end {
+		return time.Time{}
+	}
+	var next time.Time
+	for day := 0; day <= 1; day++ {
+		for _, m := range []int{start, end} {
+			c := time.Date(t.Year(), t.Month(), t.Day()+day, m/60, m%60, 0, 0, t.Location())
+			if c.After(t) && (next.IsZero() || c.Before(next)) {
+				next = c
+			}
+		}
+	}
+	return next
+}
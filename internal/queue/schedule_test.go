@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"yt-downloader/internal/youtube"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 3, 10, hour, minute, 0, 0, time.Local)
+}
+
+func TestScheduleAllows(t *testing.T) {
+	overnight := Schedule{Enabled: true, Start: "18:00", End: "07:00"}
+	daytime := Schedule{Enabled: true, Start: "09:30", End: "17:00"}
+	tests := []struct {
+		s    Schedule
+		t    time.Time
+		want bool
+	}{
+		{overnight, at(12, 0), false},
+		{overnight, at(17, 59), false},
+		{overnight, at(18, 0), true},
+		{overnight, at(23, 30), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(7, 0), false},
+		{daytime, at(9, 29), false},
+		{daytime, at(9, 30), true},
+		{daytime, at(16, 59), true},
+		{daytime, at(17, 0), false},
+		{Schedule{Enabled: true, Start: "08:00", End: "08:00"}, at(3, 0), true},
+		{Schedule{Start: "18:00", End: "07:00"}, at(12, 0), true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Allows(tt.t); got != tt.want {
+			t.Errorf("%+v.Allows(%s) = %v, want %v", tt.s, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextChange(t *testing.T) {
+	overnight := Schedule{Enabled: true, Start: "18:00", End: "07:00"}
+	tests := []struct {
+		t    time.Time
+		want time.Time
+	}{
+		{at(12, 0), at(18, 0)},
+		{at(18, 0), at(31, 0)}, // 07:00 the next day
+		{at(3, 0), at(7, 0)},
+		{at(7, 0), at(18, 0)},
+	}
+	for _, tt := range tests {
+		if got := overnight.NextChange(tt.t); !got.Equal(tt.want) {
+			t.Errorf("NextChange(%s) = %s, want %s", tt.t, got, tt.want)
+		}
+	}
+	if got := (Schedule{}).NextChange(at(12, 0)); !got.IsZero() {
+		t.Errorf("NextChange without a schedule = %s, want never", got)
+	}
+}
+
+// fakeClock is a Clock whose time only moves when set is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// set moves the clock to t, firing the timers that are due by then
+func (c *fakeClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	var pending []fakeTimer
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- t
+	}
+	c.waiters = pending
+}
+
+// waitFor waits until cond holds for the job, failing the test after a while
+func waitFor(t *testing.T, q *Queue, id string, what string, cond func(Job) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := q.Get(id); err == nil && cond(job) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := q.Get(id)
+	t.Fatalf("job never %s; it is %s", what, job.State)
+}
+
+func TestQueueFollowsSchedule(t *testing.T) {
+	clock := &fakeClock{now: at(12, 0)}
+	runs := make(chan context.Context, 4)
+	finish := make(chan struct{})
+	run := func(ctx context.Context, job Job, progressFn youtube.ProgressFunc) (*youtube.DownloadResult, error) {
+		runs <- ctx
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-finish:
+			return &youtube.DownloadResult{FilePath: "video.mp4"}, nil
+		}
+	}
+
+	q, err := New(filepath.Join(t.TempDir(), "queue.json"), run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type pause struct {
+		paused   bool
+		resumeAt time.Time
+	}
+	pauses := make(chan pause, 8)
+	q.OnPause(func(paused bool, resumeAt time.Time) { pauses <- pause{paused, resumeAt} })
+	q.SetClock(clock)
+	if err := q.SetSchedule(Schedule{Enabled: true, Start: "18:00", End: "07:00"}); err != nil {
+		t.Fatal(err)
+	}
+	q.Start(context.Background())
+	defer q.Stop()
+
+	// Outside the window the job waits, and the queue says until when
+	job, err := q.Enqueue("https://www.youtube.com/watch?v=dQw4w9WgXcQ", "Video")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := <-pauses; !p.paused || !p.resumeAt.Equal(at(18, 0)) {
+		t.Fatalf("OnPause(%v, %s), want a pause until 18:00", p.paused, p.resumeAt)
+	}
+	if paused, until := q.Paused(); !paused || !until.Equal(at(18, 0)) {
+		t.Errorf("Paused() = %v, %s; want true, 18:00", paused, until)
+	}
+	select {
+	case <-runs:
+		t.Fatal("the job ran outside the window")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The window opens
+	clock.set(at(18, 0))
+	ctx := <-runs
+	if p := <-pauses; p.paused {
+		t.Fatal("OnPause reported a pause when the window opened")
+	}
+	waitFor(t, q, job.ID, "started", func(j Job) bool { return j.State == StateDownloading })
+
+	// It closes at 07:00 the next morning, interrupting the download
+	clock.set(at(31, 0))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the running job wasn't interrupted when the window closed")
+	}
+	waitFor(t, q, job.ID, "requeued", func(j Job) bool { return j.State == StateQueued })
+	if p := <-pauses; !p.paused || !p.resumeAt.Equal(at(42, 0)) {
+		t.Fatalf("OnPause(%v, %s), want a pause until 18:00", p.paused, p.resumeAt)
+	}
+
+	// And the download resumes when it opens again
+	clock.set(at(42, 0))
+	<-runs
+	close(finish)
+	waitFor(t, q, job.ID, "finished", func(j Job) bool { return j.State == StateDone })
+}
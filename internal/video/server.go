@@ -10,24 +10,40 @@ import (
 	"sync"
 )
 
+// mediaTypes covers the preview formats the system MIME table may not know
+var mediaTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".m4a":  "audio/mp4",
+}
+
 // Server serves video files for HTML5 preview
 type Server struct {
 	mu             sync.RWMutex
-	allowedDir     string
+	allowedDirs    []string
 	currentVideo   string
 	currentVideoID string
+	currentOpened  bool              // currentVideo was opened by the user and may be outside allowedDirs
+	thumbnails     map[string]string // video ID -> local image path
 }
 
 // NewServer creates a new video server
 func NewServer() *Server {
-	return &Server{}
+	return &Server{thumbnails: make(map[string]string)}
 }
 
 // SetAllowedDir sets the directory from which videos can be served
 func (s *Server) SetAllowedDir(dir string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.allowedDir = dir
+	s.allowedDirs = []string{dir}
+}
+
+// AddAllowedDir adds another directory from which videos can be served
+func (s *Server) AddAllowedDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowedDirs = append(s.allowedDirs, dir)
 }
 
 // SetCurrentVideo sets the current video file that can be served
@@ -36,6 +52,17 @@ func (s *Server) SetCurrentVideo(path string, videoID string) {
 	defer s.mu.Unlock()
 	s.currentVideo = path
 	s.currentVideoID = videoID
+	s.currentOpened = false
+}
+
+// SetOpenedVideo sets the current video to a file the user opened themselves, which
+// is served even if it's outside the allowed directories
+func (s *Server) SetOpenedVideo(path string, videoID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentVideo = path
+	s.currentVideoID = videoID
+	s.currentOpened = true
 }
 
 // GetCurrentVideoURL returns the URL path for the current video
@@ -55,8 +82,30 @@ func (s *Server) GetCurrentVideoPath() string {
 	return s.currentVideo
 }
 
-// ServeHTTP implements http.Handler for serving video files
+// SetThumbnail registers the local thumbnail image for a video
+func (s *Server) SetThumbnail(videoID string, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.thumbnails[videoID] = path
+}
+
+// GetThumbnailURL returns the URL path for a video's thumbnail, or "" if none is registered
+func (s *Server) GetThumbnailURL(videoID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.thumbnails[videoID]; !ok {
+		return ""
+	}
+	return fmt.Sprintf("/thumb/%s", videoID)
+}
+
+// ServeHTTP implements http.Handler for serving video files and thumbnails
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/thumb/") {
+		s.serveThumbnail(w, r)
+		return
+	}
+
 	// Only handle paths starting with /video/
 	if !strings.HasPrefix(r.URL.Path, "/video/") {
 		http.NotFound(w, r)
@@ -70,7 +119,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
 	videoPath := s.currentVideo
 	videoID := s.currentVideoID
-	allowedDir := s.allowedDir
+	opened := s.currentOpened
+	allowedDirs := s.allowedDirs
 	s.mu.RUnlock()
 
 	// Security check: verify the requested video matches current
@@ -79,22 +129,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Security check: ensure video is in allowed directory
-	if allowedDir != "" {
-		absPath, err := filepath.Abs(videoPath)
-		if err != nil {
-			http.Error(w, "Invalid path", http.StatusBadRequest)
-			return
-		}
-		absAllowed, err := filepath.Abs(allowedDir)
-		if err != nil {
-			http.Error(w, "Invalid directory", http.StatusInternalServerError)
-			return
-		}
-		if !strings.HasPrefix(absPath, absAllowed) {
-			http.Error(w, "Access denied", http.StatusForbidden)
-			return
-		}
+	// Security check: ensure video is in an allowed directory, unless the user chose it
+	if status, msg := checkAllowed(videoPath, allowedDirs); status != http.StatusOK && !opened {
+		http.Error(w, msg, status)
+		return
 	}
 
 	// Check if file exists
@@ -122,6 +160,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Set content type for video
 	contentType := mime.TypeByExtension(filepath.Ext(videoPath))
+	if contentType == "" {
+		contentType = mediaTypes[strings.ToLower(filepath.Ext(videoPath))]
+	}
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
@@ -139,4 +180,71 @@ func (s *Server) ClearVideo() {
 	defer s.mu.Unlock()
 	s.currentVideo = ""
 	s.currentVideoID = ""
+	s.currentOpened = false
+}
+
+// serveThumbnail serves a registered thumbnail image from /thumb/{id}
+func (s *Server) serveThumbnail(w http.ResponseWriter, r *http.Request) {
+	videoID := strings.TrimPrefix(r.URL.Path, "/thumb/")
+	videoID = strings.Split(videoID, "/")[0]
+
+	s.mu.RLock()
+	path, ok := s.thumbnails[videoID]
+	allowedDirs := s.allowedDirs
+	s.mu.RUnlock()
+
+	if !ok || path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if status, msg := checkAllowed(path, allowedDirs); status != http.StatusOK {
+		http.Error(w, msg, status)
+		return
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not open file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(w, "Not a file", http.StatusBadRequest)
+		return
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), file)
+}
+
+// checkAllowed returns http.StatusOK if path is inside one of allowedDirs (or no
+// directories are configured), otherwise the status and message to respond with.
+func checkAllowed(path string, allowedDirs []string) (int, string) {
+	if len(allowedDirs) == 0 {
+		return http.StatusOK, ""
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return http.StatusBadRequest, "Invalid path"
+	}
+	for _, dir := range allowedDirs {
+		absAllowed, err := filepath.Abs(dir)
+		if err != nil {
+			return http.StatusInternalServerError, "Invalid directory"
+		}
+		if strings.HasPrefix(absPath, absAllowed) {
+			return http.StatusOK, ""
+		}
+	}
+	return http.StatusForbidden, "Access denied"
 }
@@ -0,0 +1,164 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// downloadAudio fetches only the audio of a video with the Go library, as M4A where
+// possible, for audio-only previews. If section is set only that range is fetched.
+func (d *Downloader) downloadAudio(ctx context.Context, video *youtube.Video, destDir string, ffmpegPath string, section *Section, formats FormatOptions, tracker *progressTracker) (*DownloadResult, error) {
+	format, err := selectAudioFormat(video.Formats, formats.AudioItag)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := sanitizeFilename(video.Title)
+	outPath := filepath.Join(destDir, baseName+"-audio.m4a")
+	offset := 0.0
+	if section != nil {
+		offset = section.Start
+	}
+
+	fmt.Printf("[DEBUG] Selected audio format: mime=%s, bitrate=%d\n", format.MimeType, format.Bitrate)
+
+	if section != nil {
+		if ffmpegPath == "" {
+			return nil, fmt.Errorf("ffmpeg is required to download part of a video")
+		}
+		// ffmpeg re-encodes to AAC while it seeks into the stream
+		if err := d.downloadSectionStreams(ctx, video, format, nil, *section, outPath, ffmpegPath, tracker); err != nil {
+			return nil, fmt.Errorf("failed to download audio: %w", err)
+		}
+		return &DownloadResult{FilePath: outPath, Method: "audio", Offset: offset, AudioOnly: true}, nil
+	}
+
+	destPath := filepath.Join(destDir, baseName+"-audio"+audioExtension(format.MimeType))
+	tracker.setStage(StageDownloadingAudio)
+	if err := d.downloadToFile(ctx, video, format, destPath, tracker.callback(format.ContentLength), tracker); err != nil {
+		return nil, fmt.Errorf("failed to download audio: %w", err)
+	}
+
+	result := &DownloadResult{FilePath: destPath, Method: "audio", AudioOnly: true}
+	if destPath != outPath && ffmpegPath != "" {
+		// WebKit can't play Opus in WebM everywhere; AAC in M4A plays in every webview
+		if err := transcodeAudioToM4A(ctx, ffmpegPath, destPath, outPath); err == nil {
+			_ = os.Remove(destPath)
+			result.FilePath = outPath
+		} else {
+			fmt.Printf("[DEBUG] Audio transcode failed: %v, keeping %s\n", err, filepath.Ext(destPath))
+		}
+	}
+	return result, nil
+}
+
+// selectAudioFormat picks the audio stream for an audio-only download: itag if set,
+// otherwise the best AAC stream, which plays everywhere without transcoding, or else
+// the best audio stream of any kind
+func selectAudioFormat(formats youtube.FormatList, itag int) (*youtube.Format, error) {
+	if itag != 0 {
+		f := findFormat(formats, itag)
+		if f == nil {
+			return nil, fmt.Errorf("format %d is not available for this video", itag)
+		}
+		if f.AudioChannels <= 0 {
+			return nil, fmt.Errorf("format %d has no audio", itag)
+		}
+		return f, nil
+	}
+
+	var audioOnly, aac []youtube.Format
+	for _, f := range formats {
+		if f.AudioChannels <= 0 || !strings.HasPrefix(f.MimeType, "audio/") {
+			continue
+		}
+		audioOnly = append(audioOnly, f)
+		if strings.Contains(f.MimeType, "mp4a") {
+			aac = append(aac, f)
+		}
+	}
+	if best := pickBestAudioOnly(aac); best != nil {
+		return best, nil
+	}
+	if best := pickBestAudioOnly(audioOnly); best != nil {
+		return best, nil
+	}
+	return nil, fmt.Errorf("no audio stream found")
+}
+
+// downloadAudioWithYtdlp has yt-dlp fetch the audio and extract it to M4A at outPath
+func downloadAudioWithYtdlp(ctx context.Context, url string, outPath string, ffmpegPath string, ytdlpPath string, selector string, section *Section, cfg ytdlpConfig, tracker *progressTracker) ([]Chapter, error) {
+	// yt-dlp picks the extension, so give it a template and let -x settle on .m4a
+	base := strings.TrimSuffix(outPath, filepath.Ext(outPath))
+	args := []string{
+		"-f", selector,
+		"-x",
+		"--audio-format", "m4a",
+		"--ffmpeg-location", filepath.Dir(ffmpegPath),
+		"-o", base + ".%(ext)s",
+		"--no-playlist",
+		"--no-warnings",
+		"--newline",
+		"--progress-template", ytdlpDownloadTemplate,
+		"--progress-template", ytdlpPostprocessTemplate,
+		"--write-info-json",
+	}
+	if section != nil {
+		args = append(args, "--download-sections", fmt.Sprintf("*%.3f-%.3f", section.Start, section.End))
+	}
+	target, err := ytdlpTarget(url)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, cfg.args...)
+	args = append(args, target...)
+
+	infoPath := base + ".info.json"
+	defer os.Remove(infoPath)
+
+	if err := runYtdlp(ctx, ytdlpPath, args, cfg, tracker); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(outPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("output file not created")
+	}
+	return readYtdlpChapters(infoPath), nil
+}
+
+func audioExtension(mimeType string) string {
+	switch {
+	case strings.Contains(mimeType, "audio/mp4"):
+		return ".m4a"
+	case strings.Contains(mimeType, "audio/webm"):
+		return ".webm"
+	default:
+		return ".audio"
+	}
+}
+
+func transcodeAudioToM4A(ctx context.Context, ffmpegPath string, inputPath string, outputPath string) error {
+	cmd := exec.CommandContext(ctx, ffmpegPath,
+		"-y",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", inputPath,
+		"-vn",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package youtube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCacheSize is how many bytes of downloads a SourceCache keeps by default
+	DefaultCacheSize = 10 << 30
+
+	// cacheEntryName is the description of a cached download, next to its file
+	cacheEntryName = "entry.json"
+
+	// cacheIncomingPrefix marks an entry directory that is still being written
+	cacheIncomingPrefix = ".incoming-"
+)
+
+// SourceCache keeps finished preview downloads on disk, keyed by video ID and the
+// formats asked for, so loading the same video again doesn't download it again.
+// Each entry records the file's size, modification time and SHA-256 when it is
+// stored. The size and time are checked before it is reused and the hash as it is
+// copied out, so a file that was changed, cut short or corrupted is downloaded again
+// instead of being served.
+type SourceCache struct {
+	dir      string
+	maxBytes int64 // Least recently used entries are removed beyond this; 0 for no limit
+}
+
+// cacheEntry is what a SourceCache knows about a cached download
+type cacheEntry struct {
+	VideoID  string         `json:"videoId"`
+	Formats  FormatOptions  `json:"formats"`
+	Info     *VideoInfo     `json:"info,omitempty"` // As resolved before the download, if known
+	Result   DownloadResult `json:"result"`         // FilePath is the file's name in the entry directory
+	Size     int64          `json:"size"`
+	ModTime  time.Time      `json:"modTime"`
+	SHA256   string         `json:"sha256"`
+	StoredAt time.Time      `json:"storedAt"`
+}
+
+// OpenSourceCache opens the cache in dir, creating it if needed. Entries written
+// by an earlier run are reused; ones that were never finished are removed.
+func OpenSourceCache(dir string, maxBytes int64) (*SourceCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	names, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name.Name(), cacheIncomingPrefix) {
+			_ = os.RemoveAll(filepath.Join(dir, name.Name()))
+		}
+	}
+	return &SourceCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// SetCache makes DownloadForPreview reuse downloads from cache and store new ones
+// in it; nil turns caching off
+func (d *Downloader) SetCache(cache *SourceCache) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache = cache
+}
+
+// CachedPreview returns the cached download of url with formats, copied into destDir,
+// and the video info it was stored with. It doesn't touch the network, so a video
+// loaded before opens without resolving it again, offline too.
+func (d *Downloader) CachedPreview(url string, destDir string, formats FormatOptions) (*VideoInfo, *DownloadResult, bool) {
+	cache, key := d.cacheFor(url, formats)
+	if cache == nil {
+		return nil, nil, false
+	}
+	entry, result, ok := cache.lookup(key, destDir)
+	if !ok || entry.Info == nil {
+		return nil, nil, false
+	}
+	fmt.Printf("[DEBUG] Using cached download %s\n", key)
+	return entry.Info, result, true
+}
+
+// cacheFor returns the cache and the key for a download, or nil if it can't be
+// cached because no cache is set or url isn't a YouTube video
+func (d *Downloader) cacheFor(url string, formats FormatOptions) (*SourceCache, string) {
+	d.mu.Lock()
+	cache := d.cache
+	d.mu.Unlock()
+	if cache == nil {
+		return nil, ""
+	}
+	parsed, err := ParseURL(url)
+	if err != nil {
+		return nil, ""
+	}
+	return cache, cacheKey(parsed.ID, formats)
+}
+
+// cacheKey names the cache entry for a video downloaded with formats. Everything
+// that changes which streams are picked goes into the key.
+func cacheKey(videoID string, formats FormatOptions) string {
+	spec, _ := json.Marshal(formats)
+	sum := sha256.Sum256(append([]byte(videoID+"\n"), spec...))
+	return videoID + "-" + hex.EncodeToString(sum[:8])
+}
+
+// lookup puts a copy of the cached download for key in destDir, as if it had just
+// been downloaded there. A missing or damaged entry is a miss; damaged ones are removed.
+func (c *SourceCache) lookup(key string, destDir string) (*cacheEntry, *DownloadResult, bool) {
+	dir := filepath.Join(c.dir, key)
+	entry, err := readCacheEntry(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("[DEBUG] Discarding cached download %s: %v\n", key, err)
+			_ = os.RemoveAll(dir)
+		}
+		return nil, nil, false
+	}
+
+	cached := filepath.Join(dir, entry.Result.FilePath)
+	if err := verifyCachedFile(cached, entry.Size, entry.ModTime); err != nil {
+		fmt.Printf("[DEBUG] Discarding cached download %s: %v\n", key, err)
+		_ = os.RemoveAll(dir)
+		return nil, nil, false
+	}
+
+	// A copy, not a link: whatever later rewrites the preview in place must not
+	// reach the cached file
+	destPath := filepath.Join(destDir, entry.Result.FilePath)
+	sum, err := copyFile(cached, destPath)
+	if err != nil {
+		fmt.Printf("[DEBUG] Failed to use cached download %s: %v\n", key, err)
+		return nil, nil, false
+	}
+	if sum != entry.SHA256 {
+		fmt.Printf("[DEBUG] Discarding cached download %s: contents don't match the stored hash\n", key)
+		_ = os.Remove(destPath)
+		_ = os.RemoveAll(dir)
+		return nil, nil, false
+	}
+	// The entry's modification time is when it was last used, for pruning
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(dir, cacheEntryName), now, now)
+
+	result := entry.Result
+	result.FilePath = destPath
+	return entry, &result, true
+}
+
+// store adds a finished download to the cache under key, replacing any earlier
+// entry. The downloaded file itself is left where it is.
+func (c *SourceCache) store(key string, videoID string, formats FormatOptions, info *VideoInfo, result *DownloadResult) error {
+	// Build the entry to the side and move it into place once it is complete, so
+	// an interrupted store never leaves an entry that looks valid
+	tmpDir, err := os.MkdirTemp(c.dir, cacheIncomingPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	name := filepath.Base(result.FilePath)
+	path := filepath.Join(tmpDir, name)
+	sum, err := copyFile(result.FilePath, path)
+	if err != nil {
+		return fmt.Errorf("failed to copy download into cache: %w", err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	entry := cacheEntry{
+		VideoID:  videoID,
+		Formats:  formats,
+		Info:     info,
+		Result:   *result,
+		Size:     stat.Size(),
+		ModTime:  stat.ModTime(),
+		SHA256:   sum,
+		StoredAt: time.Now(),
+	}
+	entry.Result.FilePath = name
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, cacheEntryName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	dir := filepath.Join(c.dir, key)
+	_ = os.RemoveAll(dir)
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	c.prune(key)
+	return nil
+}
+
+// prune removes the least recently used entries until the cache fits in maxBytes.
+// keep, the entry just stored, is never removed.
+func (c *SourceCache) prune(keep string) {
+	if c.maxBytes <= 0 {
+		return
+	}
+	names, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type usage struct {
+		key  string
+		size int64
+		used time.Time
+	}
+	var entries []usage
+	var total int64
+	for _, name := range names {
+		if !name.IsDir() || strings.HasPrefix(name.Name(), cacheIncomingPrefix) {
+			continue
+		}
+		dir := filepath.Join(c.dir, name.Name())
+		entry, err := readCacheEntry(dir)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, cacheEntryName))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, usage{key: name.Name(), size: entry.Size, used: info.ModTime()})
+		total += entry.Size
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= c.maxBytes {
+			break
+		}
+		if e.key == keep {
+			continue
+		}
+		fmt.Printf("[DEBUG] Removing cached download %s to stay under the cache size\n", e.key)
+		if err := os.RemoveAll(filepath.Join(c.dir, e.key)); err == nil {
+			total -= e.size
+		}
+	}
+}
+
+// Size returns how many bytes the cached downloads take up
+func (c *SourceCache) Size() (int64, error) {
+	names, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	var total int64
+	for _, name := range names {
+		if !name.IsDir() {
+			continue
+		}
+		_ = filepath.WalkDir(filepath.Join(c.dir, name.Name()), func(path string, e os.DirEntry, err error) error {
+			if err == nil && !e.IsDir() {
+				if info, err := e.Info(); err == nil {
+					total += info.Size()
+				}
+			}
+			return nil
+		})
+	}
+	return total, nil
+}
+
+// Clear removes every cached download
+func (c *SourceCache) Clear() error {
+	names, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	for _, name := range names {
+		if err := os.RemoveAll(filepath.Join(c.dir, name.Name())); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// readCacheEntry reads the entry in dir
+func readCacheEntry(dir string) (*cacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, cacheEntryName))
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid cache entry: %w", err)
+	}
+	if entry.Result.FilePath == "" || entry.Result.FilePath != filepath.Base(entry.Result.FilePath) {
+		return nil, fmt.Errorf("invalid cache entry: bad file name %q", entry.Result.FilePath)
+	}
+	return &entry, nil
+}
+
+// verifyCachedFile checks that path still has the size and modification time it was
+// stored with, before it is copied out and its hash checked
+func verifyCachedFile(path string, size int64, modTime time.Time) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		return fmt.Errorf("size is %d bytes, expected %d", info.Size(), size)
+	}
+	if !info.ModTime().Equal(modTime) {
+		return fmt.Errorf("modified since it was stored")
+	}
+	return nil
+}
+
+// copyFile copies src to dst, replacing any existing dst, and returns the SHA-256 of
+// what it copied
+func copyFile(src string, dst string) (string, error) {
+	tmp := dst + ".tmp"
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		out.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
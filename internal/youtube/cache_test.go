@@ -0,0 +1,139 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cachedDownload stores a download of testWatchURL in a new cache and returns the
+// Downloader using it
+func cachedDownload(t *testing.T, content string) (*Downloader, *SourceCache) {
+	t.Helper()
+	cache, err := OpenSourceCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDownloader()
+	d.SetCache(cache)
+
+	path := filepath.Join(t.TempDir(), "dQw4w9WgXcQ-preview.mp4")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, key := d.cacheFor(testWatchURL, FormatOptions{})
+	info := &VideoInfo{ID: "dQw4w9WgXcQ", Title: "Cached video", Duration: 4}
+	if err := cache.store(key, "dQw4w9WgXcQ", FormatOptions{}, info, &DownloadResult{FilePath: path, Method: "mux"}); err != nil {
+		t.Fatal(err)
+	}
+	return d, cache
+}
+
+func TestCachedPreview(t *testing.T) {
+	d, _ := cachedDownload(t, "video bytes")
+	destDir := t.TempDir()
+
+	info, result, ok := d.CachedPreview("https://youtu.be/dQw4w9WgXcQ", destDir, FormatOptions{})
+	if !ok {
+		t.Fatal("CachedPreview missed")
+	}
+	if info.Title != "Cached video" || result.Method != "mux" {
+		t.Errorf("CachedPreview = %+v, %+v", info, result)
+	}
+	if data, _ := os.ReadFile(result.FilePath); string(data) != "video bytes" || filepath.Dir(result.FilePath) != destDir {
+		t.Errorf("preview at %s has %q", result.FilePath, data)
+	}
+
+	if _, _, ok := d.CachedPreview(testWatchURL, destDir, FormatOptions{AudioOnly: true}); ok {
+		t.Error("CachedPreview hit for other formats")
+	}
+}
+
+// The preview is rewritten in place by later steps, like ffmpeg -y; that must not
+// reach the cached file
+func TestCachedPreviewIsACopy(t *testing.T) {
+	d, _ := cachedDownload(t, "video bytes")
+
+	_, result, ok := d.CachedPreview(testWatchURL, t.TempDir(), FormatOptions{})
+	if !ok {
+		t.Fatal("CachedPreview missed")
+	}
+	if err := os.WriteFile(result.FilePath, []byte("rewritten"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, result, ok = d.CachedPreview(testWatchURL, t.TempDir(), FormatOptions{})
+	if !ok {
+		t.Fatal("rewriting the preview damaged the cached file")
+	}
+	if data, _ := os.ReadFile(result.FilePath); string(data) != "video bytes" {
+		t.Errorf("cached file has %q", data)
+	}
+}
+
+func TestCachedPreviewChangedFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(path string) error
+	}{
+		{"truncated", func(path string) error { return os.Truncate(path, 3) }},
+		{"touched", func(path string) error {
+			later := time.Now().Add(time.Hour)
+			return os.Chtimes(path, later, later)
+		}},
+		{"corrupted", func(path string) error {
+			// Same size and modification time; only the hash gives it away
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte("video bytez"), 0644); err != nil {
+				return err
+			}
+			return os.Chtimes(path, info.ModTime(), info.ModTime())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, cache := cachedDownload(t, "video bytes")
+			_, key := d.cacheFor(testWatchURL, FormatOptions{})
+			if err := tt.change(filepath.Join(cache.dir, key, "dQw4w9WgXcQ-preview.mp4")); err != nil {
+				t.Fatal(err)
+			}
+			destDir := t.TempDir()
+			if _, _, ok := d.CachedPreview(testWatchURL, destDir, FormatOptions{}); ok {
+				t.Fatal("CachedPreview served a changed file")
+			}
+			if names, _ := os.ReadDir(destDir); len(names) != 0 {
+				t.Errorf("the changed file was left in the destination: %v", names)
+			}
+			if _, err := os.Stat(filepath.Join(cache.dir, key)); !os.IsNotExist(err) {
+				t.Error("the changed entry was kept")
+			}
+		})
+	}
+}
+
+func TestSourceCacheSizeAndClear(t *testing.T) {
+	d, cache := cachedDownload(t, "video bytes")
+
+	size, err := cache.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file plus its entry.json
+	if size <= int64(len("video bytes")) {
+		t.Errorf("Size = %d, want more than the %d-byte file", size, len("video bytes"))
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := cache.Size(); size != 0 {
+		t.Errorf("Size after Clear = %d", size)
+	}
+	if _, _, ok := d.CachedPreview(testWatchURL, t.TempDir(), FormatOptions{}); ok {
+		t.Error("CachedPreview hit after Clear")
+	}
+}
@@ -0,0 +1,209 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// maxCaptionSize bounds a caption download; real tracks are well under this
+const maxCaptionSize = 20 * 1024 * 1024
+
+// CaptionTrack describes a caption track available for a video
+type CaptionTrack struct {
+	Language string `json:"language"` // Language code such as "en" or "pt-BR"
+	Name     string `json:"name"`
+	Auto     bool   `json:"auto"` // Generated by speech recognition rather than uploaded
+}
+
+// ListCaptions returns the caption tracks available for a video
+func (d *Downloader) ListCaptions(ctx context.Context, url string) ([]CaptionTrack, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := d.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	tracks := make([]CaptionTrack, 0, len(video.CaptionTracks))
+	for _, t := range video.CaptionTracks {
+		tracks = append(tracks, CaptionTrack{
+			Language: t.LanguageCode,
+			Name:     t.Name.SimpleText,
+			Auto:     t.Kind == "asr",
+		})
+	}
+	return tracks, nil
+}
+
+// CaptionFileName is the name DownloadCaptions saves a track under
+func CaptionFileName(videoID string, language string) string {
+	return videoID + "." + language + ".vtt"
+}
+
+// DownloadCaptions saves the captions of a video in the given language as WebVTT in
+// destDir (see CaptionFileName) and returns the path and the track used. An uploaded
+// track is preferred over an auto-generated one. yt-dlp is used as a fallback when
+// ytdlpPath is set.
+func (d *Downloader) DownloadCaptions(ctx context.Context, url string, language string, destDir string, ytdlpPath string) (string, CaptionTrack, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return "", CaptionTrack{}, err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", CaptionTrack{}, fmt.Errorf("failed to create captions directory: %w", err)
+	}
+	destPath := filepath.Join(destDir, CaptionFileName(videoID, language))
+
+	track, err := d.downloadCaptionsNative(ctx, videoID, language, destPath)
+	if err == nil {
+		return destPath, track, nil
+	}
+	fmt.Printf("[DEBUG] Caption download failed: %v\n", err)
+	if ytdlpPath == "" {
+		return "", CaptionTrack{}, err
+	}
+
+	fmt.Printf("[DEBUG] Trying yt-dlp for %s captions\n", language)
+	track, ytdlpErr := downloadCaptionsWithYtdlp(ctx, url, videoID, language, destPath, ytdlpPath, d.ytdlpConfig())
+	if ytdlpErr != nil {
+		return "", CaptionTrack{}, fmt.Errorf("%w; yt-dlp: %v", err, ytdlpErr)
+	}
+	return destPath, track, nil
+}
+
+func (d *Downloader) downloadCaptionsNative(ctx context.Context, videoID string, language string, destPath string) (CaptionTrack, error) {
+	video, err := d.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return CaptionTrack{}, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	t := selectCaptionTrack(video.CaptionTracks, language)
+	if t == nil {
+		return CaptionTrack{}, fmt.Errorf("no %s captions available", language)
+	}
+
+	trackURL, err := neturl.Parse(t.BaseURL)
+	if err != nil {
+		return CaptionTrack{}, fmt.Errorf("invalid caption URL: %w", err)
+	}
+	query := trackURL.Query()
+	query.Set("fmt", "vtt")
+	trackURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, trackURL.String(), nil)
+	if err != nil {
+		return CaptionTrack{}, err
+	}
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return CaptionTrack{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CaptionTrack{}, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCaptionSize))
+	if err != nil {
+		return CaptionTrack{}, err
+	}
+	if !bytes.HasPrefix(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), []byte("WEBVTT")) {
+		// YouTube answers with an empty body when it wants a token the Go client can't provide
+		return CaptionTrack{}, fmt.Errorf("caption response was not WebVTT (%d bytes)", len(data))
+	}
+	if err := os.WriteFile(destPath, data, 0644); err != nil {
+		return CaptionTrack{}, fmt.Errorf("failed to save captions: %w", err)
+	}
+
+	return CaptionTrack{Language: t.LanguageCode, Name: t.Name.SimpleText, Auto: t.Kind == "asr"}, nil
+}
+
+// selectCaptionTrack picks the track for language, preferring uploaded captions over
+// auto-generated ones. A track for a regional variant ("en-GB") matches "en".
+func selectCaptionTrack(tracks []youtube.CaptionTrack, language string) *youtube.CaptionTrack {
+	var best *youtube.CaptionTrack
+	bestScore := 0
+	for i := range tracks {
+		t := &tracks[i]
+		score := 0
+		switch {
+		case strings.EqualFold(t.LanguageCode, language):
+			score = 2
+		case strings.EqualFold(strings.SplitN(t.LanguageCode, "-", 2)[0], language):
+			score = 1
+		default:
+			continue
+		}
+		if t.Kind != "asr" {
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+// downloadCaptionsWithYtdlp fetches captions with yt-dlp, which writes <base>.<lang>.vtt
+func downloadCaptionsWithYtdlp(ctx context.Context, url string, videoID string, language string, destPath string, ytdlpPath string, cfg ytdlpConfig) (CaptionTrack, error) {
+	target, err := ytdlpTarget(url)
+	if err != nil {
+		return CaptionTrack{}, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(destPath), "captions-*")
+	if err != nil {
+		return CaptionTrack{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Uploaded captions first; auto-generated ones only if there are none
+	for _, auto := range []bool{false, true} {
+		flag := "--write-subs"
+		if auto {
+			flag = "--write-auto-subs"
+		}
+		args := []string{
+			"--skip-download",
+			flag,
+			"--sub-langs", language,
+			"--sub-format", "vtt",
+			"-o", filepath.Join(tmpDir, videoID),
+			"--no-playlist",
+			"--no-warnings",
+		}
+		args = append(args, cfg.args...)
+		args = append(args, target...)
+		cmd := cfg.command(ctx, ytdlpPath, args)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return CaptionTrack{}, &ytdlpError{err: err, stderr: stderr.String()}
+		}
+
+		matches, _ := filepath.Glob(filepath.Join(tmpDir, "*.vtt"))
+		if len(matches) == 0 {
+			continue
+		}
+		data, err := os.ReadFile(matches[0])
+		if err != nil {
+			return CaptionTrack{}, err
+		}
+		if err := os.WriteFile(destPath, data, 0644); err != nil {
+			return CaptionTrack{}, fmt.Errorf("failed to save captions: %w", err)
+		}
+		return CaptionTrack{Language: language, Auto: auto}, nil
+	}
+	return CaptionTrack{}, fmt.Errorf("no %s captions available", language)
+}
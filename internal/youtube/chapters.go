@@ -0,0 +1,129 @@
+package youtube
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Chapter is a titled section of a video, in seconds
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+var (
+	// chapterTimestampPattern finds a [h:]mm:ss timestamp, optionally in brackets
+	chapterTimestampPattern = regexp.MustCompile(`[\[(]?\b((?:\d{1,2}:)?\d{1,2}:\d{2})\b[\])]?`)
+
+	// chapterTrimPattern strips list numbering and separators around a chapter title
+	chapterTrimPattern = regexp.MustCompile(`^[\s\-–—:|•·.)]*(?:\d+[.)]\s+)?|[\s\-–—:|•·]*$`)
+)
+
+// ParseChapters extracts chapters from "0:00 Intro" style timestamp lines in a video
+// description. Like YouTube, the list must start at 0:00 and have at least two
+// timestamps in ascending order, so times mentioned in prose aren't taken for one;
+// each chapter ends where the next starts and the last at duration (if known).
+func ParseChapters(description string, duration float64) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		loc := chapterTimestampPattern.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		start, ok := parseChapterTimestamp(line[loc[2]:loc[3]])
+		if !ok {
+			continue
+		}
+		// The title is whatever surrounds the timestamp: "0:00 Intro" or "Intro - 0:00"
+		title := strings.TrimSpace(line[:loc[0]] + " " + line[loc[1]:])
+		title = strings.TrimSpace(chapterTrimPattern.ReplaceAllString(title, ""))
+		if title == "" {
+			continue
+		}
+		if len(chapters) == 0 && start != 0 {
+			// The list hasn't started yet
+			continue
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			// Out of order: probably a timestamp mentioned in prose, not a chapter list
+			continue
+		}
+		if duration > 0 && start >= duration {
+			continue
+		}
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	if len(chapters) < 2 {
+		return nil
+	}
+	return closeChapters(chapters, duration)
+}
+
+// closeChapters sets each chapter's end to the start of the next one
+func closeChapters(chapters []Chapter, duration float64) []Chapter {
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else if duration > chapters[i].Start {
+			chapters[i].End = duration
+		}
+	}
+	// Without a known duration the last chapter has no end and can't be exported
+	if chapters[len(chapters)-1].End == 0 {
+		chapters = chapters[:len(chapters)-1]
+	}
+	return chapters
+}
+
+func parseChapterTimestamp(s string) (float64, bool) {
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + float64(v)
+	}
+	return seconds, true
+}
+
+// readYtdlpChapters reads the chapters from a yt-dlp .info.json file. yt-dlp includes
+// chapters set in YouTube's own metadata, not just ones from the description.
+func readYtdlpChapters(infoPath string) []Chapter {
+	data, err := os.ReadFile(infoPath)
+	if err != nil {
+		return nil
+	}
+	var info struct {
+		Chapters ytdlpChapters `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil
+	}
+	return info.Chapters.list()
+}
+
+// ytdlpChapters is the chapter list in yt-dlp's metadata
+type ytdlpChapters []struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+func (c ytdlpChapters) list() []Chapter {
+	chapters := make([]Chapter, 0, len(c))
+	for _, ch := range c {
+		if ch.EndTime <= ch.StartTime {
+			continue
+		}
+		chapters = append(chapters, Chapter{Title: strings.TrimSpace(ch.Title), Start: ch.StartTime, End: ch.EndTime})
+	}
+	if len(chapters) == 0 {
+		return nil
+	}
+	return chapters
+}
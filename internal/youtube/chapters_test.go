@@ -0,0 +1,83 @@
+package youtube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		duration    float64
+		want        []Chapter
+	}{
+		{
+			name:        "timestamps first",
+			description: "Today's lesson.\n\n0:00 Intro\n1:30 Cells\n12:05 Summary\n\nThanks for watching!",
+			duration:    900,
+			want:        []Chapter{{"Intro", 0, 90}, {"Cells", 90, 725}, {"Summary", 725, 900}},
+		},
+		{
+			name:        "titles first",
+			description: "Intro - 0:00\nPart one — 2:00\nPart two: 4:00",
+			duration:    360,
+			want:        []Chapter{{"Intro", 0, 120}, {"Part one", 120, 240}, {"Part two", 240, 360}},
+		},
+		{
+			name:        "brackets and hours",
+			description: "[0:00] Welcome\n[59:00] Break\n[1:02:03] Questions\n(1:30:00) 4. Wrap-up",
+			duration:    6000,
+			want:        []Chapter{{"Welcome", 0, 3540}, {"Break", 3540, 3723}, {"Questions", 3723, 5400}, {"Wrap-up", 5400, 6000}},
+		},
+		{
+			name:        "prose",
+			description: "The experiment starts at 5:00 and the results come in at 12:30.\nSkip to 20:00 for the summary.",
+			duration:    1800,
+			want:        nil,
+		},
+		{
+			name:        "prose before the list",
+			description: "Recorded live at 10:30.\n0:00 Start\n3:00 Middle",
+			duration:    400,
+			want:        []Chapter{{"Start", 0, 180}, {"Middle", 180, 400}},
+		},
+		{
+			name:        "out of order",
+			description: "0:00 Intro\n5:00 Main\n2:00 See the earlier part\n8:00 End",
+			duration:    600,
+			want:        []Chapter{{"Intro", 0, 300}, {"Main", 300, 480}, {"End", 480, 600}},
+		},
+		{
+			name:        "unknown duration",
+			description: "0:00 Intro\n1:00 Middle\n2:00 End",
+			duration:    0,
+			want:        []Chapter{{"Intro", 0, 60}, {"Middle", 60, 120}},
+		},
+		{
+			name:        "past the end",
+			description: "0:00 Intro\n1:00 Main\n9:00 Bonus",
+			duration:    300,
+			want:        []Chapter{{"Intro", 0, 60}, {"Main", 60, 300}},
+		},
+		{
+			name:        "one timestamp",
+			description: "0:00 The whole thing",
+			duration:    300,
+			want:        nil,
+		},
+		{
+			name:        "no titles",
+			description: "0:00\n1:00\n2:00",
+			duration:    300,
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseChapters(tt.description, tt.duration); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
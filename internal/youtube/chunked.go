@@ -0,0 +1,342 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+const (
+	// DefaultChunkWorkers is the number of parallel range requests per stream
+	DefaultChunkWorkers = 4
+
+	// DefaultChunkSize keeps each request under the size at which YouTube starts throttling a connection
+	DefaultChunkSize int64 = 10 * 1024 * 1024
+
+	// chunkStateSuffix names the sidecar file recording which chunks of a .part file are complete
+	chunkStateSuffix = ".chunks"
+)
+
+// chunkState is persisted next to a chunked .part file so an interrupted download can resume
+type chunkState struct {
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunkSize"`
+	Done      []bool `json:"done"`
+}
+
+// chunkProgress aggregates bytes written by all workers into a single progress callback
+type chunkProgress struct {
+	mu           sync.Mutex
+	written      int64
+	total        int64
+	progressCb   ProgressCallback
+	lastReported float64
+}
+
+func (cp *chunkProgress) add(n int64) {
+	if cp.progressCb == nil || cp.total <= 0 {
+		return
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.written += n
+	progress := float64(cp.written) / float64(cp.total)
+	// Only report every 1% change to avoid flooding
+	if progress-cp.lastReported >= 0.01 || progress >= 1.0 {
+		cp.progressCb(progress)
+		cp.lastReported = progress
+	}
+}
+
+// chunkWriter writes a response body into its slot of the .part file and counts progress
+type chunkWriter struct {
+	file     *os.File
+	offset   int64
+	progress *chunkProgress
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	n, err := cw.file.WriteAt(p, cw.offset)
+	cw.offset += int64(n)
+	cw.progress.add(int64(n))
+	return n, err
+}
+
+// SetChunkWorkers sets how many byte ranges of a stream are fetched in parallel.
+// A value of 1 downloads each stream over a single connection.
+func (d *Downloader) SetChunkWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.chunkWorkers = n
+}
+
+// ChunkWorkers returns how many byte ranges of a stream are fetched in parallel
+func (d *Downloader) ChunkWorkers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.chunkWorkers
+}
+
+// downloadURLChunked downloads a stream of known size by fetching fixed-size byte ranges
+// with several workers in parallel. Like downloadURLResumable, data goes to destPath+".part"
+// and completed chunks are recorded so an interrupted download picks up where it stopped.
+func (d *Downloader) downloadURLChunked(ctx context.Context, streamURL string, size int64, destPath string, workers int, chunkSize int64, progressCb ProgressCallback) error {
+	if size <= 0 {
+		return fmt.Errorf("chunked download requires a known size")
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	partPath := destPath + partSuffix
+	statePath := partPath + chunkStateSuffix
+	numChunks := int((size + chunkSize - 1) / chunkSize)
+
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read partial file: %w", err)
+	}
+	state := loadChunkState(statePath)
+	if state == nil || state.Size != size || state.ChunkSize != chunkSize || len(state.Done) != numChunks || info.Size() != size {
+		// Keep what can be trusted: the completed start of an earlier chunked download
+		// with other settings, or a .part file without chunk state, which was written
+		// sequentially so its length is a contiguous prefix
+		var keep int64
+		if prefix, found := resumablePrefix(statePath, size); found {
+			keep = min(prefix, info.Size())
+		} else if info.Size() < size {
+			keep = info.Size()
+		}
+		state = &chunkState{Size: size, ChunkSize: chunkSize, Done: make([]bool, numChunks)}
+		for i := 0; i < numChunks; i++ {
+			if int64(i)*chunkSize+chunkLength(i, chunkSize, size) <= keep {
+				state.Done[i] = true
+			}
+		}
+		if err := saveChunkState(statePath, state); err != nil {
+			return fmt.Errorf("failed to save download state: %w", err)
+		}
+	}
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to allocate file: %w", err)
+	}
+
+	progress := &chunkProgress{total: size, progressCb: progressCb}
+	var pending []int
+	for i, done := range state.Done {
+		if done {
+			progress.written += chunkLength(i, chunkSize, size)
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	if progress.written > 0 {
+		fmt.Printf("[DEBUG] Resuming %s with %d of %d chunks done\n", destPath, numChunks-len(pending), numChunks)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		stateMu  sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	work := make(chan int)
+	for w := 0; w < workers && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				start := int64(idx) * chunkSize
+				end := start + chunkLength(idx, chunkSize, size) - 1
+				err := d.fetchChunk(ctx, streamURL, file, start, end, progress)
+
+				stateMu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					cancel()
+				} else {
+					state.Done[idx] = true
+					_ = saveChunkState(statePath, state)
+				}
+				stateMu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, idx := range pending {
+		select {
+		case work <- idx:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(partPath, destPath); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
+	_ = os.Remove(statePath)
+	return nil
+}
+
+// fetchChunk downloads bytes start..end (inclusive) into file, resuming within the
+// chunk if the connection drops part way through.
+func (d *Downloader) fetchChunk(ctx context.Context, streamURL string, file *os.File, start int64, end int64, progress *chunkProgress) error {
+	offset := start
+	for attempt := 1; ; attempt++ {
+		err := d.fetchChunkOnce(ctx, streamURL, file, &offset, end, progress)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) || attempt >= maxResumeAttempts {
+			return fmt.Errorf("chunk %d-%d: %w", start, end, err)
+		}
+	}
+}
+
+func (d *Downloader) fetchChunkOnce(ctx context.Context, streamURL string, file *os.File, offset *int64, end int64, progress *chunkProgress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", streamUserAgent)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", *offset, end))
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	if rangeStart, _, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || rangeStart != *offset {
+		return fmt.Errorf("server returned unexpected range %q", resp.Header.Get("Content-Range"))
+	}
+
+	want := end - *offset + 1
+	writer := &chunkWriter{file: file, offset: *offset, progress: progress}
+	n, err := io.Copy(writer, io.LimitReader(d.limitReader(ctx, resp.Body), want))
+	*offset += n
+	if err != nil {
+		return err
+	}
+	if n < want {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func chunkLength(idx int, chunkSize int64, size int64) int64 {
+	start := int64(idx) * chunkSize
+	if start+chunkSize > size {
+		return size - start
+	}
+	return chunkSize
+}
+
+func loadChunkState(path string) *chunkState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state chunkState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
+}
+
+// resumablePrefix reads the chunk state of an interrupted chunked download and returns
+// how many bytes from the start of its .part file are complete, so the rest can be
+// fetched another way. The .part file has holes wherever a chunk wasn't finished, so
+// nothing past the first hole can be kept. found is false if there is no state; a state
+// that can't be read or was for a stream of another size keeps nothing.
+func resumablePrefix(statePath string, size int64) (prefix int64, found bool) {
+	if _, err := os.Stat(statePath); err != nil {
+		return 0, false
+	}
+	state := loadChunkState(statePath)
+	if state == nil || size <= 0 || state.Size != size || state.ChunkSize <= 0 ||
+		int64(len(state.Done)) != (size+state.ChunkSize-1)/state.ChunkSize {
+		return 0, true
+	}
+	for i, done := range state.Done {
+		if !done {
+			break
+		}
+		prefix += chunkLength(i, state.ChunkSize, size)
+	}
+	return prefix, true
+}
+
+func saveChunkState(path string, state *chunkState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// mergedProgress splits parent into callbacks for downloads running in parallel.
+// Each part's 0.0-1.0 progress is scaled by its weight and the sum is reported
+// starting at base.
+func mergedProgress(parent ProgressCallback, base float64, weights ...float64) []ProgressCallback {
+	var mu sync.Mutex
+	values := make([]float64, len(weights))
+	parts := make([]ProgressCallback, len(weights))
+	for i := range weights {
+		i := i
+		parts[i] = weightedProgress(func(p float64) {
+			mu.Lock()
+			defer mu.Unlock()
+			values[i] = p
+			total := base
+			for _, v := range values {
+				total += v
+			}
+			if parent != nil {
+				parent(total)
+			}
+		}, 0, weights[i])
+	}
+	return parts
+}
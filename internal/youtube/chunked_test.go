@@ -0,0 +1,259 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testChunkSize = 64 * 1024
+
+// throttlingServer serves data with range requests the way googlevideo does, cutting
+// each connection after perConn bytes like a throttled stream
+type throttlingServer struct {
+	data    []byte
+	perConn int // 0 for no limit
+
+	mu       sync.Mutex
+	failFrom int64 // Requests starting at or after this offset get 503; -1 for none
+	served   int64
+}
+
+func newThrottlingServer(t *testing.T, size int, perConn int) (*throttlingServer, string) {
+	t.Helper()
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	s := &throttlingServer{data: data, perConn: perConn, failFrom: -1}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func (s *throttlingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	size := int64(len(s.data))
+	start, end := int64(0), size-1
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		from, to, _ := strings.Cut(spec, "-")
+		start, _ = strconv.ParseInt(from, 10, 64)
+		if to != "" {
+			end, _ = strconv.ParseInt(to, 10, 64)
+		}
+	}
+	if start >= size {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	s.mu.Lock()
+	fail := s.failFrom >= 0 && start >= s.failFrom
+	s.mu.Unlock()
+	if fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body := s.data[start : end+1]
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusPartialContent)
+	if s.perConn > 0 && len(body) > s.perConn {
+		// Returning before Content-Length bytes are written drops the connection
+		body = body[:s.perConn]
+	}
+	n, _ := w.Write(body)
+
+	s.mu.Lock()
+	s.served += int64(n)
+	s.mu.Unlock()
+}
+
+func (s *throttlingServer) setFailFrom(offset int64) {
+	s.mu.Lock()
+	s.failFrom = offset
+	s.mu.Unlock()
+}
+
+// takeServed returns the bytes served since the last call
+func (s *throttlingServer) takeServed() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.served
+	s.served = 0
+	return n
+}
+
+func checkDownload(t *testing.T, destPath string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded file differs from the stream (%d bytes, want %d)", len(got), len(want))
+	}
+	for _, leftover := range []string{destPath + partSuffix, destPath + partSuffix + chunkStateSuffix} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", filepath.Base(leftover))
+		}
+	}
+}
+
+func TestChunkedDownloadThrottled(t *testing.T) {
+	srv, url := newThrottlingServer(t, 20*testChunkSize+123, 24*1024)
+	destPath := filepath.Join(t.TempDir(), "video.mp4")
+
+	d := NewDownloader()
+	if err := d.downloadURLChunked(context.Background(), url, int64(len(srv.data)), destPath, 4, testChunkSize, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkDownload(t, destPath, srv.data)
+}
+
+// interruptChunked runs a chunked download that fails at failFrom, leaving a .part
+// file and its chunk state behind
+func interruptChunked(t *testing.T, d *Downloader, srv *throttlingServer, url string, destPath string, workers int, failFrom int64) {
+	t.Helper()
+	srv.setFailFrom(failFrom)
+	err := d.downloadURLChunked(context.Background(), url, int64(len(srv.data)), destPath, workers, testChunkSize, nil)
+	if err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+	srv.setFailFrom(-1)
+	srv.takeServed()
+}
+
+func TestChunkedResumeWithOtherWorkerCount(t *testing.T) {
+	size := 16*testChunkSize + 500
+	failFrom := int64(8 * testChunkSize)
+
+	tests := []struct {
+		name    string
+		resume  func(d *Downloader, url string, destPath string) error
+		maxSent int64 // Most bytes the resume may fetch
+	}{
+		{
+			name: "sequential",
+			resume: func(d *Downloader, url string, destPath string) error {
+				return d.downloadURLResumable(context.Background(), url, int64(size), destPath, nil)
+			},
+			// Every reconnect fetches the verification overlap again
+			maxSent: int64(size) - failFrom + maxResumeAttempts*resumeOverlap,
+		},
+		{
+			name: "more workers",
+			resume: func(d *Downloader, url string, destPath string) error {
+				return d.downloadURLChunked(context.Background(), url, int64(size), destPath, 4, testChunkSize, nil)
+			},
+			maxSent: int64(size) - failFrom,
+		},
+		{
+			name: "other chunk size",
+			resume: func(d *Downloader, url string, destPath string) error {
+				return d.downloadURLChunked(context.Background(), url, int64(size), destPath, 3, testChunkSize*3, nil)
+			},
+			// Chunks of the new size that are entirely inside the completed prefix are kept
+			maxSent: int64(size) - 6*testChunkSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, url := newThrottlingServer(t, size, 200*1024)
+			destPath := filepath.Join(t.TempDir(), "video.mp4")
+			d := NewDownloader()
+
+			// One worker fetches chunks in order, so exactly the first half is done
+			interruptChunked(t, d, srv, url, destPath, 1, failFrom)
+			if prefix, found := resumablePrefix(destPath+partSuffix+chunkStateSuffix, int64(size)); !found || prefix != failFrom {
+				t.Fatalf("resumablePrefix = %d, %v; want %d, true", prefix, found, failFrom)
+			}
+
+			if err := tt.resume(d, url, destPath); err != nil {
+				t.Fatal(err)
+			}
+			checkDownload(t, destPath, srv.data)
+			if sent := srv.takeServed(); sent > tt.maxSent {
+				t.Errorf("resume fetched %d bytes, want at most %d", sent, tt.maxSent)
+			}
+		})
+	}
+}
+
+func TestChunkedResumeAfterParallelInterruption(t *testing.T) {
+	srv, url := newThrottlingServer(t, 16*testChunkSize, 0)
+	destPath := filepath.Join(t.TempDir(), "video.mp4")
+	d := NewDownloader()
+
+	interruptChunked(t, d, srv, url, destPath, 4, int64(10*testChunkSize))
+	if err := d.downloadURLResumable(context.Background(), url, int64(len(srv.data)), destPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkDownload(t, destPath, srv.data)
+}
+
+// A .part file whose last chunk is done but whose middle is still a hole must not
+// pass the sequential path's overlap check
+func TestSequentialResumeIgnoresChunkHoles(t *testing.T) {
+	srv, url := newThrottlingServer(t, 4*testChunkSize, 0)
+	size := int64(len(srv.data))
+	destPath := filepath.Join(t.TempDir(), "video.mp4")
+	partPath := destPath + partSuffix
+
+	part := make([]byte, size)
+	copy(part[3*testChunkSize:], srv.data[3*testChunkSize:])
+	if err := os.WriteFile(partPath, part, 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := &chunkState{Size: size, ChunkSize: testChunkSize, Done: []bool{false, false, false, true}}
+	if err := saveChunkState(partPath+chunkStateSuffix, state); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDownloader()
+	if err := d.downloadURLResumable(context.Background(), url, size, destPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkDownload(t, destPath, srv.data)
+}
+
+// Chunk state for a stream of another size means the remote file changed, so
+// nothing in the .part file can be kept by either path
+func TestResumeDiscardsStateForOtherSize(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			srv, url := newThrottlingServer(t, 4*testChunkSize, 0)
+			size := int64(len(srv.data))
+			destPath := filepath.Join(t.TempDir(), "video.mp4")
+			partPath := destPath + partSuffix
+
+			stale := bytes.Repeat([]byte{0xff}, int(size))
+			if err := os.WriteFile(partPath, stale, 0644); err != nil {
+				t.Fatal(err)
+			}
+			state := &chunkState{Size: size + 1, ChunkSize: testChunkSize, Done: []bool{true, true, true, true, true}}
+			if err := saveChunkState(partPath+chunkStateSuffix, state); err != nil {
+				t.Fatal(err)
+			}
+
+			d := NewDownloader()
+			var err error
+			if workers > 1 {
+				err = d.downloadURLChunked(context.Background(), url, size, destPath, workers, testChunkSize, nil)
+			} else {
+				err = d.downloadURLResumable(context.Background(), url, size, destPath, nil)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDownload(t, destPath, srv.data)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package youtube
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in cookies.txt files exported by browsers
+const httpOnlyPrefix = "#HttpOnly_"
+
+// signInCookies are set by YouTube only for signed-in accounts
+var signInCookies = []string{"SAPISID", "__Secure-3PAPISID", "__Secure-3PSID", "LOGIN_INFO"}
+
+// CookieSummary describes the cookies in a cookies.txt file
+type CookieSummary struct {
+	Count    int  `json:"count"`
+	YouTube  int  `json:"youtube"`  // Cookies for youtube.com or google.com
+	Expired  int  `json:"expired"`  // Not counted in YouTube
+	SignedIn bool `json:"signedIn"` // A signed-in YouTube session is included
+}
+
+// FileCookie is a cookie read from a cookies.txt file. Domain is empty for host-only
+// cookies, as a cookie jar expects; Host is the domain the file gives either way.
+type FileCookie struct {
+	*http.Cookie
+	Host string
+}
+
+// ParseCookiesFile reads cookies from a Netscape cookies.txt file, the format browser
+// extensions export and yt-dlp reads. Expired cookies are returned too.
+func ParseCookiesFile(path string) ([]FileCookie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer f.Close()
+
+	var cookies []FileCookie
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// domain, include subdomains, path, secure, expiry, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d is not a Netscape cookie (expected 7 tab-separated fields, got %d)", lineNum, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d has an invalid expiry %q", lineNum, fields[4])
+		}
+		cookie := &http.Cookie{
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		// Without the include subdomains flag, the cookie is only for that host
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = fields[0]
+		}
+		// An expiry of 0 is a session cookie
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, FileCookie{Cookie: cookie, Host: strings.TrimPrefix(fields[0], ".")})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no cookies found in %s", path)
+	}
+	return cookies, nil
+}
+
+// SummarizeCookies counts the cookies useful for YouTube and checks for a signed-in
+// session
+func SummarizeCookies(cookies []FileCookie, now time.Time) CookieSummary {
+	summary := CookieSummary{Count: len(cookies)}
+	for _, c := range cookies {
+		if !isYouTubeCookieDomain(c.Host) {
+			continue
+		}
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			summary.Expired++
+			continue
+		}
+		summary.YouTube++
+		for _, name := range signInCookies {
+			if c.Name == name {
+				summary.SignedIn = true
+			}
+		}
+	}
+	return summary
+}
+
+func isYouTubeCookieDomain(domain string) bool {
+	for _, d := range []string{"youtube.com", "google.com"} {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieJar is the jar of the Downloader's HTTP client. It holds nothing until cookies
+// are imported, so requests go out as they would without a jar.
+type cookieJar struct {
+	mu  sync.RWMutex
+	jar http.CookieJar
+}
+
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.jar != nil {
+		j.jar.SetCookies(u, cookies)
+	}
+}
+
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.jar == nil {
+		return nil
+	}
+	return j.jar.Cookies(u)
+}
+
+func (j *cookieJar) set(jar http.CookieJar) {
+	j.mu.Lock()
+	j.jar = jar
+	j.mu.Unlock()
+}
+
+// SetCookiesFile makes the Downloader send the cookies in a Netscape cookies.txt
+// file, both from its own client and by passing the file to yt-dlp. yt-dlp may
+// update the file with cookies YouTube sets. An empty path stops sending cookies.
+func (d *Downloader) SetCookiesFile(path string) error {
+	if path == "" {
+		d.cookies.set(nil)
+		d.mu.Lock()
+		d.cookiesPath = ""
+		d.mu.Unlock()
+		return nil
+	}
+
+	cookies, err := ParseCookiesFile(path)
+	if err != nil {
+		return err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	// The jar takes cookies per URL, so group them by the host they belong to
+	now := time.Now()
+	byHost := make(map[string][]*http.Cookie)
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		byHost[c.Host] = append(byHost[c.Host], c.Cookie)
+	}
+	for host, hostCookies := range byHost {
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: "/"}, hostCookies)
+	}
+
+	d.cookies.set(jar)
+	d.mu.Lock()
+	d.cookiesPath = path
+	d.mu.Unlock()
+	return nil
+}
+
+// CookiesFile returns the cookies file set with SetCookiesFile, if any
+func (d *Downloader) CookiesFile() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cookiesPath
+}
@@ -0,0 +1,137 @@
+package youtube
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCookiesFile writes a cookies.txt file with the given lines joined by newline
+func writeCookiesFile(t *testing.T, newline string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, newline)+newline), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseCookiesFile(t *testing.T) {
+	for _, newline := range []string{"\n", "\r\n"} {
+		path := writeCookiesFile(t, newline,
+			"# Netscape HTTP Cookie File",
+			"",
+			".youtube.com\tTRUE\t/\tTRUE\t2000000000\tPREF\tf6=40000000",
+			"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t2000000000\tLOGIN_INFO\tabc",
+			"www.youtube.com\tFALSE\t/watch\tFALSE\t0\tYSC\txyz",
+		)
+		cookies, err := ParseCookiesFile(path)
+		if err != nil {
+			t.Fatalf("%q: %v", newline, err)
+		}
+		if len(cookies) != 3 {
+			t.Fatalf("%q: got %d cookies, want 3", newline, len(cookies))
+		}
+
+		pref, login, ysc := cookies[0], cookies[1], cookies[2]
+		if pref.Name != "PREF" || pref.Value != "f6=40000000" || pref.Domain != ".youtube.com" || pref.Host != "youtube.com" ||
+			!pref.Secure || pref.HttpOnly || !pref.Expires.Equal(time.Unix(2000000000, 0)) {
+			t.Errorf("%q: PREF = %+v (host %q)", newline, *pref.Cookie, pref.Host)
+		}
+		if login.Name != "LOGIN_INFO" || login.Value != "abc" || !login.HttpOnly || login.Host != "youtube.com" {
+			t.Errorf("%q: #HttpOnly_ line = %+v (host %q)", newline, *login.Cookie, login.Host)
+		}
+		// Host-only session cookie
+		if ysc.Value != "xyz" || ysc.Domain != "" || ysc.Host != "www.youtube.com" || ysc.Path != "/watch" ||
+			ysc.Secure || !ysc.Expires.IsZero() {
+			t.Errorf("%q: YSC = %+v (host %q)", newline, *ysc.Cookie, ysc.Host)
+		}
+	}
+}
+
+func TestParseCookiesFileInvalid(t *testing.T) {
+	tests := map[string]string{
+		"fields": ".youtube.com\tTRUE\t/\tTRUE\t2000000000\tPREF",
+		"expiry": ".youtube.com\tTRUE\t/\tTRUE\tsoon\tPREF\tx",
+	}
+	for name, line := range tests {
+		if _, err := ParseCookiesFile(writeCookiesFile(t, "\n", "# Netscape HTTP Cookie File", line)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%s: error %q doesn't name the line", name, err)
+		}
+	}
+}
+
+func TestSummarizeCookies(t *testing.T) {
+	path := writeCookiesFile(t, "\n",
+		".youtube.com\tTRUE\t/\tTRUE\t2000000000\tPREF\tx",
+		".youtube.com\tTRUE\t/\tTRUE\t1000\tSAPISID\told",
+		"accounts.google.com\tFALSE\t/\tTRUE\t0\tLSID\tx",
+		".example.com\tTRUE\t/\tFALSE\t0\tid\tx",
+	)
+	cookies, err := ParseCookiesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1500000000, 0)
+	got := SummarizeCookies(cookies, now)
+	// The expired SAPISID doesn't count as signed in
+	want := CookieSummary{Count: 4, YouTube: 2, Expired: 1}
+	if got != want {
+		t.Errorf("SummarizeCookies = %+v, want %+v", got, want)
+	}
+
+	path = writeCookiesFile(t, "\n",
+		"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t2000000000\t__Secure-3PSID\tx",
+	)
+	if cookies, err = ParseCookiesFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := SummarizeCookies(cookies, now); !got.SignedIn || got.YouTube != 1 {
+		t.Errorf("SummarizeCookies = %+v, want signed in", got)
+	}
+}
+
+func TestSetCookiesFile(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Unix()
+	path := writeCookiesFile(t, "\r\n",
+		".youtube.com\tTRUE\t/\tTRUE\t"+strconv.FormatInt(expiry, 10)+"\tPREF\tdomain",
+		"youtube.com\tFALSE\t/\tTRUE\t0\tYSC\thost",
+		".youtube.com\tTRUE\t/\tTRUE\t1000\tOLD\texpired",
+	)
+	d := NewDownloader()
+	if err := d.SetCookiesFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if d.CookiesFile() != path {
+		t.Errorf("CookiesFile = %q, want %q", d.CookiesFile(), path)
+	}
+
+	names := func(rawURL string) string {
+		u, _ := url.Parse(rawURL)
+		var names []string
+		for _, c := range d.cookies.Cookies(u) {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	// The host-only cookie goes to its own host only, and the expired one nowhere
+	if got := names("https://youtube.com/watch"); got != "PREF,YSC" && got != "YSC,PREF" {
+		t.Errorf("youtube.com cookies = %q, want PREF and YSC", got)
+	}
+	if got := names("https://m.youtube.com/"); got != "PREF" {
+		t.Errorf("m.youtube.com cookies = %q, want PREF", got)
+	}
+
+	if err := d.SetCookiesFile(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := names("https://www.youtube.com/"); got != "" || d.CookiesFile() != "" {
+		t.Errorf("after clearing, cookies = %q, file = %q", got, d.CookiesFile())
+	}
+}
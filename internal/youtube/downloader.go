@@ -1,146 +1,178 @@
 package youtube
 
 import (
+	"bufio"
 	"context"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+
+	"yt-downloader/internal/network"
 
 	"github.com/kkdai/youtube/v2"
 )
 
 // VideoInfo holds metadata about a YouTube video
 type VideoInfo struct {
-	ID           string  `json:"id"`
-	Title        string  `json:"title"`
-	Author       string  `json:"author"`
-	Duration     float64 `json:"duration"` // in seconds
-	Thumbnail    string  `json:"thumbnail"`
-	Description  string  `json:"description"`
-	SourceWidth  int     `json:"sourceWidth"`
-	SourceHeight int     `json:"sourceHeight"`
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Duration     float64   `json:"duration"` // in seconds
+	Thumbnail    string    `json:"thumbnail"`
+	Description  string    `json:"description"`
+	SourceWidth  int       `json:"sourceWidth"`
+	SourceHeight int       `json:"sourceHeight"`
+	Chapters     []Chapter `json:"chapters,omitempty"`
+
+	// Livestreams and premieres
+	LiveStatus     LiveStatus `json:"liveStatus,omitempty"`
+	ScheduledStart int64      `json:"scheduledStart,omitempty"` // Unix seconds when an upcoming stream starts, 0 if unknown
+	LiveMessage    string     `json:"liveMessage,omitempty"`    // YouTube's explanation of the status, if any
 }
 
 // DownloadResult holds the download outcome with quality metadata
 type DownloadResult struct {
-	FilePath string `json:"filePath"`
-	Method   string `json:"method"`   // "yt-dlp", "mux", "progressive"
-	Width    int    `json:"width"`
-	Height   int    `json:"height"`
+	FilePath string  `json:"filePath"`
+	Method   string  `json:"method"` // "yt-dlp", "mux", "progressive"
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Offset   float64 `json:"offset,omitempty"`   // Where the file starts in the source video, in seconds (section downloads)
+	Duration float64 `json:"duration,omitempty"` // Length of the file in seconds, when known (live captures)
+
+	// Chapters from yt-dlp's metadata, which also knows chapters YouTube detected
+	// itself; empty when another method was used
+	Chapters []Chapter `json:"chapters,omitempty"`
+
+	AudioOnly bool `json:"audioOnly,omitempty"` // The file has no video (FormatOptions.AudioOnly)
 }
 
 // ProgressCallback is called with download progress (0.0 to 1.0)
 type ProgressCallback func(progress float64)
 
+// Stage identifies which phase a download is in
+type Stage string
+
+const (
+	StageDownloading      Stage = "downloading" // A single stream with both audio and video
+	StageDownloadingVideo Stage = "downloading video"
+	StageDownloadingAudio Stage = "downloading audio"
+	StageMerging          Stage = "merging"
+	StageRecording        Stage = "recording" // Capturing a livestream
+)
+
 // Downloader handles YouTube video operations
 type Downloader struct {
 	client *youtube.Client
+
+	mu           sync.Mutex
+	chunkWorkers int
+	chunkSize    int64
+	sources      map[string]Source
+	strategy     []string
+	cookies      *cookieJar // Imported with SetCookiesFile
+	cookiesPath  string
+	transport    *switchTransport // Set up by SetNetwork
+	network      network.Settings
+	limiter      *rateLimiter // Set with SetRateLimit
+	retry        RetryPolicy  // Set with SetRetryPolicy
+	cache        *SourceCache // Set with SetCache
 }
 
 // NewDownloader creates a new YouTube downloader
 func NewDownloader() *Downloader {
-	return &Downloader{
-		client: &youtube.Client{},
-	}
+	d := &Downloader{
+		client:       &youtube.Client{},
+		cookies:      &cookieJar{},
+		transport:    &switchTransport{},
+		limiter:      newRateLimiter(),
+		retry:        DefaultRetryPolicy,
+		chunkWorkers: DefaultChunkWorkers,
+		chunkSize:    DefaultChunkSize,
+		strategy:     append([]string(nil), DefaultStrategy...),
+	}
+	d.client.HTTPClient = &http.Client{Jar: d.cookies, Transport: d.transport}
+	d.sources = map[string]Source{
+		SourceYtdlp:   ytdlpSource{d: d},
+		SourceBuiltin: builtinSource{d: d},
+		SourceLocal:   localSource{},
+	}
+	return d
 }
 
-// ExtractVideoID extracts the video ID from a YouTube URL
+// ExtractVideoID extracts the video ID from a YouTube URL (see ParseURL)
 func ExtractVideoID(url string) (string, error) {
-	patterns := []string{
-		`(?:v=|\/v\/|youtu\.be\/|\/embed\/|\/shorts\/)([a-zA-Z0-9_-]{11})`,
-		`^([a-zA-Z0-9_-]{11})$`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(url)
-		if len(matches) > 1 {
-			return matches[1], nil
-		}
+	parsed, err := ParseURL(url)
+	if err != nil {
+		return "", err
 	}
-
-	return "", fmt.Errorf("could not extract video ID from URL: %s", url)
+	return parsed.ID, nil
 }
 
-// GetVideoInfo fetches metadata for a YouTube video without downloading
+// GetVideoInfo fetches metadata for a video without downloading. Sources that need
+// external programs are skipped; use ResolveVideo to include them.
 func (d *Downloader) GetVideoInfo(ctx context.Context, url string) (*VideoInfo, error) {
-	videoID, err := ExtractVideoID(url)
-	if err != nil {
-		return nil, err
-	}
+	return d.ResolveVideo(ctx, url, Tools{})
+}
 
-	video, err := d.client.GetVideoContext(ctx, videoID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video info: %w", err)
+// DownloadForPreview downloads a video for preview (best quality available, unless
+// formats names specific streams or asks for audio only). The sources are tried in
+// strategy order until one succeeds. With a cache (see SetCache), an earlier download
+// of the same video and formats is copied into destDir instead; info, the video as
+// the caller resolved it (nil if it didn't), is kept with new downloads for
+// CachedPreview.
+func (d *Downloader) DownloadForPreview(ctx context.Context, url string, info *VideoInfo, destDir string, ffmpegPath string, ytdlpPath string, formats FormatOptions, progressFn ProgressFunc) (*DownloadResult, error) {
+	cache, key := d.cacheFor(url, formats)
+	if cache != nil {
+		if _, result, ok := cache.lookup(key, destDir); ok {
+			fmt.Printf("[DEBUG] Using cached download %s\n", key)
+			return result, nil
+		}
 	}
 
-	// Get best thumbnail
-	thumbnail := ""
-	if len(video.Thumbnails) > 0 {
-		thumbnail = video.Thumbnails[len(video.Thumbnails)-1].URL
+	result, err := d.acquire(ctx, AcquireRequest{
+		URL:      url,
+		DestDir:  destDir,
+		Tools:    Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath},
+		Formats:  formats,
+		Progress: progressFn,
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	// Get source resolution from best available format
-	sourceWidth, sourceHeight := 0, 0
-	for _, f := range video.Formats {
-		if f.Width > sourceWidth {
-			sourceWidth = f.Width
-			sourceHeight = f.Height
+	if cache != nil {
+		videoID, _ := ExtractVideoID(url)
+		if err := cache.store(key, videoID, formats, info, result); err != nil {
+			fmt.Printf("[DEBUG] Failed to cache download %s: %v\n", key, err)
 		}
 	}
-
-	return &VideoInfo{
-		ID:           video.ID,
-		Title:        sanitizeFilename(video.Title),
-		Author:       video.Author,
-		Duration:     video.Duration.Seconds(),
-		Thumbnail:    thumbnail,
-		Description:  video.Description,
-		SourceWidth:  sourceWidth,
-		SourceHeight: sourceHeight,
-	}, nil
+	return result, nil
 }
 
-// DownloadForPreview downloads a video for preview (best quality available)
-func (d *Downloader) DownloadForPreview(ctx context.Context, url string, destDir string, ffmpegPath string, ytdlpPath string, progressCb ProgressCallback) (*DownloadResult, error) {
-	videoID, err := ExtractVideoID(url)
+// downloadStreams downloads a video with the Go library: separate high-quality streams
+// muxed with ffmpeg if possible, otherwise a single stream with both audio and video
+func (d *Downloader) downloadStreams(ctx context.Context, video *youtube.Video, destDir string, ffmpegPath string, formats FormatOptions, tracker *progressTracker) (*DownloadResult, error) {
+	v, a, format, err := pickFormats(video.Formats, formats)
 	if err != nil {
 		return nil, err
 	}
 
-	video, err := d.client.GetVideoContext(ctx, videoID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video: %w", err)
-	}
-
-	baseName := sanitizeFilename(video.Title)
-	outPath := filepath.Join(destDir, baseName+"-preview.mp4")
-
-	// Try yt-dlp first - most reliable for high-quality downloads
-	if ytdlpPath != "" && ffmpegPath != "" {
-		fmt.Printf("[DEBUG] Trying yt-dlp for high-quality download\n")
-		err := d.downloadWithYtdlp(ctx, url, outPath, ffmpegPath, ytdlpPath, progressCb)
-		if err == nil {
-			// Probe the downloaded file for resolution
-			w, h := probeResolution(ffmpegPath, outPath)
-			return &DownloadResult{FilePath: outPath, Method: "yt-dlp", Width: w, Height: h}, nil
-		}
-		fmt.Printf("[DEBUG] yt-dlp failed: %v, falling back to Go library\n", err)
-	}
-
 	// If ffmpeg is available, prefer muxing high-quality separate streams (video-only + audio-only).
 	// This makes export quality options meaningful because progressive (audio+video) streams are often capped at 720p or lower.
+	var muxErr error
 	if ffmpegPath != "" {
-		v, a := selectMuxFormats(video.Formats)
 		if v != nil && a != nil {
 			fmt.Printf("[DEBUG] Selected video format: %dx%d, mime=%s, bitrate=%d\n", v.Width, v.Height, v.MimeType, v.Bitrate)
 			fmt.Printf("[DEBUG] Selected audio format: mime=%s, bitrate=%d\n", a.MimeType, a.Bitrate)
-			out, muxErr := d.downloadAndMux(ctx, video, v, a, destDir, ffmpegPath, progressCb)
+			var out string
+			out, muxErr = d.downloadAndMux(ctx, video, v, a, destDir, ffmpegPath, tracker)
 			if muxErr == nil {
 				return &DownloadResult{FilePath: out, Method: "mux", Width: v.Width, Height: v.Height}, nil
 			}
@@ -151,22 +183,20 @@ func (d *Downloader) DownloadForPreview(ctx context.Context, url string, destDir
 		}
 	}
 
-	// Find best available progressive format (has both audio+video in one stream).
+	// Fall back to the best available progressive format (has both audio+video in one stream).
 	// YouTube caps these at 720p or lower, so this is the worst-case fallback.
-	format := selectFormat(video.Formats)
 	if format == nil {
+		if muxErr != nil {
+			return nil, fmt.Errorf("failed to download video: %w", muxErr)
+		}
+		if v != nil && a != nil && ffmpegPath == "" {
+			return nil, fmt.Errorf("ffmpeg is required to download the chosen formats")
+		}
 		return nil, fmt.Errorf("no suitable video format found")
 	}
 
 	fmt.Printf("[DEBUG] Falling back to progressive stream: %dx%d, mime=%s\n", format.Width, format.Height, format.MimeType)
 
-	// Get the stream
-	stream, contentLength, err := d.client.GetStreamContext(ctx, video, format)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get stream: %w", err)
-	}
-	defer stream.Close()
-
 	// Create destination file
 	ext := extensionFromMimeType(format.MimeType)
 	if ext == "" {
@@ -174,28 +204,10 @@ func (d *Downloader) DownloadForPreview(ctx context.Context, url string, destDir
 	}
 	filename := sanitizeFilename(video.Title) + ext
 	destPath := filepath.Join(destDir, filename)
-	file, err := os.Create(destPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	// Download with progress tracking
-	if progressCb != nil && contentLength > 0 {
-		reader := &progressReader{
-			reader:        stream,
-			total:         contentLength,
-			progressCb:    progressCb,
-			lastReported:  0,
-			reportedCount: 0,
-		}
-		_, err = io.Copy(file, reader)
-	} else {
-		_, err = io.Copy(file, stream)
-	}
 
-	if err != nil {
-		os.Remove(destPath)
+	// Download with progress tracking, resuming any partial file from an earlier attempt
+	tracker.setStage(StageDownloading)
+	if err := d.downloadToFile(ctx, video, format, destPath, tracker.callback(format.ContentLength), tracker); err != nil {
 		return nil, fmt.Errorf("failed to download video: %w", err)
 	}
 
@@ -204,6 +216,7 @@ func (d *Downloader) DownloadForPreview(ctx context.Context, url string, destDir
 	// If we couldn't get a Safari/WebKit-friendly MP4 (H.264 + AAC), optionally transcode.
 	if needsSafariTranscode(*format) {
 		if ffmpegPath != "" {
+			tracker.setStage(StageMerging)
 			previewPath := filepath.Join(destDir, sanitizeFilename(video.Title)+"-preview.mp4")
 			if err := transcodeToMP4(ctx, ffmpegPath, destPath, previewPath); err == nil {
 				_ = os.Remove(destPath)
@@ -259,44 +272,75 @@ func parseWxH(s string) (int, int) {
 	return w, h
 }
 
-// downloadWithYtdlp uses yt-dlp for reliable high-quality downloads
-func (d *Downloader) downloadWithYtdlp(ctx context.Context, url string, outPath string, ffmpegPath string, ytdlpPath string, progressCb ProgressCallback) error {
-	// Download best H.264 video + AAC audio for Safari/WebKit compatibility
-	// Prefer H.264 (avc1) which Safari can play natively without re-encoding
+// downloadWithYtdlp uses yt-dlp for reliable high-quality downloads.
+// If section is set only that time range is downloaded. The video's chapters are
+// returned from the metadata yt-dlp writes alongside.
+func downloadWithYtdlp(ctx context.Context, url string, outPath string, ffmpegPath string, ytdlpPath string, format string, section *Section, cfg ytdlpConfig, tracker *progressTracker) ([]Chapter, error) {
 	args := []string{
-		"-f", "bestvideo[vcodec^=avc1]+bestaudio[acodec^=mp4a]/bestvideo[vcodec^=avc1]+bestaudio/best[vcodec^=avc1]/bestvideo+bestaudio/best",
+		"-f", format,
 		"--merge-output-format", "mp4",
 		"--ffmpeg-location", filepath.Dir(ffmpegPath),
 		"-o", outPath,
 		"--no-playlist",
 		"--no-warnings",
-		url,
+		"--newline",
+		"--progress-template", ytdlpDownloadTemplate,
+		"--progress-template", ytdlpPostprocessTemplate,
+		"--write-info-json",
+	}
+	if section != nil {
+		args = append(args, "--download-sections", fmt.Sprintf("*%.3f-%.3f", section.Start, section.End))
+	}
+	target, err := ytdlpTarget(url)
+	if err != nil {
+		return nil, err
 	}
+	args = append(args, cfg.args...)
+	args = append(args, target...)
 
-	cmd := exec.CommandContext(ctx, ytdlpPath, args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	// yt-dlp names the metadata after the output file: <name>.info.json
+	infoPath := strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".info.json"
+	defer os.Remove(infoPath)
 
-	// Report some progress (yt-dlp progress is hard to parse, so we fake it)
-	if progressCb != nil {
-		progressCb(0.1)
+	if err := runYtdlp(ctx, ytdlpPath, args, cfg, tracker); err != nil {
+		return nil, err
 	}
 
-	err := cmd.Run()
-
-	if progressCb != nil {
-		progressCb(1.0)
+	// Verify output exists
+	if _, err := os.Stat(outPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("output file not created")
 	}
 
+	return readYtdlpChapters(infoPath), nil
+}
+
+// runYtdlp runs yt-dlp with args, which must include the progress templates, and
+// reports its progress through tracker
+func runYtdlp(ctx context.Context, ytdlpPath string, args []string, cfg ytdlpConfig, tracker *progressTracker) error {
+	cmd := cfg.command(ctx, ytdlpPath, args)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("yt-dlp error: %w: %s", err, stderr.String())
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start yt-dlp: %w", err)
 	}
 
-	// Verify output exists
-	if _, err := os.Stat(outPath); os.IsNotExist(err) {
-		return fmt.Errorf("output file not created")
+	// Parse progress lines as yt-dlp prints them
+	parser := &ytdlpProgressParser{tracker: tracker}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		parser.parseLine(scanner.Text())
 	}
 
+	if err := cmd.Wait(); err != nil {
+		return &ytdlpError{err: err, stderr: stderr.String()}
+	}
+
+	tracker.report(Progress{Fraction: 1.0, Stage: parser.stage, ETA: 0})
 	return nil
 }
 
@@ -315,35 +359,26 @@ func weightedProgress(parent ProgressCallback, base float64, weight float64) Pro
 	}
 }
 
-func (d *Downloader) downloadToFile(ctx context.Context, video *youtube.Video, format *youtube.Format, destPath string, progressCb ProgressCallback) error {
-	stream, contentLength, err := d.client.GetStreamContext(ctx, video, format)
+// downloadStream makes one attempt at downloading a stream to destPath; downloadToFile
+// retries it
+func (d *Downloader) downloadStream(ctx context.Context, video *youtube.Video, format *youtube.Format, destPath string, progressCb ProgressCallback) error {
+	streamURL, err := d.client.GetStreamURLContext(ctx, video, format)
 	if err != nil {
 		return fmt.Errorf("failed to get stream: %w", err)
 	}
-	defer stream.Close()
 
-	file, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
+	d.mu.Lock()
+	workers, chunkSize := d.chunkWorkers, d.chunkSize
+	d.mu.Unlock()
 
-	if progressCb != nil && contentLength > 0 {
-		reader := &progressReader{
-			reader:        stream,
-			total:         contentLength,
-			progressCb:    progressCb,
-			lastReported:  0,
-			reportedCount: 0,
-		}
-		_, err = io.Copy(file, reader)
-	} else {
-		_, err = io.Copy(file, stream)
+	// Parallel ranges need the size up front; otherwise stream it over one connection
+	if workers > 1 && format.ContentLength > chunkSize {
+		return d.downloadURLChunked(ctx, streamURL, format.ContentLength, destPath, workers, chunkSize, progressCb)
 	}
-	return err
+	return d.downloadURLResumable(ctx, streamURL, format.ContentLength, destPath, progressCb)
 }
 
-func (d *Downloader) downloadAndMux(ctx context.Context, video *youtube.Video, videoFmt *youtube.Format, audioFmt *youtube.Format, destDir string, ffmpegPath string, progressCb ProgressCallback) (string, error) {
+func (d *Downloader) downloadAndMux(ctx context.Context, video *youtube.Video, videoFmt *youtube.Format, audioFmt *youtube.Format, destDir string, ffmpegPath string, tracker *progressTracker) (string, error) {
 	videoExt := extensionFromMimeType(videoFmt.MimeType)
 	if videoExt == "" {
 		videoExt = ".mp4"
@@ -363,40 +398,52 @@ func (d *Downloader) downloadAndMux(ctx context.Context, video *youtube.Video, v
 	audioPath := filepath.Join(destDir, baseName+"-audio"+audioExt)
 	outPath := filepath.Join(destDir, baseName+"-preview.mp4")
 
-	// 0-0.75 video, 0.75-0.95 audio, 0.95-1.0 mux
-	if err := d.downloadToFile(ctx, video, videoFmt, videoPath, weightedProgress(progressCb, 0.0, 0.75)); err != nil {
-		_ = os.Remove(videoPath)
-		return "", fmt.Errorf("failed to download video stream: %w", err)
+	// Video and audio download in parallel and share 0-0.95 of the progress range
+	// in proportion to their size; 0.95-1.0 is the mux.
+	// Partial files are left in place on failure so the next attempt can resume them.
+	videoWeight, audioWeight := 0.75, 0.20
+	if videoFmt.ContentLength > 0 && audioFmt.ContentLength > 0 {
+		total := float64(videoFmt.ContentLength + audioFmt.ContentLength)
+		videoWeight = 0.95 * float64(videoFmt.ContentLength) / total
+		audioWeight = 0.95 - videoWeight
+	}
+	tracker.setStage(StageDownloading)
+	parts := mergedProgress(tracker.callback(videoFmt.ContentLength+audioFmt.ContentLength), 0.0, videoWeight, audioWeight)
+
+	dlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var videoErr, audioErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if videoErr = d.downloadToFile(dlCtx, video, videoFmt, videoPath, parts[0], tracker); videoErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if audioErr = d.downloadToFile(dlCtx, video, audioFmt, audioPath, parts[1], tracker); audioErr != nil {
+			cancel()
+		}
+	}()
+	wg.Wait()
+
+	// Report the stream that actually failed rather than the one cancelled because of it
+	if videoErr != nil && (audioErr == nil || !errors.Is(videoErr, context.Canceled)) {
+		return "", fmt.Errorf("failed to download video stream: %w", videoErr)
 	}
-	if err := d.downloadToFile(ctx, video, audioFmt, audioPath, weightedProgress(progressCb, 0.75, 0.20)); err != nil {
-		_ = os.Remove(videoPath)
-		_ = os.Remove(audioPath)
-		return "", fmt.Errorf("failed to download audio stream: %w", err)
+	if audioErr != nil {
+		return "", fmt.Errorf("failed to download audio stream: %w", audioErr)
 	}
 
-	// Determine if we need to transcode video (VP9/AV1 needs conversion to H.264 for Safari/WebKit)
-	needsVideoTranscode := strings.Contains(videoFmt.MimeType, "vp9") || strings.Contains(videoFmt.MimeType, "vp09") ||
-		strings.Contains(videoFmt.MimeType, "av01") || strings.Contains(videoFmt.MimeType, "webm")
-	needsAudioTranscode := strings.Contains(audioFmt.MimeType, "opus") || strings.Contains(audioFmt.MimeType, "webm")
+	tracker.setStage(StageMerging)
 
 	var args []string
 	args = append(args, "-y", "-hide_banner", "-loglevel", "error")
 	args = append(args, "-i", videoPath, "-i", audioPath)
 	args = append(args, "-map", "0:v:0", "-map", "1:a:0")
-
-	if needsVideoTranscode {
-		// Transcode to H.264 with high quality settings
-		args = append(args, "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p")
-	} else {
-		args = append(args, "-c:v", "copy")
-	}
-
-	if needsAudioTranscode {
-		args = append(args, "-c:a", "aac", "-b:a", "192k")
-	} else {
-		args = append(args, "-c:a", "copy")
-	}
-
+	args = append(args, muxCodecArgs(videoFmt, audioFmt)...)
 	args = append(args, "-movflags", "+faststart", "-shortest", outPath)
 
 	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
@@ -416,26 +463,51 @@ func (d *Downloader) downloadAndMux(ctx context.Context, video *youtube.Video, v
 
 	_ = os.Remove(videoPath)
 	_ = os.Remove(audioPath)
-	if progressCb != nil {
-		progressCb(1.0)
-	}
+	tracker.report(Progress{Fraction: 1.0, Stage: StageMerging, ETA: 0})
 	return outPath, nil
 }
 
-// selectFormat picks the best format for preview (720p with audio preferred)
-func selectFormat(formats youtube.FormatList) *youtube.Format {
-	// Prefer MP4 container with H.264 + AAC (most compatible with WebKit/Safari).
-	var mp4H264 []youtube.Format
-	var mp4Any []youtube.Format
+// muxCodecArgs copies streams the preview player can handle and transcodes the rest
+func muxCodecArgs(videoFmt *youtube.Format, audioFmt *youtube.Format) []string {
+	// Determine if we need to transcode video (VP9/AV1 needs conversion to H.264 for Safari/WebKit)
+	needsVideoTranscode := strings.Contains(videoFmt.MimeType, "vp9") || strings.Contains(videoFmt.MimeType, "vp09") ||
+		strings.Contains(videoFmt.MimeType, "av01") || strings.Contains(videoFmt.MimeType, "webm")
+	needsAudioTranscode := strings.Contains(audioFmt.MimeType, "opus") || strings.Contains(audioFmt.MimeType, "webm")
+
+	var args []string
+	if needsVideoTranscode {
+		// Transcode to H.264 with high quality settings
+		args = append(args, "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p")
+	} else {
+		args = append(args, "-c:v", "copy")
+	}
+
+	if needsAudioTranscode {
+		args = append(args, "-c:a", "aac", "-b:a", "192k")
+	} else {
+		args = append(args, "-c:a", "copy")
+	}
+	return args
+}
+
+// selectFormat picks the best format for preview (720p with audio preferred) within ceiling
+func selectFormat(formats youtube.FormatList, ceiling QualityCeiling) *youtube.Format {
 	var withAudio []youtube.Format
 	for _, f := range formats {
 		if f.AudioChannels > 0 && strings.Contains(f.MimeType, "video") {
 			withAudio = append(withAudio, f)
-			if strings.Contains(f.MimeType, "video/mp4") {
-				mp4Any = append(mp4Any, f)
-				if strings.Contains(f.MimeType, "avc1") && strings.Contains(f.MimeType, "mp4a") {
-					mp4H264 = append(mp4H264, f)
-				}
+		}
+	}
+	withAudio = ceiling.limit(withAudio)
+
+	// Prefer MP4 container with H.264 + AAC (most compatible with WebKit/Safari).
+	var mp4H264 []youtube.Format
+	var mp4Any []youtube.Format
+	for _, f := range withAudio {
+		if strings.Contains(f.MimeType, "video/mp4") {
+			mp4Any = append(mp4Any, f)
+			if strings.Contains(f.MimeType, "avc1") && strings.Contains(f.MimeType, "mp4a") {
+				mp4H264 = append(mp4H264, f)
 			}
 		}
 	}
@@ -462,7 +534,7 @@ func selectFormat(formats youtube.FormatList) *youtube.Format {
 	return nil
 }
 
-func selectMuxFormats(formats youtube.FormatList) (*youtube.Format, *youtube.Format) {
+func selectMuxFormats(formats youtube.FormatList, ceiling QualityCeiling) (*youtube.Format, *youtube.Format) {
 	// Video-only: prefer highest resolution, accepting MP4/H.264, WebM/VP9, or MP4/AV1.
 	// Modern YouTube often serves highest quality in VP9 or AV1 rather than H.264.
 	var videoOnly []youtube.Format
@@ -500,7 +572,7 @@ func selectMuxFormats(formats youtube.FormatList) (*youtube.Format, *youtube.For
 		audioOnly = append(audioOnly, f)
 	}
 
-	bestVideo := pickBestVideoOnly(videoOnly)
+	bestVideo := pickBestVideoOnly(ceiling.preferCodec(ceiling.limit(videoOnly)))
 	bestAudio := pickBestAudioOnly(audioOnly)
 	return bestVideo, bestAudio
 }
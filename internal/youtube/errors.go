@@ -0,0 +1,291 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ErrorCategory says why a video couldn't be fetched, so the user can be told what to do
+type ErrorCategory string
+
+const (
+	CategoryUnknown           ErrorCategory = "unknown"
+	CategoryUnavailable       ErrorCategory = "unavailable"        // Removed, private or never existed
+	CategoryAgeRestricted     ErrorCategory = "age-restricted"     // Needs a signed-in adult account
+	CategoryMembersOnly       ErrorCategory = "members-only"       // Needs a channel membership
+	CategoryGeoBlocked        ErrorCategory = "geo-blocked"        // Not available in this country
+	CategoryLiveNotEnded      ErrorCategory = "live-not-ended"     // An upcoming or still running live stream or premiere
+	CategoryNetwork           ErrorCategory = "network"            // YouTube couldn't be reached
+	CategoryThrottled         ErrorCategory = "throttled"          // YouTube is refusing or rate limiting requests
+	CategoryExtractorOutdated ErrorCategory = "extractor-outdated" // YouTube changed and the downloader needs an update
+	CategoryDiskFull          ErrorCategory = "disk-full"
+)
+
+// categoryPriority orders categories from most to least useful to report when sources
+// disagree. Facts about the video beat transient problems, which beat guesses.
+var categoryPriority = []ErrorCategory{
+	CategoryDiskFull,
+	CategoryUnavailable,
+	CategoryAgeRestricted,
+	CategoryMembersOnly,
+	CategoryGeoBlocked,
+	CategoryLiveNotEnded,
+	CategoryThrottled,
+	CategoryExtractorOutdated,
+	CategoryNetwork,
+	CategoryUnknown,
+}
+
+// categoryPattern maps phrases found in an error message to a category
+type categoryPattern struct {
+	category ErrorCategory
+	patterns []string
+}
+
+// youtubePatterns recognize YouTube's reasons for refusing a video, as given in kkdai's
+// playability errors and yt-dlp's "ERROR: [youtube] ID: ..." lines, matched lowercase in order
+var youtubePatterns = []categoryPattern{
+	{CategoryMembersOnly, []string{"join this channel to get access to members-only content", "available to this channel's members"}},
+	{CategoryAgeRestricted, []string{"sign in to confirm your age", "age-restricted"}},
+	{CategoryGeoBlocked, []string{"not made this video available in your country", "not available in your country", "not available from your location"}},
+	{CategoryLiveNotEnded, []string{"this live event will begin", "premieres in", "is not currently live", "this live stream recording is not available", "is upcoming"}},
+	{CategoryUnavailable, []string{"video unavailable", "private video", "this video is private", "has been removed", "no longer available", "account associated with this video has been terminated", "playlist does not exist", "channel does not exist"}},
+	{CategoryThrottled, []string{"confirm you're not a bot", "confirm you’re not a bot"}},
+	{CategoryExtractorOutdated, []string{"unable to extract", "failed to extract any player response", "nsig extraction failed", "signature extraction failed"}},
+}
+
+// ytdlpPatterns recognize failures that yt-dlp reports from fetching and writing rather
+// than from YouTube, in any of its ERROR lines
+var ytdlpPatterns = []categoryPattern{
+	{CategoryDiskFull, []string{"[errno 28]", "no space left on device"}},
+	{CategoryThrottled, []string{"http error 429", "too many requests"}},
+	{CategoryNetwork, []string{"<urlopen error", "temporary failure in name resolution", "name or service not known", "connection refused", "connection reset", "network is unreachable", "read timed out", "remote end closed connection"}},
+}
+
+// matchPatterns returns the category of the first pattern found in msg
+func matchPatterns(msg string, rules []categoryPattern) (ErrorCategory, bool) {
+	msg = strings.ToLower(msg)
+	for _, p := range rules {
+		for _, pattern := range p.patterns {
+			if strings.Contains(msg, pattern) {
+				return p.category, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Attempt is one source's try at a video
+type Attempt struct {
+	Source   string        `json:"source"`
+	Category ErrorCategory `json:"category"`
+	Message  string        `json:"message"`
+	Skipped  bool          `json:"skipped,omitempty"` // The source couldn't be used, e.g. a program isn't installed
+	Err      error         `json:"-"`
+}
+
+// DownloadError is returned when no source could fetch a video. It records what each
+// source tried and why it failed.
+type DownloadError struct {
+	Category ErrorCategory `json:"category"`
+	Action   string        `json:"action"` // "resolve", "download", ...
+	URL      string        `json:"url"`
+	Attempts []Attempt     `json:"attempts"`
+}
+
+func (e *DownloadError) Error() string {
+	primary := e.primary()
+	if primary == nil {
+		return fmt.Sprintf("failed to %s %s", e.Action, e.URL)
+	}
+	return fmt.Sprintf("%s: %s", primary.Source, primary.Message)
+}
+
+// Unwrap returns the errors of all attempts, so errors.Is and errors.As see them
+func (e *DownloadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		errs = append(errs, a.Err)
+	}
+	return errs
+}
+
+// primary returns the attempt whose failure explains the error best: the first of the
+// error's category, preferring sources that actually tried
+func (e *DownloadError) primary() *Attempt {
+	var fallback *Attempt
+	for i := range e.Attempts {
+		a := &e.Attempts[i]
+		if a.Category != e.Category {
+			continue
+		}
+		if !a.Skipped {
+			return a
+		}
+		if fallback == nil {
+			fallback = a
+		}
+	}
+	return fallback
+}
+
+// newDownloadError sums up failed attempts, taking the most useful category among them
+func newDownloadError(action string, url string, attempts []Attempt) *DownloadError {
+	e := &DownloadError{Category: CategoryUnknown, Action: action, URL: url, Attempts: attempts}
+	best := len(categoryPriority)
+	for _, a := range attempts {
+		if a.Skipped && len(attempts) > 1 {
+			continue
+		}
+		for i, c := range categoryPriority {
+			if c == a.Category && i < best {
+				best = i
+				e.Category = c
+			}
+		}
+	}
+	// Only skipped sources: report the first one's reason
+	if best == len(categoryPriority) && len(attempts) > 0 {
+		e.Category = attempts[0].Category
+	}
+	return e
+}
+
+// CategoryOf returns the category of an error from the Downloader, CategoryUnknown if
+// it doesn't fit any
+func CategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+	var dlErr *DownloadError
+	if errors.As(err, &dlErr) {
+		return dlErr.Category
+	}
+	return classify(err)
+}
+
+// classify works out an error's category from its type. Messages are only read where
+// they come from YouTube or yt-dlp; anything else is CategoryUnknown.
+func classify(err error) ErrorCategory {
+	if errors.Is(err, syscall.ENOSPC) {
+		return CategoryDiskFull
+	}
+	if errors.Is(err, youtube.ErrVideoPrivate) {
+		return CategoryUnavailable
+	}
+	if errors.Is(err, youtube.ErrLoginRequired) {
+		return CategoryAgeRestricted
+	}
+	if errors.Is(err, youtube.ErrCipherNotFound) || errors.Is(err, youtube.ErrSignatureTimestampNotFound) {
+		return CategoryExtractorOutdated
+	}
+	if errors.Is(err, youtube.ErrInvalidPlaylist) {
+		return CategoryUnavailable
+	}
+	var liveErr *LiveError
+	if errors.As(err, &liveErr) {
+		return CategoryLiveNotEnded
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == 429 {
+		return CategoryThrottled
+	}
+	var unexpected youtube.ErrUnexpectedStatusCode
+	if errors.As(err, &unexpected) && unexpected == 429 {
+		return CategoryThrottled
+	}
+
+	var ytdlpErr *ytdlpError
+	if errors.As(err, &ytdlpErr) {
+		if category, ok := ytdlpErr.category(); ok {
+			return category
+		}
+	}
+	var playability *youtube.ErrPlayabiltyStatus
+	if errors.As(err, &playability) {
+		if category, ok := matchPatterns(playability.Reason, youtubePatterns); ok {
+			return category
+		}
+		switch playability.Status {
+		case "LIVE_STREAM_OFFLINE":
+			return CategoryLiveNotEnded
+		case "ERROR", "UNPLAYABLE":
+			return CategoryUnavailable
+		}
+	}
+	var playlistErr youtube.ErrPlaylistStatus
+	if errors.As(err, &playlistErr) {
+		if category, ok := matchPatterns(playlistErr.Reason, youtubePatterns); ok {
+			return category
+		}
+		return CategoryUnavailable
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return CategoryNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CategoryNetwork
+	}
+	return CategoryUnknown
+}
+
+// ytdlpError is a failed yt-dlp run. Its message is just yt-dlp's ERROR lines; the
+// full stderr is kept for debugging.
+type ytdlpError struct {
+	err    error
+	stderr string
+}
+
+func (e *ytdlpError) Error() string {
+	lines := e.errorLines()
+	if len(lines) == 0 {
+		// No ERROR lines: the last line of output is the best guess
+		if last := strings.TrimSpace(e.stderr); last != "" {
+			parts := strings.Split(last, "\n")
+			lines = append(lines, strings.TrimSpace(parts[len(parts)-1]))
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("yt-dlp error: %v", e.err)
+	}
+	return "yt-dlp error: " + strings.Join(lines, "; ")
+}
+
+// errorLines returns yt-dlp's ERROR messages, without the prefix
+func (e *ytdlpError) errorLines() []string {
+	var lines []string
+	for _, line := range strings.Split(e.stderr, "\n") {
+		if msg, ok := strings.CutPrefix(strings.TrimSpace(line), "ERROR: "); ok {
+			lines = append(lines, msg)
+		}
+	}
+	return lines
+}
+
+// category recognizes the run's failure from its ERROR lines. Only the YouTube
+// extractor's lines ("[youtube] ID: ..." and "[youtube:tab] ...") carry YouTube's reasons.
+func (e *ytdlpError) category() (ErrorCategory, bool) {
+	for _, line := range e.errorLines() {
+		if strings.HasPrefix(line, "[youtube]") || strings.HasPrefix(line, "[youtube:") {
+			if category, ok := matchPatterns(line, youtubePatterns); ok {
+				return category, true
+			}
+		}
+		if category, ok := matchPatterns(line, ytdlpPatterns); ok {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+func (e *ytdlpError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,70 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// ytdlpFailure is a failed yt-dlp run with the given stderr
+func ytdlpFailure(stderr string) error {
+	return &ytdlpError{err: errors.New("exit status 1"), stderr: stderr}
+}
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		// yt-dlp, as its YouTube extractor reports things
+		{"ytdlp private", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Private video. Sign in if you've been granted access to this video\n"), CategoryUnavailable},
+		{"ytdlp removed", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Video unavailable. This video has been removed by the uploader\n"), CategoryUnavailable},
+		{"ytdlp age", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Sign in to confirm your age. This video may be inappropriate for some users. Use --cookies-from-browser or --cookies for the authentication.\n"), CategoryAgeRestricted},
+		{"ytdlp members", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Join this channel to get access to members-only content like this video, and other exclusive perks.\n"), CategoryMembersOnly},
+		{"ytdlp geo", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: The uploader has not made this video available in your country\n"), CategoryGeoBlocked},
+		{"ytdlp upcoming", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: This live event will begin in 3 hours.\n"), CategoryLiveNotEnded},
+		{"ytdlp bot check", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Sign in to confirm you’re not a bot. Use --cookies-from-browser or --cookies for the authentication.\n"), CategoryThrottled},
+		{"ytdlp 429", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Unable to download API page: HTTP Error 429: Too Many Requests (caused by <HTTPError 429: Too Many Requests>)\n"), CategoryThrottled},
+		{"ytdlp outdated", ytdlpFailure("WARNING: [youtube] dQw4w9WgXcQ: nsig extraction failed: Some formats may be missing\nERROR: [youtube] dQw4w9WgXcQ: Unable to extract yt initial data; please report this issue on  https://github.com/yt-dlp/yt-dlp/issues\n"), CategoryExtractorOutdated},
+		{"ytdlp channel", ytdlpFailure("ERROR: [youtube:tab] @nobody: This channel does not exist.\n"), CategoryUnavailable},
+		{"ytdlp no network", ytdlpFailure("ERROR: [youtube] dQw4w9WgXcQ: Unable to download webpage: <urlopen error [Errno -3] Temporary failure in name resolution> (caused by TransportError('<urlopen error [Errno -3] Temporary failure in name resolution>'))\n"), CategoryNetwork},
+		{"ytdlp disk full", ytdlpFailure("[download] Destination: /tmp/x.mp4\nERROR: unable to write data: [Errno 28] No space left on device\n"), CategoryDiskFull},
+
+		// yt-dlp failures that don't say why
+		{"ytdlp 403", ytdlpFailure("ERROR: unable to download video data: HTTP Error 403: Forbidden\n"), CategoryUnknown},
+		{"ytdlp warning only", ytdlpFailure("WARNING: [youtube] dQw4w9WgXcQ: nsig extraction failed: Some formats may be missing\nERROR: Postprocessing: Conversion failed!\n"), CategoryUnknown},
+		{"ytdlp other site", ytdlpFailure("ERROR: [generic] Video unavailable\n"), CategoryUnknown},
+
+		// kkdai
+		{"kkdai private", fmt.Errorf("failed to get video info: %w", youtube.ErrVideoPrivate), CategoryUnavailable},
+		{"kkdai age", youtube.ErrLoginRequired, CategoryAgeRestricted},
+		{"kkdai cipher", youtube.ErrCipherNotFound, CategoryExtractorOutdated},
+		{"kkdai members", &youtube.ErrPlayabiltyStatus{Status: "UNPLAYABLE", Reason: "Join this channel to get access to members-only content like this video, and other exclusive perks."}, CategoryMembersOnly},
+		{"kkdai unplayable", &youtube.ErrPlayabiltyStatus{Status: "ERROR", Reason: "Something else"}, CategoryUnavailable},
+		{"kkdai offline", &youtube.ErrPlayabiltyStatus{Status: "LIVE_STREAM_OFFLINE"}, CategoryLiveNotEnded},
+		{"kkdai playlist", youtube.ErrPlaylistStatus{Reason: "The playlist does not exist."}, CategoryUnavailable},
+		{"kkdai 429", youtube.ErrUnexpectedStatusCode(429), CategoryThrottled},
+		{"kkdai 403", youtube.ErrUnexpectedStatusCode(403), CategoryUnknown},
+
+		// Ours
+		{"stream 429", &HTTPStatusError{StatusCode: 429}, CategoryThrottled},
+		{"stream 403", &HTTPStatusError{StatusCode: 403}, CategoryUnknown},
+		{"live", &LiveError{Status: LiveStatusUpcoming}, CategoryLiveNotEnded},
+
+		// Anything else is only looked at by type, whatever it says
+		{"missing file", fmt.Errorf("failed to open file: %w", &os.PathError{Op: "open", Path: "/tmp/x", Err: os.ErrNotExist}), CategoryUnknown},
+		{"message only", errors.New("output directory does not exist"), CategoryUnknown},
+		{"message 403", errors.New("http error 403: forbidden"), CategoryUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CategoryOf(tt.err); got != tt.want {
+				t.Errorf("CategoryOf(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// FormatInfo describes one of the streams YouTube offers for a video
+type FormatInfo struct {
+	Itag         int    `json:"itag"`
+	Container    string `json:"container"`  // "mp4", "webm", ...
+	VideoCodec   string `json:"videoCodec"` // e.g. "avc1.640028", empty for audio-only streams
+	AudioCodec   string `json:"audioCodec"` // e.g. "mp4a.40.2", empty for video-only streams
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FPS          int    `json:"fps"`
+	Bitrate      int    `json:"bitrate"` // bits per second
+	Size         int64  `json:"size"`    // bytes, 0 if YouTube doesn't say
+	QualityLabel string `json:"qualityLabel"`
+	AudioOnly    bool   `json:"audioOnly"`
+	VideoOnly    bool   `json:"videoOnly"`
+}
+
+// FormatOptions overrides the automatic format choice with specific streams, by itag.
+// Zero leaves that stream to the automatic pickers, which stay within Ceiling. A video
+// itag of a stream that has audio is downloaded on its own unless an audio itag is also
+// given. AudioOnly skips the video entirely; VideoItag and Ceiling don't apply then.
+type FormatOptions struct {
+	VideoItag int            `json:"videoItag"`
+	AudioItag int            `json:"audioItag"`
+	Ceiling   QualityCeiling `json:"ceiling"`
+	AudioOnly bool           `json:"audioOnly"`
+}
+
+// QualityCeiling limits the streams the automatic pickers choose, to save bandwidth and
+// disk when the source is better than needed. Zero values mean no limit. When no stream
+// fits, the smallest one is used instead.
+type QualityCeiling struct {
+	MaxHeight int    `json:"maxHeight"`
+	MaxFPS    int    `json:"maxFps"`
+	Codec     string `json:"codec"` // Preferred video codec: "avc1" (H.264), "vp9" or "av01"; "" for the default
+}
+
+// Video codecs a QualityCeiling can prefer
+var ceilingCodecs = []string{"avc1", "vp9", "av01"}
+
+// Validate checks that the ceiling's values make sense
+func (c QualityCeiling) Validate() error {
+	if c.MaxHeight < 0 || c.MaxFPS < 0 {
+		return fmt.Errorf("quality limits can't be negative")
+	}
+	if c.Codec == "" {
+		return nil
+	}
+	for _, codec := range ceilingCodecs {
+		if c.Codec == codec {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported codec %q", c.Codec)
+}
+
+// allows reports whether a video stream is within the height and frame rate limits
+func (c QualityCeiling) allows(f youtube.Format) bool {
+	if c.MaxHeight > 0 && f.Height > c.MaxHeight {
+		return false
+	}
+	if c.MaxFPS > 0 && f.FPS > c.MaxFPS {
+		return false
+	}
+	return true
+}
+
+// limit returns the video streams within the ceiling. If none are, the smallest stream
+// is returned on its own as the closest there is.
+func (c QualityCeiling) limit(formats []youtube.Format) []youtube.Format {
+	var within []youtube.Format
+	for _, f := range formats {
+		if c.allows(f) {
+			within = append(within, f)
+		}
+	}
+	if len(within) > 0 || len(formats) == 0 {
+		return within
+	}
+
+	smallest := formats[0]
+	for _, f := range formats[1:] {
+		if f.Height < smallest.Height || (f.Height == smallest.Height && f.FPS < smallest.FPS) {
+			smallest = f
+		}
+	}
+	fmt.Printf("[DEBUG] No stream within %dp/%dfps, using smallest: %dx%d@%d\n", c.MaxHeight, c.MaxFPS, smallest.Width, smallest.Height, smallest.FPS)
+	return []youtube.Format{smallest}
+}
+
+// preferCodec narrows formats to those in the preferred codec, if there are any
+func (c QualityCeiling) preferCodec(formats []youtube.Format) []youtube.Format {
+	if c.Codec == "" {
+		return formats
+	}
+	var preferred []youtube.Format
+	for _, f := range formats {
+		if codecMatches(f.MimeType, c.Codec) {
+			preferred = append(preferred, f)
+		}
+	}
+	if len(preferred) == 0 {
+		return formats
+	}
+	return preferred
+}
+
+// codecMatches reports whether mimeType's codecs include codec. VP9 shows up as either
+// "vp9" or "vp09.xx".
+func codecMatches(mimeType string, codec string) bool {
+	if codec == "vp9" {
+		return strings.Contains(mimeType, "vp9") || strings.Contains(mimeType, "vp09")
+	}
+	return strings.Contains(mimeType, codec)
+}
+
+// explicit reports whether any stream was chosen by hand
+func (o FormatOptions) explicit() bool {
+	return o.VideoItag != 0 || o.AudioItag != 0
+}
+
+// audioCodecPrefixes identifies the audio entries in a combined codecs list
+var audioCodecPrefixes = []string{"mp4a", "opus", "vorbis", "ac-3", "ec-3", "flac"}
+
+// ListFormats returns every stream available for a video, from the first source that
+// can list them
+func (d *Downloader) ListFormats(ctx context.Context, url string, tools Tools) ([]FormatInfo, error) {
+	var formats []FormatInfo
+	err := d.trySources(ctx, url, "list formats of", func(s Source) error {
+		var err error
+		formats, err = s.ListFormats(ctx, url, tools)
+		return err
+	})
+	return formats, err
+}
+
+func describeFormat(f youtube.Format) FormatInfo {
+	info := FormatInfo{
+		Itag:         f.ItagNo,
+		Width:        f.Width,
+		Height:       f.Height,
+		FPS:          f.FPS,
+		Bitrate:      f.Bitrate,
+		Size:         f.ContentLength,
+		QualityLabel: f.QualityLabel,
+	}
+
+	// MimeType looks like: video/mp4; codecs="avc1.640028, mp4a.40.2"
+	mediaType, params, _ := strings.Cut(f.MimeType, ";")
+	kind, container, _ := strings.Cut(strings.TrimSpace(mediaType), "/")
+	info.Container = container
+	if _, codecs, ok := strings.Cut(params, "codecs="); ok {
+		for _, codec := range strings.Split(strings.Trim(strings.TrimSpace(codecs), `"`), ",") {
+			codec = strings.TrimSpace(codec)
+			if kind == "audio" || isAudioCodec(codec) {
+				info.AudioCodec = codec
+			} else {
+				info.VideoCodec = codec
+			}
+		}
+	}
+
+	hasAudio := kind == "audio" || f.AudioChannels > 0
+	hasVideo := kind == "video" && (f.Width > 0 || info.VideoCodec != "")
+	info.AudioOnly = hasAudio && !hasVideo
+	info.VideoOnly = hasVideo && !hasAudio
+	return info
+}
+
+func isAudioCodec(codec string) bool {
+	for _, prefix := range audioCodecPrefixes {
+		if strings.HasPrefix(codec, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickFormats chooses the streams to download: a video-only and an audio-only stream
+// to mux, and a single stream with both to fall back to. Streams not chosen in opts are
+// picked automatically. When opts names streams there is no automatic fallback, so the
+// result is what was asked for or nothing.
+func pickFormats(formats youtube.FormatList, opts FormatOptions) (muxVideo *youtube.Format, muxAudio *youtube.Format, progressive *youtube.Format, err error) {
+	if !opts.explicit() {
+		muxVideo, muxAudio = selectMuxFormats(formats, opts.Ceiling)
+		return muxVideo, muxAudio, selectFormat(formats, opts.Ceiling), nil
+	}
+
+	if opts.VideoItag != 0 {
+		f := findFormat(formats, opts.VideoItag)
+		if f == nil {
+			return nil, nil, nil, fmt.Errorf("format %d is not available for this video", opts.VideoItag)
+		}
+		if !strings.Contains(f.MimeType, "video") {
+			return nil, nil, nil, fmt.Errorf("format %d has no video", opts.VideoItag)
+		}
+		if f.AudioChannels > 0 && opts.AudioItag == 0 {
+			return nil, nil, f, nil
+		}
+		muxVideo = f
+	}
+
+	if opts.AudioItag != 0 {
+		f := findFormat(formats, opts.AudioItag)
+		if f == nil {
+			return nil, nil, nil, fmt.Errorf("format %d is not available for this video", opts.AudioItag)
+		}
+		if f.AudioChannels <= 0 {
+			return nil, nil, nil, fmt.Errorf("format %d has no audio", opts.AudioItag)
+		}
+		muxAudio = f
+	}
+
+	if muxVideo == nil {
+		muxVideo, _ = selectMuxFormats(formats, opts.Ceiling)
+		if muxVideo == nil {
+			return nil, nil, nil, fmt.Errorf("no video stream to go with audio format %d", opts.AudioItag)
+		}
+	}
+	if muxAudio == nil {
+		_, muxAudio = selectMuxFormats(formats, opts.Ceiling)
+		if muxAudio == nil {
+			return nil, nil, nil, fmt.Errorf("no audio stream to go with video format %d", opts.VideoItag)
+		}
+	}
+	return muxVideo, muxAudio, nil, nil
+}
+
+func findFormat(formats youtube.FormatList, itag int) *youtube.Format {
+	matches := formats.Itag(itag)
+	if len(matches) == 0 {
+		return nil
+	}
+	return &matches[0]
+}
+
+// ytdlpFormat is the yt-dlp -f selector for the streams the options ask for, matching
+// what pickFormats chooses. YouTube format IDs are itags, so explicit choices carry
+// over directly.
+func (o FormatOptions) ytdlpFormat() string {
+	switch {
+	case o.AudioOnly && o.AudioItag != 0:
+		return fmt.Sprintf("%d", o.AudioItag)
+	case o.AudioOnly:
+		return "bestaudio[acodec^=mp4a]/bestaudio"
+	case o.VideoItag != 0 && o.AudioItag != 0:
+		return fmt.Sprintf("%d+%d", o.VideoItag, o.AudioItag)
+	case o.VideoItag != 0:
+		// A stream with audio on its own, otherwise the best audio to go with it
+		return fmt.Sprintf("%d[acodec!=none]/%d+bestaudio", o.VideoItag, o.VideoItag)
+	case o.AudioItag != 0:
+		codec, limit := o.Ceiling.ytdlpFilters()
+		return fmt.Sprintf("bestvideo%s%s+%d/bestvideo%s+%d", codec, limit, o.AudioItag, limit, o.AudioItag)
+	default:
+		return o.Ceiling.ytdlpFormat()
+	}
+}
+
+// ytdlpFormat is the yt-dlp -f selector for the automatic choice within the ceiling
+func (c QualityCeiling) ytdlpFormat() string {
+	if c == (QualityCeiling{}) {
+		// Download best H.264 video + AAC audio for Safari/WebKit compatibility
+		// Prefer H.264 (avc1) which Safari can play natively without re-encoding
+		return "bestvideo[vcodec^=avc1]+bestaudio[acodec^=mp4a]/bestvideo[vcodec^=avc1]+bestaudio/best[vcodec^=avc1]/bestvideo+bestaudio/best"
+	}
+
+	codec, limit := c.ytdlpFilters()
+
+	return strings.Join([]string{
+		"bestvideo" + codec + limit + "+bestaudio[acodec^=mp4a]",
+		"bestvideo" + codec + limit + "+bestaudio",
+		"best" + codec + limit,
+		"bestvideo" + limit + "+bestaudio",
+		"best" + limit,
+		// Nothing within the limits: the smallest stream is the closest
+		"worstvideo+bestaudio",
+		"worst",
+	}, "/")
+}
+
+// ytdlpFilters returns yt-dlp format filters for the preferred codec (H.264 unless set)
+// and for the height and frame rate limits
+func (c QualityCeiling) ytdlpFilters() (codec string, limit string) {
+	codec = "[vcodec^=avc1]"
+	switch c.Codec {
+	case "vp9":
+		codec = "[vcodec~='^vp0?9']"
+	case "av01":
+		codec = "[vcodec^=av01]"
+	}
+	if c.MaxHeight > 0 {
+		limit += fmt.Sprintf("[height<=%d]", c.MaxHeight)
+	}
+	if c.MaxFPS > 0 {
+		limit += fmt.Sprintf("[fps<=%d]", c.MaxFPS)
+	}
+	return codec, limit
+}
@@ -0,0 +1,433 @@
+package youtube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// LiveStatus says whether a video is a livestream or premiere, and what state it is in
+type LiveStatus string
+
+const (
+	LiveStatusNone     LiveStatus = ""          // An ordinary video, or a stream whose recording is ready
+	LiveStatusLive     LiveStatus = "live"      // Streaming now; see CaptureLive
+	LiveStatusUpcoming LiveStatus = "upcoming"  // A stream or premiere that hasn't started
+	LiveStatusPostLive LiveStatus = "post_live" // Ended, but YouTube is still processing the recording
+)
+
+// maxManifestSize caps how much of an HLS playlist is read
+const maxManifestSize = 8 * 1024 * 1024
+
+// LiveError means a video can't be downloaded as a whole because it is a livestream
+// that is running, hasn't started or is still being processed
+type LiveError struct {
+	Status         LiveStatus
+	ScheduledStart int64  // Unix seconds, 0 if unknown
+	Message        string // YouTube's explanation, if any
+}
+
+func (e *LiveError) Error() string {
+	switch e.Status {
+	case LiveStatusLive:
+		return "this video is a livestream that is still running; capture it instead"
+	case LiveStatusUpcoming:
+		if e.ScheduledStart > 0 {
+			return fmt.Sprintf("this livestream or premiere starts at %s", time.Unix(e.ScheduledStart, 0).Format("Jan 2 15:04"))
+		}
+		if e.Message != "" {
+			return e.Message
+		}
+		return "this livestream or premiere hasn't started yet"
+	case LiveStatusPostLive:
+		return "this livestream has ended, but YouTube is still processing the recording"
+	}
+	return "this livestream can't be downloaded"
+}
+
+// Downloadable returns a *LiveError if the video is a livestream that can't be
+// downloaded as a whole yet
+func (v *VideoInfo) Downloadable() error {
+	if v.LiveStatus == LiveStatusNone {
+		return nil
+	}
+	return &LiveError{Status: v.LiveStatus, ScheduledStart: v.ScheduledStart, Message: v.LiveMessage}
+}
+
+// builtinLiveStatus works out the live status of a video resolved with the Go library.
+// Running streams have an HLS manifest and no length. The library can't tell a
+// stream still being processed from an ordinary video.
+func builtinLiveStatus(video *youtube.Video) LiveStatus {
+	if video.HLSManifestURL != "" && video.Duration == 0 {
+		return LiveStatusLive
+	}
+	return LiveStatusNone
+}
+
+// upcomingFromError returns the info of an upcoming stream, which the Go library
+// reports as unplayable, or nil if err is about something else
+func upcomingFromError(videoID string, err error) *VideoInfo {
+	var status *youtube.ErrPlayabiltyStatus
+	if !errors.As(err, &status) || status.Status != "LIVE_STREAM_OFFLINE" {
+		return nil
+	}
+	return &VideoInfo{ID: videoID, LiveStatus: LiveStatusUpcoming, LiveMessage: status.Reason}
+}
+
+// ytdlpLiveStatus maps yt-dlp's live_status field
+func ytdlpLiveStatus(status string) LiveStatus {
+	switch status {
+	case "is_live":
+		return LiveStatusLive
+	case "is_upcoming":
+		return LiveStatusUpcoming
+	case "post_live":
+		return LiveStatusPostLive
+	}
+	return LiveStatusNone
+}
+
+// LiveCapture says what to record of a running livestream
+type LiveCapture struct {
+	LastMinutes float64 `json:"lastMinutes"` // Record this much from the stream's DVR window, ending now; 0 records from now on
+	MaxMinutes  float64 `json:"maxMinutes"`  // When recording from now, stop after this long; 0 records until stopped
+}
+
+// Validate checks the capture's durations
+func (c LiveCapture) Validate() error {
+	if c.LastMinutes < 0 || c.MaxMinutes < 0 {
+		return fmt.Errorf("capture durations can't be negative")
+	}
+	return nil
+}
+
+// hlsPlaylist is the part of an HLS playlist needed to record a livestream
+type hlsPlaylist struct {
+	variants []hlsVariant // Set for master playlists
+	segments int          // Media segments, for media playlists
+	window   float64      // Their total length in seconds
+}
+
+type hlsVariant struct {
+	url       string
+	bandwidth int64
+}
+
+// parseHLSPlaylist reads a master or media playlist; relative URLs are resolved
+// against base
+func parseHLSPlaylist(r io.Reader, base *neturl.URL) (*hlsPlaylist, error) {
+	p := &hlsPlaylist{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	var pending *hlsVariant
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first {
+			if line != "#EXTM3U" {
+				return nil, fmt.Errorf("not an HLS playlist")
+			}
+			first = false
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			pending = &hlsVariant{}
+			for _, attr := range strings.Split(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"), ",") {
+				if value, ok := strings.CutPrefix(attr, "BANDWIDTH="); ok {
+					pending.bandwidth, _ = strconv.ParseInt(value, 10, 64)
+				}
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				p.window += seconds
+				p.segments++
+			}
+		case strings.HasPrefix(line, "#"):
+		case pending != nil:
+			u, err := base.Parse(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid variant URL %q", line)
+			}
+			pending.url = u.String()
+			p.variants = append(p.variants, *pending)
+			pending = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if first {
+		return nil, fmt.Errorf("empty HLS playlist")
+	}
+	return p, nil
+}
+
+// fetchHLSPlaylist downloads and parses an HLS playlist
+func (d *Downloader) fetchHLSPlaylist(ctx context.Context, playlistURL string) (*hlsPlaylist, error) {
+	base, err := neturl.Parse(playlistURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", streamUserAgent)
+	resp, err := d.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch livestream playlist: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	return parseHLSPlaylist(io.LimitReader(resp.Body, maxManifestSize), base)
+}
+
+// mediaPlaylist fetches the playlist at playlistURL and, if it is a master playlist,
+// the media playlist of its best variant. It returns the media playlist and its URL.
+func (d *Downloader) mediaPlaylist(ctx context.Context, playlistURL string) (string, *hlsPlaylist, error) {
+	playlist, err := d.fetchHLSPlaylist(ctx, playlistURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(playlist.variants) == 0 {
+		return playlistURL, playlist, nil
+	}
+	best := playlist.variants[0]
+	for _, v := range playlist.variants[1:] {
+		if v.bandwidth > best.bandwidth {
+			best = v
+		}
+	}
+	if playlist, err = d.fetchHLSPlaylist(ctx, best.url); err != nil {
+		return "", nil, err
+	}
+	return best.url, playlist, nil
+}
+
+// captureRange returns the segment of the media playlist p that ffmpeg should start
+// recording at (see recordLive) and how many seconds to record
+func (p *hlsPlaylist) captureRange(capture LiveCapture) (string, float64) {
+	// ffmpeg starts a live playlist a few segments from its end unless told otherwise
+	switch {
+	case capture.LastMinutes > 0:
+		length := capture.LastMinutes * 60
+		if p.segments == 0 || length >= p.window {
+			fmt.Printf("[DEBUG] DVR window is %.0fs; recording all of it\n", p.window)
+			return "0", p.window
+		}
+		segment := p.window / float64(p.segments)
+		return strconv.Itoa(-int(math.Ceil(length / segment))), length
+	case capture.MaxMinutes > 0:
+		return "", capture.MaxMinutes * 60
+	}
+	return "", 0
+}
+
+// liveManifestURL finds the HLS playlist of a running livestream, from the Go
+// library or, failing that, yt-dlp
+func (d *Downloader) liveManifestURL(ctx context.Context, url string, ytdlpPath string) (string, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return "", err
+	}
+	video, err := d.client.GetVideoContext(ctx, videoID)
+	if err == nil && video.HLSManifestURL != "" {
+		return video.HLSManifestURL, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("the video has no livestream playlist; is it live?")
+	}
+	if ytdlpPath == "" {
+		return "", fmt.Errorf("failed to find the livestream: %w", err)
+	}
+
+	fmt.Printf("[DEBUG] Go library found no livestream playlist (%v), asking yt-dlp\n", err)
+	target, err := ytdlpTarget(url)
+	if err != nil {
+		return "", err
+	}
+	cfg := d.ytdlpConfig()
+	args := append([]string{"-g", "-f", "b", "--no-playlist", "--no-warnings"}, cfg.args...)
+	cmd := cfg.command(ctx, ytdlpPath, append(args, target...))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", &ytdlpError{err: err, stderr: stderr.String()}
+	}
+	manifest, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if manifest == "" {
+		return "", fmt.Errorf("yt-dlp found no livestream playlist")
+	}
+	return manifest, nil
+}
+
+// CaptureLive records a running livestream into an MP4 in destDir: the last
+// capture.LastMinutes of its DVR window, or from now until stop is closed (or
+// capture.MaxMinutes pass). Closing stop ends the recording and keeps what was
+// recorded; cancelling ctx abandons it. The result's Duration is the recorded length.
+func (d *Downloader) CaptureLive(ctx context.Context, url string, destDir string, ffmpegPath string, ytdlpPath string, capture LiveCapture, stop <-chan struct{}, progressFn ProgressFunc) (*DownloadResult, error) {
+	if ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to capture a livestream")
+	}
+	if err := capture.Validate(); err != nil {
+		return nil, err
+	}
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+	tracker := newProgressTracker(progressFn)
+	tracker.setStage(StageRecording)
+
+	manifest, err := d.liveManifestURL(ctx, url, ytdlpPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest, playlist, err := d.mediaPlaylist(ctx, manifest)
+	if err != nil {
+		return nil, err
+	}
+	startIndex, length := playlist.captureRange(capture)
+
+	tsPath := filepath.Join(destDir, videoID+"-live.ts")
+	outPath := filepath.Join(destDir, videoID+"-preview.mp4")
+	defer os.Remove(tsPath)
+	cfg, err := d.ffmpegConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := recordLive(ctx, ffmpegPath, cfg, manifest, startIndex, length, tsPath, stop, tracker); err != nil {
+		return nil, err
+	}
+
+	// The MPEG-TS recording survives being stopped at any point; the preview needs MP4
+	tracker.setStage(StageMerging)
+	args := []string{"-y", "-hide_banner", "-loglevel", "error", "-i", tsPath,
+		"-c", "copy", "-bsf:a", "aac_adtstoasc", "-movflags", "+faststart", outPath}
+	if out, err := exec.CommandContext(ctx, ffmpegPath, args...).CombinedOutput(); err != nil {
+		_ = os.Remove(outPath)
+		return nil, fmt.Errorf("failed to finish recording: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	probe, err := ProbeMedia(ctx, ffmpegPath, outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	tracker.report(Progress{Fraction: 1.0, Stage: StageMerging, ETA: 0})
+	return &DownloadResult{
+		FilePath:  outPath,
+		Method:    "live",
+		Width:     probe.Width,
+		Height:    probe.Height,
+		Duration:  probe.Duration,
+		AudioOnly: probe.AudioOnly(),
+	}, nil
+}
+
+// recordLive has ffmpeg copy a live HLS playlist to an MPEG-TS file, starting at
+// segment startIndex ("" for ffmpeg's default near the live edge) for length seconds
+// (0 for no limit). Closing stop asks ffmpeg to finish; what it recorded is kept.
+func recordLive(ctx context.Context, ffmpegPath string, cfg ffmpegConfig, playlistURL string, startIndex string, length float64, outPath string, stop <-chan struct{}, tracker *progressTracker) error {
+	args := []string{"-y", "-hide_banner", "-loglevel", "error", "-nostats", "-progress", "pipe:1"}
+	args = append(args, cfg.inputArgs...)
+	args = append(args, "-user_agent", streamUserAgent)
+	if startIndex != "" {
+		args = append(args, "-live_start_index", startIndex)
+	}
+	args = append(args, "-i", playlistURL)
+	if length > 0 {
+		args = append(args, "-t", strconv.FormatFloat(length, 'f', 3, 64))
+	}
+	args = append(args, "-map", "0:v:0?", "-map", "0:a:0?", "-c", "copy", "-f", "mpegts", outPath)
+
+	cmd := cfg.command(ctx, ffmpegPath, args)
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// "q" on stdin makes ffmpeg stop reading and close the output properly
+	done := make(chan struct{})
+	stoppedCh := make(chan bool, 1)
+	go func() {
+		select {
+		case <-stop:
+			_, _ = io.WriteString(stdin, "q")
+			stoppedCh <- true
+		case <-done:
+			stoppedCh <- false
+		}
+	}()
+
+	// ffmpeg reports how much it has recorded; with a length that is the progress
+	var recorded float64
+	var size int64
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "total_size":
+			size, _ = strconv.ParseInt(value, 10, 64)
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+				recorded = float64(us) / 1e6
+			}
+		case "progress":
+			p := Progress{Stage: StageRecording, DownloadedBytes: size, Elapsed: recorded, ETA: -1}
+			if length > 0 {
+				p.Fraction = math.Min(recorded/length, 1.0)
+				p.ETA = math.Max(length-recorded, 0)
+			}
+			tracker.report(p)
+		}
+	}
+
+	err = cmd.Wait()
+	close(done)
+	stopped := <-stoppedCh
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if info, statErr := os.Stat(outPath); statErr == nil && info.Size() > 0 && (err == nil || stopped) {
+		return nil
+	}
+	msg := strings.TrimSpace(stderr.String())
+	if err == nil {
+		err = fmt.Errorf("nothing was recorded")
+	}
+	if msg == "" {
+		return fmt.Errorf("failed to record livestream: %w", err)
+	}
+	return fmt.Errorf("failed to record livestream: %w: %s", err, msg)
+}
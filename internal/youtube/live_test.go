@@ -0,0 +1,160 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS="avc1.4d401e,mp4a.40.2",RESOLUTION=640x360
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+/hls/high/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720
+mid/index.m3u8
+`
+
+const testMediaPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:5
+#EXT-X-MEDIA-SEQUENCE:1200
+#EXTINF:5.0,
+seg1200.ts
+#EXTINF:5.005,
+seg1201.ts
+
+#EXTINF:4.995,title
+seg1202.ts
+#EXTINF:5,
+seg1203.ts
+`
+
+// hlsServer serves the test playlists, the master at /hls/master.m3u8 and the
+// media playlist for each variant, and counts the requests
+func hlsServer(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	requests := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case r.URL.Path == "/hls/master.m3u8":
+			w.Write([]byte(testMasterPlaylist))
+		case strings.HasSuffix(r.URL.Path, "/index.m3u8"):
+			w.Write([]byte(testMediaPlaylist))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, requests
+}
+
+func TestFetchHLSPlaylist(t *testing.T) {
+	base, _ := hlsServer(t)
+	d := NewDownloader()
+	ctx := context.Background()
+
+	master, err := d.fetchHLSPlaylist(ctx, base+"/hls/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []hlsVariant{
+		{url: base + "/hls/low/index.m3u8", bandwidth: 800000},
+		{url: base + "/hls/high/index.m3u8", bandwidth: 5000000},
+		{url: base + "/hls/mid/index.m3u8", bandwidth: 2500000},
+	}
+	if len(master.variants) != len(want) {
+		t.Fatalf("variants = %+v, want %+v", master.variants, want)
+	}
+	for i, v := range master.variants {
+		if v != want[i] {
+			t.Errorf("variant %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+	if master.segments != 0 {
+		t.Errorf("master playlist has %d segments", master.segments)
+	}
+
+	media, err := d.fetchHLSPlaylist(ctx, base+"/hls/low/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media.variants) != 0 || media.segments != 4 || media.window < 19.999 || media.window > 20.001 {
+		t.Errorf("media playlist = %+v, want 4 segments in 20s", media)
+	}
+
+	var statusErr *HTTPStatusError
+	if _, err := d.fetchHLSPlaylist(ctx, base+"/missing.m3u8"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("missing playlist: err = %v, want a 404 HTTPStatusError", err)
+	}
+}
+
+func TestParseHLSPlaylistInvalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"empty":    "\n\n",
+		"not HLS":  "<html>Sign in</html>\n#EXTM3U\n",
+		"DASH XML": `<?xml version="1.0"?><MPD/>`,
+	} {
+		if _, err := parseHLSPlaylist(strings.NewReader(body), nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMediaPlaylist(t *testing.T) {
+	base, requests := hlsServer(t)
+	d := NewDownloader()
+
+	// A master playlist leads to the media playlist of its highest bandwidth variant
+	url, playlist, err := d.mediaPlaylist(context.Background(), base+"/hls/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != base+"/hls/high/index.m3u8" || playlist.segments != 4 {
+		t.Errorf("mediaPlaylist = %q, %+v; want the 1080p variant's playlist", url, playlist)
+	}
+
+	// A media playlist is used as it is
+	requests.Store(0)
+	url, playlist, err = d.mediaPlaylist(context.Background(), base+"/hls/mid/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != base+"/hls/mid/index.m3u8" || playlist.segments != 4 || requests.Load() != 1 {
+		t.Errorf("mediaPlaylist = %q, %+v after %d requests; want the same playlist after 1", url, playlist, requests.Load())
+	}
+}
+
+func TestCaptureRange(t *testing.T) {
+	// Two minutes of DVR window in 2s segments
+	dvr := &hlsPlaylist{segments: 60, window: 120}
+	tests := []struct {
+		name       string
+		playlist   *hlsPlaylist
+		capture    LiveCapture
+		startIndex string
+		length     float64
+	}{
+		{"from now", dvr, LiveCapture{}, "", 0},
+		{"from now with limit", dvr, LiveCapture{MaxMinutes: 30}, "", 1800},
+		{"last minute", dvr, LiveCapture{LastMinutes: 1}, "-30", 60},
+		{"part of a segment", dvr, LiveCapture{LastMinutes: 0.51}, "-16", 30.6},
+		{"longer than the window", dvr, LiveCapture{LastMinutes: 5}, "0", 120},
+		{"exactly the window", dvr, LiveCapture{LastMinutes: 2}, "0", 120},
+		{"uneven segments", &hlsPlaylist{segments: 4, window: 20.5}, LiveCapture{LastMinutes: 0.2}, "-3", 12},
+		{"no segments", &hlsPlaylist{}, LiveCapture{LastMinutes: 1}, "0", 0},
+		{"last minutes win over the limit", dvr, LiveCapture{LastMinutes: 1, MaxMinutes: 30}, "-30", 60},
+	}
+	for _, tt := range tests {
+		startIndex, length := tt.playlist.captureRange(tt.capture)
+		if startIndex != tt.startIndex || length < tt.length-1e-9 || length > tt.length+1e-9 {
+			t.Errorf("%s: captureRange = %q, %v; want %q, %v", tt.name, startIndex, length, tt.startIndex, tt.length)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// localIDPrefix marks the IDs of local files, which can't clash with YouTube's
+// 11-character video IDs
+const localIDPrefix = "local-"
+
+var (
+	// ffmpeg -i prints the input's demuxer, e.g. "Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'a.mp4':"
+	probeInputPattern    = regexp.MustCompile(`Input #0, (.+?), from '`)
+	probeDurationPattern = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+	probeStreamPattern   = regexp.MustCompile(`Stream #\d+:\d+.*?: (Video|Audio): (\w+)(.*)`)
+	probeSizePattern     = regexp.MustCompile(`, (\d{2,5})x(\d{2,5})`)
+)
+
+// MediaProbe describes a local media file as ffmpeg sees it
+type MediaProbe struct {
+	Container  string  `json:"container"` // ffmpeg's demuxer, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration   float64 `json:"duration"`  // in seconds, 0 if unknown
+	VideoCodec string  `json:"videoCodec"`
+	AudioCodec string  `json:"audioCodec"`
+	PixFmt     string  `json:"pixFmt"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+}
+
+// IsLocalID reports whether a video ID belongs to a local file rather than YouTube
+func IsLocalID(id string) bool {
+	return strings.HasPrefix(id, localIDPrefix)
+}
+
+// ProbeMedia reads the streams of a local media file with ffmpeg
+func ProbeMedia(ctx context.Context, ffmpegPath string, path string) (*MediaProbe, error) {
+	if ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to open local files")
+	}
+	// ffmpeg -i with no output "fails", but still describes the input on stderr
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-i", path)
+	out, _ := cmd.CombinedOutput()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return parseProbe(string(out))
+}
+
+func parseProbe(out string) (*MediaProbe, error) {
+	input := probeInputPattern.FindStringSubmatch(out)
+	if input == nil {
+		msg := strings.TrimSpace(out)
+		if lines := strings.Split(msg, "\n"); len(lines) > 0 {
+			msg = lines[len(lines)-1]
+		}
+		return nil, fmt.Errorf("not a media file: %s", msg)
+	}
+	probe := &MediaProbe{Container: input[1]}
+
+	if m := probeDurationPattern.FindStringSubmatch(out); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		mins, _ := strconv.Atoi(m[2])
+		sec, _ := strconv.ParseFloat(m[3], 64)
+		probe.Duration = float64(h*3600+mins*60) + sec
+	}
+
+	for _, m := range probeStreamPattern.FindAllStringSubmatch(out, -1) {
+		kind, codec, rest := m[1], m[2], m[3]
+		switch {
+		case kind == "Video" && probe.VideoCodec == "":
+			// Cover art in audio files shows up as a one-frame video stream
+			if strings.Contains(rest, "(attached pic)") {
+				continue
+			}
+			probe.VideoCodec = codec
+			if fields := strings.SplitN(strings.TrimPrefix(rest, " "), ", ", 3); len(fields) > 1 {
+				pixFmt, _, _ := strings.Cut(fields[1], "(")
+				probe.PixFmt = strings.TrimSpace(pixFmt)
+			}
+			if size := probeSizePattern.FindStringSubmatch(rest); size != nil {
+				probe.Width, probe.Height = parseWxH(size[1] + "x" + size[2])
+			}
+		case kind == "Audio" && probe.AudioCodec == "":
+			probe.AudioCodec = codec
+		}
+	}
+	if probe.VideoCodec == "" && probe.AudioCodec == "" {
+		return nil, fmt.Errorf("no audio or video streams found")
+	}
+	return probe, nil
+}
+
+// AudioOnly reports whether the file has no video
+func (p *MediaProbe) AudioOnly() bool {
+	return p.VideoCodec == ""
+}
+
+// playable reports whether WebKit can play the file as it is: H.264 (8-bit 4:2:0) and
+// AAC in MP4, or MP3/AAC audio on its own
+func (p *MediaProbe) playable() bool {
+	mp4 := strings.Contains(p.Container, "mp4")
+	if p.AudioOnly() {
+		return (mp4 && p.AudioCodec == "aac") || (p.Container == "mp3" && p.AudioCodec == "mp3")
+	}
+	if !mp4 || p.VideoCodec != "h264" || p.PixFmt != "yuv420p" {
+		return false
+	}
+	return p.AudioCodec == "" || p.AudioCodec == "aac" || p.AudioCodec == "mp3"
+}
+
+// OpenLocalFile makes a local media file ready for preview, as DownloadForPreview does
+// for YouTube videos. The file is used in place when WebKit can play it; otherwise a
+// preview copy is transcoded into destDir. The file itself is never modified.
+func (d *Downloader) OpenLocalFile(ctx context.Context, path string, destDir string, ffmpegPath string) (*VideoInfo, *DownloadResult, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid path: %w", err)
+	}
+	info, probe, err := probeLocalFile(ctx, ffmpegPath, absPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &DownloadResult{
+		FilePath:  absPath,
+		Method:    "local",
+		Width:     probe.Width,
+		Height:    probe.Height,
+		AudioOnly: probe.AudioOnly(),
+	}
+	if probe.playable() {
+		return info, result, nil
+	}
+
+	// Transcode a preview
+	baseName := sanitizeFilename(info.Title)
+	if probe.AudioOnly() {
+		previewPath := filepath.Join(destDir, baseName+"-audio.m4a")
+		if err := transcodeAudioToM4A(ctx, ffmpegPath, absPath, previewPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to convert audio for preview: %w", err)
+		}
+		result.FilePath = previewPath
+	} else {
+		previewPath := filepath.Join(destDir, baseName+"-preview.mp4")
+		if err := transcodeToMP4(ctx, ffmpegPath, absPath, previewPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to convert video for preview: %w", err)
+		}
+		result.FilePath = previewPath
+	}
+	result.Method = "local-transcode"
+	return info, result, nil
+}
+
+// probeLocalFile describes the local file at absPath
+func probeLocalFile(ctx context.Context, ffmpegPath string, absPath string) (*VideoInfo, *MediaProbe, error) {
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, nil, fmt.Errorf("%s is a directory", filepath.Base(absPath))
+	}
+
+	probe, err := ProbeMedia(ctx, ffmpegPath, absPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Printf("[DEBUG] Local file: container=%s video=%s/%s %dx%d audio=%s duration=%.2f\n",
+		probe.Container, probe.VideoCodec, probe.PixFmt, probe.Width, probe.Height, probe.AudioCodec, probe.Duration)
+
+	info := &VideoInfo{
+		ID:           localFileID(absPath, stat),
+		Title:        strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath)),
+		Duration:     probe.Duration,
+		SourceWidth:  probe.Width,
+		SourceHeight: probe.Height,
+	}
+	return info, probe, nil
+}
+
+// localFileID derives a stable ID for a local file from its path, size and
+// modification time, so an edited file isn't mistaken for the old one
+func localFileID(absPath string, stat os.FileInfo) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", absPath, stat.Size(), stat.ModTime().UnixNano())))
+	return localIDPrefix + hex.EncodeToString(sum[:8])
+}
+
+// localSource treats absolute paths and file:// URLs as videos, for loading local files
+// through the same path as downloads. Unlike OpenLocalFile it always makes a copy in the
+// destination directory, since downloads are moved into the library afterwards.
+type localSource struct{}
+
+// localPath returns the file a local URL refers to, or "" if it isn't one
+func localPath(url string) string {
+	if path, ok := strings.CutPrefix(url, "file://"); ok {
+		url = path
+	}
+	if !filepath.IsAbs(url) {
+		return ""
+	}
+	return filepath.Clean(url)
+}
+
+func (s localSource) Name() string {
+	return SourceLocal
+}
+
+func (s localSource) Supports(url string) bool {
+	return localPath(url) != ""
+}
+
+func (s localSource) Resolve(ctx context.Context, url string, tools Tools) (*VideoInfo, error) {
+	if tools.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to open local files: %w", ErrSourceUnavailable)
+	}
+	info, _, err := probeLocalFile(ctx, tools.FFmpegPath, localPath(url))
+	return info, err
+}
+
+func (s localSource) ListFormats(ctx context.Context, url string, tools Tools) ([]FormatInfo, error) {
+	if tools.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to open local files: %w", ErrSourceUnavailable)
+	}
+	_, probe, err := probeLocalFile(ctx, tools.FFmpegPath, localPath(url))
+	if err != nil {
+		return nil, err
+	}
+	// A file has just the one "format"
+	return []FormatInfo{{
+		Container:  probe.Container,
+		VideoCodec: probe.VideoCodec,
+		AudioCodec: probe.AudioCodec,
+		Width:      probe.Width,
+		Height:     probe.Height,
+		AudioOnly:  probe.AudioOnly(),
+		VideoOnly:  probe.AudioCodec == "",
+	}}, nil
+}
+
+func (s localSource) Acquire(ctx context.Context, req AcquireRequest) (*DownloadResult, error) {
+	if req.Tools.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to open local files: %w", ErrSourceUnavailable)
+	}
+	path := localPath(req.URL)
+	info, probe, err := probeLocalFile(ctx, req.Tools.FFmpegPath, path)
+	if err != nil {
+		return nil, err
+	}
+	return acquireLocalFile(ctx, path, info, probe, req)
+}
+
+// acquireLocalFile copies or converts a local file into req.DestDir as a download
+// would, cutting out req.Section if set
+func acquireLocalFile(ctx context.Context, path string, info *VideoInfo, probe *MediaProbe, req AcquireRequest) (*DownloadResult, error) {
+	audioOnly := req.Formats.AudioOnly || probe.AudioOnly()
+	result := &DownloadResult{Method: "local", Width: probe.Width, Height: probe.Height, AudioOnly: audioOnly}
+	if audioOnly {
+		result.Width, result.Height = 0, 0
+	}
+
+	section := req.Section
+	if section != nil {
+		clamped := *section
+		if probe.Duration > 0 {
+			if clamped.Start >= probe.Duration {
+				return nil, fmt.Errorf("section starts after the end of the video")
+			}
+			clamped.End = min(clamped.End, probe.Duration)
+		}
+		section = &clamped
+		result.Offset = section.Start
+	}
+
+	tracker := newProgressTracker(req.Progress)
+	baseName := filepath.Join(req.DestDir, sanitizeFilename(info.Title))
+
+	// The file can be used as it is
+	if section == nil && probe.playable() && audioOnly == probe.AudioOnly() {
+		result.FilePath = baseName + "-preview" + strings.ToLower(filepath.Ext(path))
+		tracker.setStage(StageDownloading)
+		if err := copyWithProgress(path, result.FilePath, tracker.callback(0)); err != nil {
+			return nil, fmt.Errorf("failed to copy file: %w", err)
+		}
+		return result, nil
+	}
+
+	if audioOnly {
+		result.FilePath = baseName + "-audio.m4a"
+	} else {
+		result.FilePath = baseName + "-preview.mp4"
+	}
+	tracker.setStage(StageMerging)
+	if err := convertLocalFile(ctx, req.Tools.FFmpegPath, path, result.FilePath, section, audioOnly); err != nil {
+		return nil, fmt.Errorf("failed to convert file: %w", err)
+	}
+	tracker.report(Progress{Fraction: 1.0, Stage: StageMerging, ETA: 0})
+	return result, nil
+}
+
+// convertLocalFile converts a file (or a section of it) to a preview WebKit can play:
+// H.264 and AAC in MP4, or AAC in M4A if audioOnly
+func convertLocalFile(ctx context.Context, ffmpegPath string, inputPath string, outputPath string, section *Section, audioOnly bool) error {
+	if section == nil {
+		if audioOnly {
+			return transcodeAudioToM4A(ctx, ffmpegPath, inputPath, outputPath)
+		}
+		return transcodeToMP4(ctx, ffmpegPath, inputPath, outputPath)
+	}
+
+	args := []string{
+		"-y",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-ss", strconv.FormatFloat(section.Start, 'f', 3, 64),
+		"-i", inputPath,
+		"-t", strconv.FormatFloat(section.Duration(), 'f', 3, 64),
+	}
+	if audioOnly {
+		args = append(args, "-vn", "-c:a", "aac", "-b:a", "192k")
+	} else {
+		args = append(args, "-c:v", "libx264", "-pix_fmt", "yuv420p", "-c:a", "aac")
+	}
+	args = append(args, "-movflags", "+faststart", outputPath)
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(outputPath)
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return err
+		}
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return nil
+}
+
+// copyWithProgress copies a file, reporting progress as it goes
+func copyWithProgress(src string, dst string, progressCb ProgressCallback) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	reader := &progressReader{reader: in, total: stat.Size(), progressCb: progressCb}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+
+	"yt-downloader/internal/network"
+)
+
+// switchTransport is the transport of the Downloader's HTTP client. SetNetwork swaps
+// what it delegates to without replacing the client other goroutines are using.
+type switchTransport struct {
+	mu sync.RWMutex
+	rt http.RoundTripper
+}
+
+func (t *switchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	rt := t.rt
+	t.mu.RUnlock()
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return rt.RoundTrip(req)
+}
+
+func (t *switchTransport) set(rt http.RoundTripper) {
+	t.mu.Lock()
+	t.rt = rt
+	t.mu.Unlock()
+}
+
+// SetNetwork makes all of the Downloader's traffic, including yt-dlp's, follow the
+// network settings. Requests already running finish with the old settings.
+func (d *Downloader) SetNetwork(s network.Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	t, err := s.Transport()
+	if err != nil {
+		return err
+	}
+	d.transport.set(t)
+	d.mu.Lock()
+	d.network = s
+	d.mu.Unlock()
+	return nil
+}
+
+// ytdlpConfig is what every yt-dlp run gets from the Downloader's settings
+type ytdlpConfig struct {
+	args []string // Passed before the URL
+	env  []string // Added to yt-dlp's environment
+}
+
+func (d *Downloader) ytdlpConfig() ytdlpConfig {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cfg := ytdlpConfig{args: d.network.YtdlpArgs(), env: d.network.YtdlpEnv()}
+	if d.cookiesPath != "" {
+		cfg.args = append(cfg.args, "--cookies", d.cookiesPath)
+	}
+	return cfg
+}
+
+// command returns a yt-dlp command with the config's environment
+func (c ytdlpConfig) command(ctx context.Context, ytdlpPath string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, ytdlpPath, args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	return cmd
+}
+
+// ffmpegConfig is what every ffmpeg run that reads from the network gets from the
+// Downloader's settings
+type ffmpegConfig struct {
+	inputArgs []string // Passed before each -i of a URL
+	env       []string // Added to ffmpeg's environment
+}
+
+func (d *Downloader) ffmpegConfig() (ffmpegConfig, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	env, err := d.network.FFmpegEnv()
+	if err != nil {
+		return ffmpegConfig{}, err
+	}
+	return ffmpegConfig{inputArgs: d.network.FFmpegArgs(), env: env}, nil
+}
+
+// command returns an ffmpeg command with the config's environment
+func (c ffmpegConfig) command(ctx context.Context, ffmpegPath string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	return cmd
+}
@@ -0,0 +1,164 @@
+package youtube
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"yt-downloader/internal/network"
+)
+
+// proxyStandIn is an HTTP proxy that wants user:secret and answers every request
+// itself, recording what it was asked for
+type proxyStandIn struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (p *proxyStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	p.mu.Lock()
+	p.urls = append(p.urls, r.URL.String())
+	p.mu.Unlock()
+	if r.URL.Path == "/watch" {
+		_, _ = io.WriteString(w, `{"id": "dQw4w9WgXcQ", "title": "Video", "duration": 10}`)
+		return
+	}
+	_, _ = io.WriteString(w, "segment data")
+}
+
+func (p *proxyStandIn) requested() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.urls...)
+}
+
+// proxiedDownloader returns a Downloader whose settings send everything through a
+// proxyStandIn with credentials
+func proxiedDownloader(t *testing.T) (*Downloader, *proxyStandIn) {
+	t.Helper()
+	proxy := &proxyStandIn{}
+	srv := httptest.NewServer(proxy)
+	t.Cleanup(srv.Close)
+	d := NewDownloader()
+	err := d.SetNetwork(network.Settings{ProxyURL: srv.URL, ProxyUsername: "user", ProxyPassword: "secret", ConnectTimeout: 45})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, proxy
+}
+
+// argsScript writes a stand-in for yt-dlp or ffmpeg that records its arguments, one
+// per line, in argsPath and then runs body, where $last is the last argument
+func argsScript(t *testing.T, name string, body string) (path string, argsPath string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-ins are shell scripts")
+	}
+	dir := t.TempDir()
+	path = filepath.Join(dir, name)
+	argsPath = filepath.Join(dir, "args")
+	script := "#!/bin/sh\n" +
+		"for arg in \"$@\"; do printf '%s\\n' \"$arg\"; done > '" + argsPath + "'\n" +
+		"for arg in \"$@\"; do last=\"$arg\"; done\n" +
+		body + "\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, argsPath
+}
+
+// curlScript writes an argsScript that fetches with curl, which takes the proxy
+// from the environment like yt-dlp and ffmpeg do
+func curlScript(t *testing.T, name string, fetch string) (path string, argsPath string) {
+	t.Helper()
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not installed")
+	}
+	return argsScript(t, name, fetch)
+}
+
+// checkArgs checks that no argument carries the proxy credentials
+func checkArgs(t *testing.T, argsPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(argsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("the proxy password is on the command line: %q", data)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestYtdlpUsesProxy(t *testing.T) {
+	d, proxy := proxiedDownloader(t)
+	if err := d.SetStrategy([]string{SourceYtdlp}); err != nil {
+		t.Fatal(err)
+	}
+	// The proxy stand-in can't tunnel TLS, so the fake yt-dlp asks it for plain http
+	ytdlpPath, argsPath := curlScript(t, "yt-dlp", `curl -sf "http://${last#https://}"`)
+
+	info, err := d.ResolveVideo(context.Background(), testWatchURL, Tools{YtdlpPath: ytdlpPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Video" {
+		t.Errorf("title = %q, want the one the proxy returned", info.Title)
+	}
+	if got := proxy.requested(); len(got) != 1 || got[0] != "http://www.youtube.com/watch?v=dQw4w9WgXcQ" {
+		t.Errorf("proxy got %q, want the watch page", got)
+	}
+
+	args := checkArgs(t, argsPath)
+	if !strings.Contains(strings.Join(args, " "), "--socket-timeout 60") {
+		t.Errorf("yt-dlp got %q, want --socket-timeout from the timeouts", args)
+	}
+}
+
+func TestRecordLiveUsesProxy(t *testing.T) {
+	d, proxy := proxiedDownloader(t)
+	// Like ffmpeg, the stand-in reads the -i URL and writes the output, its last argument
+	ffmpegPath, argsPath := curlScript(t, "ffmpeg",
+		`while [ $# -gt 0 ]; do [ "$1" = -i ] && input="$2"; shift; done; curl -sf -o "$last" "$input"`)
+
+	cfg, err := d.ffmpegConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	playlistURL := "http://manifest.googlevideo.com/index.m3u8"
+	outPath := filepath.Join(t.TempDir(), "live.ts")
+	if err := recordLive(context.Background(), ffmpegPath, cfg, playlistURL, "", 0, outPath, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(outPath); string(data) != "segment data" {
+		t.Errorf("recording is %q, want what the proxy returned", data)
+	}
+	if got := proxy.requested(); len(got) != 1 || got[0] != playlistURL {
+		t.Errorf("proxy got %q, want %s", got, playlistURL)
+	}
+	checkArgs(t, argsPath)
+}
+
+// ffmpeg can only use http:// proxies; with any other it must not run and go direct
+func TestFFmpegRefusesUnusableProxy(t *testing.T) {
+	d := NewDownloader()
+	if err := d.SetNetwork(network.Settings{ProxyURL: "socks5://127.0.0.1:1080"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.ffmpegConfig(); err == nil {
+		t.Fatal("ffmpegConfig accepted a socks5 proxy")
+	}
+}
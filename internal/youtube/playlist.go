@@ -0,0 +1,189 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	playlistIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{13,42}$`)
+	channelIDPattern  = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+
+	// Patterns used to find the canonical channel ID on a channel page (handles, /c/ and /user/ URLs)
+	channelPagePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`<meta itemprop="identifier" content="(UC[A-Za-z0-9_-]{22})"`),
+		regexp.MustCompile(`"externalId":"(UC[A-Za-z0-9_-]{22})"`),
+		regexp.MustCompile(`"channelId":"(UC[A-Za-z0-9_-]{22})"`),
+	}
+)
+
+// IsPlaylistURL reports whether the URL refers to a playlist or a channel rather than a single video.
+// Watch URLs that carry playlist context still count as a single video.
+func IsPlaylistURL(url string) bool {
+	if _, err := ExtractVideoID(url); err == nil {
+		return false
+	}
+	u, err := parseYouTubePageURL(url)
+	if err != nil {
+		return false
+	}
+	if u.Query().Get("list") != "" {
+		return true
+	}
+	_, _, ok := channelFromPath(u.Path)
+	return ok
+}
+
+// ExpandPlaylist resolves a playlist or channel URL into its videos, in playlist order.
+// Channel URLs expand to the channel's uploads playlist.
+func (d *Downloader) ExpandPlaylist(ctx context.Context, url string) ([]VideoInfo, error) {
+	playlistID, err := d.resolvePlaylistID(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist, err := d.client.GetPlaylistContext(ctx, playlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	entries := make([]VideoInfo, 0, len(playlist.Videos))
+	for _, entry := range playlist.Videos {
+		// Deleted and private videos come back without an ID
+		if entry == nil || entry.ID == "" {
+			continue
+		}
+		thumbnail := ""
+		if len(entry.Thumbnails) > 0 {
+			thumbnail = entry.Thumbnails[len(entry.Thumbnails)-1].URL
+		}
+		author := entry.Author
+		if author == "" {
+			author = playlist.Author
+		}
+		entries = append(entries, VideoInfo{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Author:    author,
+			Duration:  entry.Duration.Seconds(),
+			Thumbnail: thumbnail,
+		})
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("playlist %s has no available videos", playlistID)
+	}
+	return entries, nil
+}
+
+// resolvePlaylistID turns a playlist, channel or bare playlist ID into a playlist ID
+func (d *Downloader) resolvePlaylistID(ctx context.Context, url string) (string, error) {
+	url = strings.TrimSpace(url)
+	if playlistIDPattern.MatchString(url) && !channelIDPattern.MatchString(url) {
+		return url, nil
+	}
+
+	u, err := parseYouTubePageURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	if list := u.Query().Get("list"); list != "" {
+		if !playlistIDPattern.MatchString(list) {
+			return "", fmt.Errorf("invalid playlist ID: %s", list)
+		}
+		return list, nil
+	}
+
+	kind, name, ok := channelFromPath(u.Path)
+	if !ok {
+		return "", fmt.Errorf("not a playlist or channel URL: %s", url)
+	}
+
+	channelID := name
+	if kind != "channel" {
+		channelID, err = d.lookupChannelID(ctx, "https://www.youtube.com"+u.Path)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !channelIDPattern.MatchString(channelID) {
+		return "", fmt.Errorf("invalid channel ID: %s", channelID)
+	}
+
+	// Every channel has an uploads playlist whose ID is the channel ID with a UU prefix
+	return "UU" + channelID[2:], nil
+}
+
+// parseYouTubePageURL parses a URL the way ParseURL does and checks that it is on a
+// YouTube host
+func parseYouTubePageURL(url string) (*neturl.URL, error) {
+	u, err := parseWebURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid playlist URL: %w", err)
+	}
+	if !isYouTubeHost(strings.ToLower(u.Hostname())) {
+		return nil, fmt.Errorf("not a YouTube URL: %s", url)
+	}
+	return u, nil
+}
+
+// channelFromPath recognizes /channel/ID, /@handle, /c/name and /user/name paths
+func channelFromPath(path string) (kind string, name string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		return "", "", false
+	}
+	if strings.HasPrefix(parts[0], "@") && len(parts[0]) > 1 {
+		return "handle", parts[0], true
+	}
+	if len(parts) < 2 || parts[1] == "" {
+		return "", "", false
+	}
+	switch parts[0] {
+	case "channel", "c", "user":
+		return parts[0], parts[1], true
+	}
+	return "", "", false
+}
+
+// lookupChannelID fetches a channel page and extracts its canonical UC... channel ID
+func (d *Downloader) lookupChannelID(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// Skip the EU consent interstitial, which has no channel metadata
+	req.Header.Set("Cookie", "CONSENT=YES+cb")
+
+	httpClient := d.client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch channel page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch channel page: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read channel page: %w", err)
+	}
+
+	for _, re := range channelPagePatterns {
+		if m := re.FindSubmatch(body); len(m) > 1 {
+			return string(m[1]), nil
+		}
+	}
+	return "", fmt.Errorf("could not find channel ID on %s", pageURL)
+}
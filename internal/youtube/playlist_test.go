@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsPlaylistURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.youtube.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", true},
+		{"youtube.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", true},
+		{"https://m.youtube.com/@handle", true},
+		{"youtube.com/@handle", true},
+		{"www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw/videos", true},
+		{"https://music.youtube.com/c/name", true},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", false},
+		{"https://www.youtube.com/feed/trending", false},
+		{"https://example.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", false},
+		{"https://youtube.com.example.com/@handle", false},
+		{"example.com/user/name", false},
+		{"ftp://www.youtube.com/@handle", false},
+	}
+	for _, tt := range tests {
+		if got := IsPlaylistURL(tt.url); got != tt.want {
+			t.Errorf("IsPlaylistURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePlaylistID(t *testing.T) {
+	d := NewDownloader()
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"},
+		{" youtube.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf ", "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"},
+		{"https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw", "UUuAXFkgsw1L7xaCfnd5JJOw"},
+		{"youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw/videos", "UUuAXFkgsw1L7xaCfnd5JJOw"},
+	}
+	for _, tt := range tests {
+		got, err := d.resolvePlaylistID(context.Background(), tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("resolvePlaylistID(%q) = %q, %v; want %q", tt.url, got, err, tt.want)
+		}
+	}
+
+	// Other hosts are turned away before anything is fetched from them
+	for _, url := range []string{
+		"https://example.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf",
+		"https://example.com/@handle",
+		"example.com/user/name",
+	} {
+		if got, err := d.resolvePlaylistID(context.Background(), url); err == nil {
+			t.Errorf("resolvePlaylistID(%q) = %q, want an error", url, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package youtube
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress is a detailed download progress report
+type Progress struct {
+	Fraction        float64 `json:"fraction"` // Overall progress, 0.0 to 1.0
+	Stage           Stage   `json:"stage"`
+	DownloadedBytes int64   `json:"downloadedBytes"`   // 0 when unknown
+	TotalBytes      int64   `json:"totalBytes"`        // 0 when unknown
+	Speed           float64 `json:"speed"`             // Bytes per second, 0 when unknown
+	ETA             float64 `json:"eta"`               // Seconds remaining, -1 when unknown
+	Elapsed         float64 `json:"elapsed,omitempty"` // Seconds recorded so far, while capturing a livestream
+
+	// Set while waiting to retry a failed stream download
+	Retry       int     `json:"retry,omitempty"`       // Which retry is next, from 1
+	MaxRetries  int     `json:"maxRetries,omitempty"`  // How many retries the policy allows
+	RetryDelay  float64 `json:"retryDelay,omitempty"`  // Seconds until the retry
+	RetryReason string  `json:"retryReason,omitempty"` // Why the last attempt failed
+}
+
+// ProgressFunc receives detailed progress reports
+type ProgressFunc func(p Progress)
+
+// speedSmoothing weights the newest sample in the moving average used for speed
+const speedSmoothing = 0.3
+
+// progressTracker turns the fractional progress reported by the native download paths
+// into detailed reports with the current stage, byte counts, speed and ETA.
+type progressTracker struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	now   func() time.Time
+	last  Progress
+	since time.Time
+}
+
+func newProgressTracker(fn ProgressFunc) *progressTracker {
+	return &progressTracker{
+		fn:   fn,
+		now:  time.Now,
+		last: Progress{ETA: -1},
+	}
+}
+
+// setStage reports a stage change, keeping the current overall fraction
+func (t *progressTracker) setStage(stage Stage) {
+	if t == nil || t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	p := Progress{Fraction: t.last.Fraction, Stage: stage, ETA: -1}
+	t.last = p
+	t.since = time.Time{}
+	t.mu.Unlock()
+	t.fn(p)
+}
+
+// retrying reports that a stream download failed and is retried after delay,
+// keeping the current overall fraction
+func (t *progressTracker) retrying(retry, maxRetries int, delay time.Duration, err error) {
+	if t == nil || t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	p := Progress{
+		Fraction:    t.last.Fraction,
+		Stage:       t.last.Stage,
+		ETA:         -1,
+		Retry:       retry,
+		MaxRetries:  maxRetries,
+		RetryDelay:  delay.Seconds(),
+		RetryReason: err.Error(),
+	}
+	t.last = p
+	t.since = time.Time{}
+	t.mu.Unlock()
+	t.fn(p)
+}
+
+// report forwards a fully populated progress report (e.g. parsed from yt-dlp)
+func (t *progressTracker) report(p Progress) {
+	if t == nil || t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	t.last = p
+	t.mu.Unlock()
+	t.fn(p)
+}
+
+// callback returns a ProgressCallback for a download of totalBytes. Its 0.0-1.0 input
+// is the overall fraction; bytes, speed and ETA are derived from how fast it grows.
+func (t *progressTracker) callback(totalBytes int64) ProgressCallback {
+	return func(fraction float64) {
+		if t == nil || t.fn == nil {
+			return
+		}
+		t.mu.Lock()
+		now := t.now()
+		p := Progress{Fraction: fraction, Stage: t.last.Stage, TotalBytes: totalBytes, ETA: -1}
+		if totalBytes > 0 {
+			p.DownloadedBytes = int64(fraction * float64(totalBytes))
+			p.Speed = t.last.Speed
+			if !t.since.IsZero() {
+				if elapsed := now.Sub(t.since).Seconds(); elapsed > 0 {
+					sample := float64(p.DownloadedBytes-t.last.DownloadedBytes) / elapsed
+					if sample >= 0 {
+						if p.Speed == 0 {
+							p.Speed = sample
+						} else {
+							p.Speed = speedSmoothing*sample + (1-speedSmoothing)*p.Speed
+						}
+					}
+				}
+			}
+			if p.Speed > 0 {
+				p.ETA = float64(totalBytes-p.DownloadedBytes) / p.Speed
+			}
+		}
+		t.since = now
+		t.last = p
+		t.mu.Unlock()
+		t.fn(p)
+	}
+}
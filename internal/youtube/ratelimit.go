@@ -0,0 +1,197 @@
+package youtube
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxLimitedRead caps single reads from a rate-limited body so waits stay short
+const maxLimitedRead = 32 * 1024
+
+// rateLimiter is a token bucket shared by all of a Downloader's streams, so the limit
+// holds for the total however many downloads run at once
+type rateLimiter struct {
+	mu    sync.Mutex
+	rate  int64   // Bytes per second; 0 is unlimited
+	avail float64 // Bytes that may be read without waiting; negative when in debt
+	last  time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	slots         int   // Downloads that may run at once, see SetDownloadSlots
+	ytdlpReserved int64 // Rate held by running yt-dlp downloads, see ytdlpShare
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{now: time.Now, sleep: sleepContext, slots: 1}
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) setRate(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = bytesPerSec
+	l.avail = float64(bytesPerSec)
+	l.last = time.Time{}
+}
+
+func (l *rateLimiter) getRate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// bucketRate returns the rate left for the token bucket once running yt-dlp
+// downloads have their shares. Callers must hold l.mu.
+func (l *rateLimiter) bucketRate() int64 {
+	if l.rate <= 0 {
+		return 0
+	}
+	return max(l.rate-l.ytdlpReserved, 1)
+}
+
+// readSize returns how much to read at once: up to a tenth of a second's worth
+func (l *rateLimiter) readSize() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	size := maxLimitedRead
+	if rate := l.bucketRate(); rate > 0 && rate/10 < int64(size) {
+		size = int(rate / 10)
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// wait accounts for n bytes that were just read, sleeping as long as needed to keep
+// to the rate. At most a second's worth of unused allowance is saved up.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	rate := l.bucketRate()
+	if rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := l.now()
+	if !l.last.IsZero() {
+		l.avail += now.Sub(l.last).Seconds() * float64(rate)
+	}
+	if burst := float64(rate); l.avail > burst {
+		l.avail = burst
+	}
+	l.last = now
+	l.avail -= float64(n)
+	var delay time.Duration
+	if l.avail < 0 {
+		delay = time.Duration(-l.avail / float64(rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		return l.sleep(ctx, delay)
+	}
+	return nil
+}
+
+// limitedReader reads from r no faster than limiter allows
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if size := lr.limiter.readSize(); len(p) > size {
+		p = p[:size]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.limiter.wait(lr.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+// limitReader applies the Downloader's rate limit to a download body
+func (d *Downloader) limitReader(ctx context.Context, r io.Reader) io.Reader {
+	if d.limiter.getRate() <= 0 {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, limiter: d.limiter}
+}
+
+// SetRateLimit caps the download speed of all downloads together, in bytes per
+// second; 0 removes the limit. yt-dlp downloads each get a fixed share of it (see
+// SetDownloadSlots) and the Go library's streams share the rest. Section downloads
+// and live recordings, which ffmpeg streams itself, aren't limited.
+func (d *Downloader) SetRateLimit(bytesPerSec int64) {
+	if bytesPerSec < 0 {
+		bytesPerSec = 0
+	}
+	d.limiter.setRate(bytesPerSec)
+}
+
+// SetDownloadSlots sets how many downloads may run at once, e.g. the queue's
+// concurrency plus one loaded in the editor. Each yt-dlp download gets that fraction
+// of the rate limit, so the limit holds with every slot in use.
+func (d *Downloader) SetDownloadSlots(n int) {
+	d.limiter.mu.Lock()
+	defer d.limiter.mu.Unlock()
+	d.limiter.slots = max(n, 1)
+}
+
+// RateLimit returns the limit set with SetRateLimit
+func (d *Downloader) RateLimit() int64 {
+	return d.limiter.getRate()
+}
+
+// ytdlpShare reserves a yt-dlp download's part of the rate limit and returns it, 0
+// for none. yt-dlp's limit can't be changed once it runs, so each download gets a
+// fixed share: the limit divided by the download slots, or what is left of it if
+// more downloads than that are running. The token bucket gets the rest. done must
+// be called when the download ends.
+func (l *rateLimiter) ytdlpShare() (share int64, done func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0, func() {}
+	}
+	share = min(l.rate/int64(l.slots), l.rate-l.ytdlpReserved)
+	if share < 1 {
+		share = 1
+	}
+	l.ytdlpReserved += share
+	return share, func() {
+		l.mu.Lock()
+		l.ytdlpReserved -= share
+		l.mu.Unlock()
+	}
+}
+
+// ytdlpDownloadConfig is ytdlpConfig for a yt-dlp run that downloads a video, with
+// its share of the rate limit. done must be called when the download ends.
+func (d *Downloader) ytdlpDownloadConfig() (cfg ytdlpConfig, done func()) {
+	cfg = d.ytdlpConfig()
+	share, done := d.limiter.ytdlpShare()
+	if share > 0 {
+		cfg.args = append(cfg.args, "--limit-rate", strconv.FormatInt(share, 10))
+	}
+	return cfg, done
+}
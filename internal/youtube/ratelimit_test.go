@@ -0,0 +1,184 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeClock stands in for the rate limiter's time: sleeping moves it forward at once
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.now = c.now.Add(d)
+	c.slept += d
+	return ctx.Err()
+}
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(rate int64) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	l := &rateLimiter{now: clock.Now, sleep: clock.Sleep, slots: 1}
+	l.setRate(rate)
+	return l, clock
+}
+
+func TestRateLimiterBurstThenRate(t *testing.T) {
+	l, clock := newTestLimiter(1000)
+	ctx := context.Background()
+
+	// A second's worth goes through without waiting
+	for i := 0; i < 10; i++ {
+		if err := l.wait(ctx, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if clock.slept != 0 {
+		t.Fatalf("slept %s within the burst", clock.slept)
+	}
+
+	// After that, each byte costs a millisecond
+	if err := l.wait(ctx, 500); err != nil {
+		t.Fatal(err)
+	}
+	if clock.slept != 500*time.Millisecond {
+		t.Errorf("slept %s for 500 bytes over the burst, want 500ms", clock.slept)
+	}
+}
+
+func TestRateLimiterSavesAtMostASecond(t *testing.T) {
+	l, clock := newTestLimiter(1000)
+	ctx := context.Background()
+	if err := l.wait(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	// A minute idle still only allows a second's worth
+	clock.advance(time.Minute)
+	if err := l.wait(ctx, 3000); err != nil {
+		t.Fatal(err)
+	}
+	if clock.slept != 2*time.Second {
+		t.Errorf("slept %s, want 2s for the 2000 bytes past a 1000-byte allowance", clock.slept)
+	}
+}
+
+func TestLimitedReaderKeepsToRate(t *testing.T) {
+	l, clock := newTestLimiter(10_000)
+	data := bytes.Repeat([]byte("x"), 60_000)
+	start := clock.now
+
+	lr := &limitedReader{ctx: context.Background(), r: bytes.NewReader(data), limiter: l}
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := lr.Read(buf)
+		if n > 1000 {
+			t.Fatalf("read %d bytes at once, want at most a tenth of a second's worth", n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 60 KB at 10 KB/s, less the first second's allowance
+	if elapsed := clock.now.Sub(start); elapsed != 5*time.Second {
+		t.Errorf("reading took %s, want 5s", elapsed)
+	}
+}
+
+func TestRateLimiterCancelledWait(t *testing.T) {
+	l, _ := newTestLimiter(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 5000); err != context.Canceled {
+		t.Errorf("wait = %v, want context.Canceled", err)
+	}
+}
+
+// yt-dlp downloads each get a fixed share of the limit, so with every slot in use
+// they still keep to it together
+func TestYtdlpRateShare(t *testing.T) {
+	d := NewDownloader()
+	d.SetRateLimit(900_000)
+	d.SetDownloadSlots(3)
+
+	var total int64
+	var dones []func()
+	for i := 0; i < 3; i++ {
+		cfg, done := d.ytdlpDownloadConfig()
+		dones = append(dones, done)
+		if want := []string{"--limit-rate", "300000"}; !reflect.DeepEqual(cfg.args, want) {
+			t.Errorf("download %d: yt-dlp args = %q, want %q", i+1, cfg.args, want)
+		}
+		share, _ := strconv.ParseInt(cfg.args[len(cfg.args)-1], 10, 64)
+		total += share
+	}
+	if total > 900_000 {
+		t.Errorf("running shares add up to %d, over the 900000 limit", total)
+	}
+	for _, done := range dones {
+		done()
+	}
+
+	// Once they finish, the shares are free again
+	cfg, done := d.ytdlpDownloadConfig()
+	defer done()
+	if want := []string{"--limit-rate", "300000"}; !reflect.DeepEqual(cfg.args, want) {
+		t.Errorf("yt-dlp args = %q, want %q", cfg.args, want)
+	}
+
+	d.SetRateLimit(0)
+	cfg, doneUnlimited := d.ytdlpDownloadConfig()
+	defer doneUnlimited()
+	if len(cfg.args) != 0 {
+		t.Errorf("yt-dlp args without a limit = %q", cfg.args)
+	}
+}
+
+// The Go library's streams only get what the running yt-dlp downloads leave
+func TestRateLimiterLeavesYtdlpShares(t *testing.T) {
+	l, clock := newTestLimiter(1000)
+	l.slots = 4
+	_, done := l.ytdlpShare()
+	_, done2 := l.ytdlpShare()
+	ctx := context.Background()
+
+	// 500 bytes a second are left: the burst, then a second for each 500 bytes
+	if err := l.wait(ctx, 1500); err != nil {
+		t.Fatal(err)
+	}
+	if clock.slept != 2*time.Second {
+		t.Errorf("slept %s for 1500 bytes at 500 B/s, want 2s", clock.slept)
+	}
+
+	// With more yt-dlp downloads than slots, the last gets what is left
+	_, done3 := l.ytdlpShare()
+	share, done4 := l.ytdlpShare()
+	if share != 250 {
+		t.Errorf("fourth share = %d, want 250", share)
+	}
+	share, done5 := l.ytdlpShare()
+	done5()
+	if share != 1 {
+		t.Errorf("share with nothing left = %d, want the 1 B/s minimum", share)
+	}
+	for _, done := range []func(){done, done2, done3, done4} {
+		done()
+	}
+	if l.ytdlpReserved != 0 {
+		t.Errorf("%d B/s still reserved after every download ended", l.ytdlpReserved)
+	}
+}
@@ -0,0 +1,237 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// partSuffix marks an incomplete download. The final name is only used once the size is verified.
+	partSuffix = ".part"
+
+	// resumeOverlap is re-downloaded when resuming and compared with the bytes already on disk,
+	// so a stale or corrupt partial file is detected instead of being silently extended.
+	resumeOverlap = 64 * 1024
+
+	// maxResumeAttempts bounds how often a dropped connection is resumed within one download
+	maxResumeAttempts = 5
+
+	// streamUserAgent matches the kkdai client that resolves stream URLs; googlevideo
+	// rejects requests whose client doesn't match the one the URL was issued to.
+	streamUserAgent = "com.google.android.youtube/20.10.38 (Linux; U; Android 11) gzip"
+)
+
+// errPartMismatch means the partial file on disk doesn't match the remote stream
+var errPartMismatch = errors.New("partial download does not match remote file")
+
+// HTTPStatusError is returned when a stream request gets an unexpected HTTP status
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d", e.StatusCode)
+}
+
+func (d *Downloader) httpClient() *http.Client {
+	if d.client.HTTPClient != nil {
+		return d.client.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// downloadURLResumable downloads streamURL to destPath. Data is written to destPath+".part"
+// and resumed with Range requests if the connection drops or a previous attempt left a
+// partial file behind. The part file is renamed to destPath only after its size matches
+// expectedSize (or the size the server reports when expectedSize is 0).
+func (d *Downloader) downloadURLResumable(ctx context.Context, streamURL string, expectedSize int64, destPath string, progressCb ProgressCallback) error {
+	partPath := destPath + partSuffix
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	// A chunked download (see downloadURLChunked) leaves holes in its .part file; keep
+	// only the chunks completed from the start, or nothing if its state doesn't match
+	statePath := partPath + chunkStateSuffix
+	if prefix, found := resumablePrefix(statePath, expectedSize); found {
+		if info, err := file.Stat(); err == nil && info.Size() < prefix {
+			prefix = info.Size()
+		}
+		if err := file.Truncate(prefix); err != nil {
+			return fmt.Errorf("failed to reset partial file: %w", err)
+		}
+		_ = os.Remove(statePath)
+	}
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to read partial file: %w", err)
+	}
+	if expectedSize > 0 && offset > expectedSize {
+		// Larger than the stream can be; start over
+		if err := file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to reset partial file: %w", err)
+		}
+		offset = 0
+	}
+	if offset > 0 {
+		fmt.Printf("[DEBUG] Resuming %s at %d bytes\n", destPath, offset)
+	}
+
+	total := expectedSize
+	for attempt := 1; ; attempt++ {
+		offset, total, err = d.fetchRange(ctx, streamURL, file, offset, total, progressCb)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) || attempt >= maxResumeAttempts {
+			return err
+		}
+		fmt.Printf("[DEBUG] Download interrupted at %d bytes (attempt %d): %v\n", offset, attempt, err)
+	}
+
+	if total > 0 && offset != total {
+		return fmt.Errorf("incomplete download: got %d of %d bytes", offset, total)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(partPath, destPath); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
+	return nil
+}
+
+// fetchRange requests the stream from offset onwards (minus a verification overlap) and
+// appends it to file. It returns the new end offset and the total size of the stream,
+// which are meaningful even when an error is returned so the caller can resume.
+func (d *Downloader) fetchRange(ctx context.Context, streamURL string, file *os.File, offset int64, total int64, progressCb ProgressCallback) (int64, int64, error) {
+	start := offset - resumeOverlap
+	if start < 0 {
+		start = 0
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return offset, total, err
+	}
+	req.Header.Set("User-Agent", streamUserAgent)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return offset, total, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		rangeStart, rangeTotal, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || rangeStart != start {
+			return offset, total, fmt.Errorf("server returned unexpected range %q", resp.Header.Get("Content-Range"))
+		}
+		if rangeTotal > 0 {
+			if total > 0 && rangeTotal != total {
+				return offset, total, fmt.Errorf("remote size %d does not match expected %d", rangeTotal, total)
+			}
+			total = rangeTotal
+		}
+	case http.StatusOK:
+		// Server ignored the range; the body is the whole file
+		if err := file.Truncate(0); err != nil {
+			return offset, total, err
+		}
+		offset, start = 0, 0
+		if resp.ContentLength > 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if total > 0 && offset == total {
+			return offset, total, nil
+		}
+		_ = file.Truncate(0)
+		return 0, total, errPartMismatch
+	default:
+		return offset, total, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	// Verify the overlap against what we already have before appending
+	if overlap := offset - start; overlap > 0 {
+		remote := make([]byte, overlap)
+		if _, err := io.ReadFull(resp.Body, remote); err != nil {
+			return offset, total, err
+		}
+		local := make([]byte, overlap)
+		if _, err := file.ReadAt(local, start); err != nil {
+			return offset, total, err
+		}
+		if !bytes.Equal(local, remote) {
+			_ = file.Truncate(0)
+			return 0, total, errPartMismatch
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, total, err
+	}
+
+	reader := d.limitReader(ctx, resp.Body)
+	if progressCb != nil && total > 0 {
+		reader = &progressReader{
+			reader:       reader,
+			total:        total,
+			read:         offset,
+			progressCb:   progressCb,
+			lastReported: float64(offset) / float64(total),
+		}
+	}
+	n, err := io.Copy(file, reader)
+	offset += n
+	if err != nil {
+		return offset, total, err
+	}
+	if total > 0 && offset < total {
+		return offset, total, io.ErrUnexpectedEOF
+	}
+	return offset, total, nil
+}
+
+// parseContentRange parses "bytes start-end/total". Total is 0 when the server sends "*".
+func parseContentRange(header string) (start int64, total int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if totalPart != "*" {
+		total, err = strconv.ParseInt(totalPart, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
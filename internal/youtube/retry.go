@@ -0,0 +1,167 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// RetryPolicy says how often and how patiently a failed stream download is retried
+type RetryPolicy struct {
+	MaxAttempts int           // Including the first; 1 disables retries
+	BaseDelay   time.Duration // Before the first retry, doubling for each one after
+	MaxDelay    time.Duration // Cap on the doubled delay
+	Jitter      float64       // Fraction of the delay randomized, 0 to 1, so parallel streams don't retry in step
+}
+
+// DefaultRetryPolicy is used unless SetRetryPolicy says otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.5,
+}
+
+// delay returns how long to wait before retry number n (counting from 1). rnd returns
+// a random number in [0, 1).
+func (p RetryPolicy) delay(n int, rnd func() float64) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(n-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		// Spread over [d*(1-jitter), d]
+		d -= d * p.Jitter * rnd()
+	}
+	return time.Duration(d)
+}
+
+// SetRetryPolicy changes how failed stream downloads are retried
+func (d *Downloader) SetRetryPolicy(p RetryPolicy) {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retry = p
+}
+
+// RetryPolicy returns the policy set with SetRetryPolicy
+func (d *Downloader) RetryPolicy() RetryPolicy {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.retry
+}
+
+// retryable reports whether a failed stream download may succeed if tried again
+func retryable(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusForbidden, statusErr.StatusCode == http.StatusGone,
+			statusErr.StatusCode == http.StatusTooManyRequests, statusErr.StatusCode >= 500:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errPartMismatch) {
+		return true
+	}
+	switch classify(err) {
+	case CategoryNetwork, CategoryThrottled:
+		return true
+	}
+	return false
+}
+
+// urlExpired reports whether err means the stream URL is no longer valid, so a fresh
+// one must be resolved before retrying
+func urlExpired(err error) bool {
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusGone)
+}
+
+// downloadToFile downloads a stream to destPath, retrying failures that may be
+// temporary with exponential backoff. Each retry resumes the partial file. When the
+// stream URL has expired the video is resolved again to get a fresh one.
+func (d *Downloader) downloadToFile(ctx context.Context, video *youtube.Video, format *youtube.Format, destPath string, progressCb ProgressCallback, tracker *progressTracker) error {
+	download := func() error {
+		return d.downloadStream(ctx, video, format, destPath, progressCb)
+	}
+	refresh := func() {
+		fresh, freshFormat, err := d.refreshFormat(ctx, video, format)
+		if err != nil {
+			fmt.Printf("[DEBUG] Failed to refresh stream URL: %v\n", err)
+			return
+		}
+		video, format = fresh, freshFormat
+	}
+	return retryDownload(ctx, d.RetryPolicy(), tracker, download, refresh)
+}
+
+// retryDownload calls download until it succeeds, the policy's attempts run out or
+// it fails in a way that won't get better, waiting longer before each retry. refresh
+// is called before retrying when the stream URL has expired.
+func retryDownload(ctx context.Context, policy RetryPolicy, tracker *progressTracker, download func() error, refresh func()) error {
+	for attempt := 1; ; attempt++ {
+		err := download()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= policy.MaxAttempts || !retryable(err) {
+			if attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		if urlExpired(err) {
+			refresh()
+		}
+
+		delay := policy.delay(attempt, rand.Float64)
+		fmt.Printf("[DEBUG] Stream download failed (attempt %d of %d): %v, retrying in %s\n", attempt, policy.MaxAttempts, err, delay)
+		tracker.retrying(attempt, policy.MaxAttempts-1, delay, err)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// refreshFormat resolves a video again and finds format in the fresh copy, whose
+// stream URLs are valid again. The same size is preferred so the partial file can
+// be resumed.
+func (d *Downloader) refreshFormat(ctx context.Context, video *youtube.Video, format *youtube.Format) (*youtube.Video, *youtube.Format, error) {
+	fresh, err := d.client.GetVideoContext(ctx, video.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var match *youtube.Format
+	for i := range fresh.Formats {
+		f := &fresh.Formats[i]
+		if f.ItagNo != format.ItagNo {
+			continue
+		}
+		if f.ContentLength == format.ContentLength {
+			return fresh, f, nil
+		}
+		if match == nil {
+			match = f
+		}
+	}
+	if match == nil {
+		return nil, nil, fmt.Errorf("format %d is no longer offered", format.ItagNo)
+	}
+	return fresh, match, nil
+}
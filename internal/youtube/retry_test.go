@@ -0,0 +1,224 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 8, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	tests := []struct {
+		retry int
+		rnd   float64
+		want  time.Duration
+	}{
+		{1, 0, time.Second},
+		{2, 0, 2 * time.Second},
+		{3, 0, 4 * time.Second},
+		{4, 0, 8 * time.Second},
+		{5, 0, 10 * time.Second}, // Capped
+		{7, 0, 10 * time.Second},
+		{1, 0.5, 750 * time.Millisecond},
+		{3, 0.5, 3 * time.Second},
+		{5, 0.5, 7500 * time.Millisecond},
+		{2, 0.999, 2*time.Second - 999*time.Millisecond}, // Never below half with 0.5 jitter
+	}
+	for _, tt := range tests {
+		got := policy.delay(tt.retry, func() float64 { return tt.rnd })
+		if got != tt.want {
+			t.Errorf("delay(%d) with rnd %v = %s, want %s", tt.retry, tt.rnd, got, tt.want)
+		}
+	}
+
+	noJitter := RetryPolicy{BaseDelay: 100 * time.Millisecond}
+	if got := noJitter.delay(4, func() float64 { return 0.9 }); got != 800*time.Millisecond {
+		t.Errorf("delay without jitter or cap = %s, want 800ms", got)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+		expired   bool
+	}{
+		{&HTTPStatusError{StatusCode: 403}, true, true},
+		{&HTTPStatusError{StatusCode: 410}, true, true},
+		{&HTTPStatusError{StatusCode: 429}, true, false},
+		{&HTTPStatusError{StatusCode: 503}, true, false},
+		{&HTTPStatusError{StatusCode: 404}, false, false},
+		{fmt.Errorf("failed to download: %w", &HTTPStatusError{StatusCode: 500}), true, false},
+		{io.ErrUnexpectedEOF, true, false},
+		{errPartMismatch, true, false},
+		{&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true, false},
+		{errors.New("failed to create file: permission denied"), false, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.retryable {
+			t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.retryable)
+		}
+		if got := urlExpired(tt.err); got != tt.expired {
+			t.Errorf("urlExpired(%v) = %v, want %v", tt.err, got, tt.expired)
+		}
+	}
+}
+
+// progressLog collects progress reports
+type progressLog struct {
+	mu      sync.Mutex
+	reports []Progress
+}
+
+func (l *progressLog) add(p Progress) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reports = append(l.reports, p)
+}
+
+// retries returns the reports announcing a retry
+func (l *progressLog) retries() []Progress {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var retries []Progress
+	for _, p := range l.reports {
+		if p.Retry > 0 {
+			retries = append(retries, p)
+		}
+	}
+	return retries
+}
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryDownloadGivesUp(t *testing.T) {
+	progress := &progressLog{}
+	calls := 0
+	err := retryDownload(context.Background(), fastRetries, newProgressTracker(progress.add), func() error {
+		calls++
+		return &HTTPStatusError{StatusCode: 503}
+	}, func() { t.Error("refreshed a URL that hadn't expired") })
+
+	if calls != 3 {
+		t.Errorf("tried %d times, want the policy's 3", calls)
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("error = %v, want the last failure after 3 attempts", err)
+	}
+	retries := progress.retries()
+	if len(retries) != 2 {
+		t.Fatalf("%d retry reports, want 2: %+v", len(retries), retries)
+	}
+	for i, p := range retries {
+		if p.Retry != i+1 || p.MaxRetries != 2 || p.RetryDelay <= 0 || !strings.Contains(p.RetryReason, "503") {
+			t.Errorf("retry report %d = %+v", i, p)
+		}
+	}
+}
+
+func TestRetryDownloadStopsOnPermanentFailure(t *testing.T) {
+	calls := 0
+	err := retryDownload(context.Background(), fastRetries, nil, func() error {
+		calls++
+		return &HTTPStatusError{StatusCode: 404}
+	}, func() {})
+	if calls != 1 || err == nil || strings.Contains(err.Error(), "attempts") {
+		t.Errorf("after %d calls: %v; want one try and its error", calls, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = retryDownload(ctx, fastRetries, nil, func() error {
+		cancel()
+		return io.ErrUnexpectedEOF
+	}, func() {})
+	if err != context.Canceled {
+		t.Errorf("cancelled download = %v, want context.Canceled", err)
+	}
+}
+
+// expiringServer serves data with range requests, but cuts the first response off
+// halfway and answers the second with 403 as if the stream URL had expired
+type expiringServer struct {
+	data []byte
+
+	mu     sync.Mutex
+	starts []string // Range of each request
+}
+
+func (s *expiringServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.starts = append(s.starts, r.Header.Get("Range"))
+	n := len(s.starts)
+	s.mu.Unlock()
+
+	var start int
+	fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+	size := len(s.data)
+	switch n {
+	case 1:
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, size-1, size))
+		w.Header().Set("Content-Length", fmt.Sprint(size-start))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(s.data[start : size/2])
+		return // The connection drops
+	case 2:
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, size-1, size))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(s.data[start:])
+}
+
+// An expired stream URL is refreshed and the download resumes where it stopped,
+// with the retry in the progress reports
+func TestRetryDownloadResumesAfterExpiry(t *testing.T) {
+	data := make([]byte, 512*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	server := &expiringServer{data: data}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	d := NewDownloader()
+	destPath := filepath.Join(t.TempDir(), "stream.mp4")
+	progress := &progressLog{}
+	refreshes := 0
+	err := retryDownload(context.Background(), fastRetries, newProgressTracker(progress.add), func() error {
+		return d.downloadURLResumable(context.Background(), srv.URL, int64(len(data)), destPath, nil)
+	}, func() { refreshes++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("downloaded %d bytes that don't match the stream", len(got))
+	}
+	resumeAt := fmt.Sprintf("bytes=%d-", len(data)/2-resumeOverlap)
+	if want := []string{"bytes=0-", resumeAt, resumeAt}; strings.Join(server.starts, " ") != strings.Join(want, " ") {
+		t.Errorf("requests for %q, want %q", server.starts, want)
+	}
+	if refreshes != 1 {
+		t.Errorf("refreshed the URL %d times, want once", refreshes)
+	}
+	retries := progress.retries()
+	if len(retries) != 1 || retries[0].Retry != 1 || retries[0].MaxRetries != 2 || !strings.Contains(retries[0].RetryReason, "403") {
+		t.Errorf("retry reports = %+v, want one for the 403", retries)
+	}
+}
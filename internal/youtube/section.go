@@ -0,0 +1,204 @@
+package youtube
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// DefaultSectionMargin is added on both sides of a requested range so the trim points
+// can still be nudged in the editor without downloading again
+const DefaultSectionMargin = 10.0
+
+// Section is a time range within a video, in seconds
+type Section struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Duration returns the length of the section in seconds
+func (s Section) Duration() float64 {
+	return s.End - s.Start
+}
+
+// Pad widens the section by margin seconds on each side, clamped to the video.
+// A duration of 0 means the video length is unknown and only the start is clamped.
+func (s Section) Pad(margin float64, duration float64) Section {
+	s.Start -= margin
+	s.End += margin
+	if s.Start < 0 {
+		s.Start = 0
+	}
+	if duration > 0 && s.End > duration {
+		s.End = duration
+	}
+	return s
+}
+
+// validate checks the section is a usable range
+func (s Section) validate() error {
+	if s.Start < 0 {
+		return fmt.Errorf("section start must not be negative")
+	}
+	if s.End <= s.Start {
+		return fmt.Errorf("section end must be greater than start")
+	}
+	return nil
+}
+
+// DownloadSection downloads only the given time range of a video for preview, so a short
+// clip from a long video doesn't need the whole file. The yt-dlp source uses section
+// downloads; the Go library has ffmpeg seek directly into the stream URLs. The result's
+// Offset is where the file starts in the original video. formats works as for
+// DownloadForPreview.
+func (d *Downloader) DownloadSection(ctx context.Context, url string, destDir string, ffmpegPath string, ytdlpPath string, section Section, formats FormatOptions, progressFn ProgressFunc) (*DownloadResult, error) {
+	if err := section.validate(); err != nil {
+		return nil, err
+	}
+	if ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg is required to download part of a video")
+	}
+	return d.acquire(ctx, AcquireRequest{
+		URL:      url,
+		DestDir:  destDir,
+		Tools:    Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath},
+		Section:  &section,
+		Formats:  formats,
+		Progress: progressFn,
+	})
+}
+
+// downloadSection downloads a section with the Go library, which has ffmpeg read it
+// straight from the stream URLs
+func (d *Downloader) downloadSection(ctx context.Context, video *youtube.Video, destDir string, ffmpegPath string, section Section, formats FormatOptions, tracker *progressTracker) (*DownloadResult, error) {
+	if duration := video.Duration.Seconds(); duration > 0 {
+		if section.Start >= duration {
+			return nil, fmt.Errorf("section starts after the end of the video")
+		}
+		if section.End > duration {
+			section.End = duration
+		}
+	}
+
+	if formats.AudioOnly {
+		return d.downloadAudio(ctx, video, destDir, ffmpegPath, &section, formats, tracker)
+	}
+
+	v, a, format, err := pickFormats(video.Formats, formats)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := sanitizeFilename(video.Title)
+	outPath := filepath.Join(destDir, baseName+"-preview.mp4")
+
+	var muxErr error
+	if v != nil && a != nil {
+		fmt.Printf("[DEBUG] Seeking into video format: %dx%d, mime=%s\n", v.Width, v.Height, v.MimeType)
+		muxErr = d.downloadSectionStreams(ctx, video, v, a, section, outPath, ffmpegPath, tracker)
+		if muxErr == nil {
+			return &DownloadResult{FilePath: outPath, Method: "mux", Width: v.Width, Height: v.Height, Offset: section.Start}, nil
+		}
+		fmt.Printf("[DEBUG] Section mux failed: %v, falling back to progressive stream\n", muxErr)
+	}
+
+	if format == nil {
+		if muxErr != nil {
+			return nil, fmt.Errorf("failed to download video: %w", muxErr)
+		}
+		return nil, fmt.Errorf("no suitable video format found")
+	}
+	fmt.Printf("[DEBUG] Falling back to progressive stream for section: %dx%d, mime=%s\n", format.Width, format.Height, format.MimeType)
+	if err := d.downloadSectionStreams(ctx, video, format, nil, section, outPath, ffmpegPath, tracker); err != nil {
+		return nil, fmt.Errorf("failed to download video: %w", err)
+	}
+	return &DownloadResult{FilePath: outPath, Method: "progressive", Width: format.Width, Height: format.Height, Offset: section.Start}, nil
+}
+
+// downloadSectionStreams has ffmpeg read the section straight from the stream URLs.
+// Seeking is done on the inputs so only the bytes around the range are fetched.
+// audioFmt is nil when videoFmt is a progressive stream that already has audio.
+func (d *Downloader) downloadSectionStreams(ctx context.Context, video *youtube.Video, videoFmt *youtube.Format, audioFmt *youtube.Format, section Section, outPath string, ffmpegPath string, tracker *progressTracker) error {
+	cfg, err := d.ffmpegConfig()
+	if err != nil {
+		return err
+	}
+	videoURL, err := d.client.GetStreamURLContext(ctx, video, videoFmt)
+	if err != nil {
+		return fmt.Errorf("failed to get video stream URL: %w", err)
+	}
+
+	start := strconv.FormatFloat(section.Start, 'f', 3, 64)
+	length := strconv.FormatFloat(section.Duration(), 'f', 3, 64)
+
+	args := []string{"-y", "-hide_banner", "-nostats", "-loglevel", "error", "-progress", "pipe:1"}
+	args = append(args, cfg.inputArgs...)
+	args = append(args, "-user_agent", streamUserAgent, "-ss", start, "-i", videoURL)
+
+	if audioFmt != nil {
+		audioURL, err := d.client.GetStreamURLContext(ctx, video, audioFmt)
+		if err != nil {
+			return fmt.Errorf("failed to get audio stream URL: %w", err)
+		}
+		args = append(args, cfg.inputArgs...)
+		args = append(args, "-user_agent", streamUserAgent, "-ss", start, "-i", audioURL)
+		args = append(args, "-t", length, "-map", "0:v:0", "-map", "1:a:0")
+		args = append(args, muxCodecArgs(videoFmt, audioFmt)...)
+	} else {
+		args = append(args, "-t", length)
+		if needsSafariTranscode(*videoFmt) {
+			args = append(args, "-c:v", "libx264", "-pix_fmt", "yuv420p", "-c:a", "aac")
+		} else {
+			args = append(args, "-c", "copy")
+		}
+	}
+	args = append(args, "-movflags", "+faststart", outPath)
+
+	tracker.setStage(StageDownloading)
+
+	cmd := cfg.command(ctx, ffmpegPath, args)
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// ffmpeg reports how far into the output it is; that is our progress through the section
+	progressCb := tracker.callback(0)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok {
+			continue
+		}
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+			progress := float64(us) / 1e6 / section.Duration()
+			if progress > 1.0 {
+				progress = 1.0
+			}
+			progressCb(progress)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		_ = os.Remove(outPath)
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return err
+		}
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+
+	tracker.report(Progress{Fraction: 1.0, Stage: StageDownloading, ETA: 0})
+	return nil
+}
@@ -0,0 +1,164 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Names of the built-in sources, as used in a strategy
+const (
+	SourceYtdlp   = "yt-dlp"  // The yt-dlp program
+	SourceBuiltin = "youtube" // The built-in Go client
+	SourceLocal   = "local"   // Files on disk, by path or file:// URL
+)
+
+// DefaultStrategy is the order sources are tried in unless SetStrategy says otherwise.
+// yt-dlp copes best with YouTube's changes, so it goes first when installed.
+var DefaultStrategy = []string{SourceYtdlp, SourceBuiltin, SourceLocal}
+
+// ErrSourceUnavailable is returned (wrapped) by a Source that can't be used right now,
+// e.g. because a program it needs isn't installed. The next source is tried without
+// treating it as a failure.
+var ErrSourceUnavailable = errors.New("source unavailable")
+
+// Source is somewhere videos can be fetched from. Downloader asks its sources in
+// strategy order: metadata and formats come from the first that succeeds, and a failed
+// download falls back to the next source that supports the URL.
+type Source interface {
+	// Name identifies the source in a strategy
+	Name() string
+	// Supports reports whether the source understands url at all
+	Supports(url string) bool
+	// Resolve fetches a video's metadata
+	Resolve(ctx context.Context, url string, tools Tools) (*VideoInfo, error)
+	// ListFormats returns the streams available for a video
+	ListFormats(ctx context.Context, url string, tools Tools) ([]FormatInfo, error)
+	// Acquire downloads a video (or part of it) into req.DestDir for preview
+	Acquire(ctx context.Context, req AcquireRequest) (*DownloadResult, error)
+}
+
+// Tools locates the external programs a Source may use. Empty paths mean not installed.
+type Tools struct {
+	FFmpegPath string
+	YtdlpPath  string
+}
+
+// AcquireRequest describes a download for Source.Acquire
+type AcquireRequest struct {
+	URL      string
+	DestDir  string
+	Tools    Tools
+	Section  *Section // Only this range of the video, if set
+	Formats  FormatOptions
+	Progress ProgressFunc
+}
+
+// AddSource registers a source and puts it first in the strategy, replacing any
+// source of the same name
+func (d *Downloader) AddSource(s Source) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sources[s.Name()] = s
+	strategy := []string{s.Name()}
+	for _, name := range d.strategy {
+		if name != s.Name() {
+			strategy = append(strategy, name)
+		}
+	}
+	d.strategy = strategy
+}
+
+// Strategy returns the names of the sources in the order they are tried
+func (d *Downloader) Strategy() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.strategy...)
+}
+
+// SetStrategy sets which sources are used and in what order. Sources left out are
+// not used at all.
+func (d *Downloader) SetStrategy(names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("at least one source is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := d.sources[name]; !ok {
+			return fmt.Errorf("unknown source %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("source %q is listed twice", name)
+		}
+		seen[name] = true
+	}
+	d.strategy = append([]string(nil), names...)
+	return nil
+}
+
+// sourcesFor returns the sources that support url, in strategy order
+func (d *Downloader) sourcesFor(url string) []Source {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var sources []Source
+	for _, name := range d.strategy {
+		if s := d.sources[name]; s.Supports(url) {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+// ResolveVideo fetches a video's metadata from the first source that can provide it.
+// tools lets sources that need external programs take part.
+func (d *Downloader) ResolveVideo(ctx context.Context, url string, tools Tools) (*VideoInfo, error) {
+	var info *VideoInfo
+	err := d.trySources(ctx, url, "resolve", func(s Source) error {
+		var err error
+		info, err = s.Resolve(ctx, url, tools)
+		return err
+	})
+	return info, err
+}
+
+// acquire downloads a video with the first source that manages to
+func (d *Downloader) acquire(ctx context.Context, req AcquireRequest) (*DownloadResult, error) {
+	var result *DownloadResult
+	err := d.trySources(ctx, req.URL, "download", func(s Source) error {
+		var err error
+		result, err = s.Acquire(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+// trySources calls fn with each source that supports url until one succeeds. If none
+// do, a *DownloadError records why each source failed.
+func (d *Downloader) trySources(ctx context.Context, url string, action string, fn func(Source) error) error {
+	sources := d.sourcesFor(url)
+	if len(sources) == 0 {
+		return fmt.Errorf("unrecognized URL %q: no source can %s it", url, action)
+	}
+
+	var attempts []Attempt
+	for _, s := range sources {
+		err := fn(s)
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return err
+		}
+		attempt := Attempt{Source: s.Name(), Category: classify(err), Message: err.Error(), Err: err}
+		if errors.Is(err, ErrSourceUnavailable) {
+			fmt.Printf("[DEBUG] Skipping %s: %v\n", s.Name(), err)
+			attempt.Skipped = true
+		} else {
+			fmt.Printf("[DEBUG] %s failed to %s (%s): %v, trying next source\n", s.Name(), action, attempt.Category, err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	return newDownloadError(action, url, attempts)
+}
@@ -0,0 +1,108 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+)
+
+// builtinSource fetches videos with the Go library, muxing separate streams with
+// ffmpeg when it is installed
+type builtinSource struct {
+	d *Downloader
+}
+
+func (s builtinSource) Name() string {
+	return SourceBuiltin
+}
+
+func (s builtinSource) Supports(url string) bool {
+	_, err := ExtractVideoID(url)
+	return err == nil
+}
+
+func (s builtinSource) Resolve(ctx context.Context, url string, tools Tools) (*VideoInfo, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.d.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		if info := upcomingFromError(videoID, err); info != nil {
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	// Get best thumbnail
+	thumbnail := ""
+	if len(video.Thumbnails) > 0 {
+		thumbnail = video.Thumbnails[len(video.Thumbnails)-1].URL
+	}
+
+	// Get source resolution from best available format
+	sourceWidth, sourceHeight := 0, 0
+	for _, f := range video.Formats {
+		if f.Width > sourceWidth {
+			sourceWidth = f.Width
+			sourceHeight = f.Height
+		}
+	}
+
+	return &VideoInfo{
+		ID:           video.ID,
+		Title:        sanitizeFilename(video.Title),
+		Author:       video.Author,
+		Duration:     video.Duration.Seconds(),
+		Thumbnail:    thumbnail,
+		Description:  video.Description,
+		SourceWidth:  sourceWidth,
+		SourceHeight: sourceHeight,
+		Chapters:     ParseChapters(video.Description, video.Duration.Seconds()),
+		LiveStatus:   builtinLiveStatus(video),
+	}, nil
+}
+
+func (s builtinSource) ListFormats(ctx context.Context, url string, tools Tools) ([]FormatInfo, error) {
+	videoID, err := ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.d.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	formats := make([]FormatInfo, 0, len(video.Formats))
+	for _, f := range video.Formats {
+		formats = append(formats, describeFormat(f))
+	}
+	return formats, nil
+}
+
+func (s builtinSource) Acquire(ctx context.Context, req AcquireRequest) (*DownloadResult, error) {
+	videoID, err := ExtractVideoID(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.d.client.GetVideoContext(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	// A running stream has no end to download to
+	if status := builtinLiveStatus(video); status != LiveStatusNone {
+		return nil, &LiveError{Status: status}
+	}
+
+	tracker := newProgressTracker(req.Progress)
+	switch {
+	case req.Section != nil:
+		return s.d.downloadSection(ctx, video, req.DestDir, req.Tools.FFmpegPath, *req.Section, req.Formats, tracker)
+	case req.Formats.AudioOnly:
+		return s.d.downloadAudio(ctx, video, req.DestDir, req.Tools.FFmpegPath, nil, req.Formats, tracker)
+	default:
+		return s.d.downloadStreams(ctx, video, req.DestDir, req.Tools.FFmpegPath, req.Formats, tracker)
+	}
+}
@@ -0,0 +1,201 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// ytdlpSource fetches videos with the yt-dlp program, which needs ffmpeg to merge the
+// streams it downloads
+type ytdlpSource struct {
+	d *Downloader
+}
+
+// ytdlpMetadata is the part of yt-dlp's JSON description of a video that is used
+type ytdlpMetadata struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Uploader    string        `json:"uploader"`
+	Channel     string        `json:"channel"`
+	Duration    float64       `json:"duration"`
+	Thumbnail   string        `json:"thumbnail"`
+	Description string        `json:"description"`
+	Chapters    ytdlpChapters `json:"chapters"`
+	LiveStatus  string        `json:"live_status"`       // is_live, is_upcoming, post_live, was_live or not_live
+	ReleaseTime int64         `json:"release_timestamp"` // Unix seconds an upcoming stream is scheduled for
+	Formats     []struct {
+		FormatID       string  `json:"format_id"`
+		Ext            string  `json:"ext"`
+		VCodec         string  `json:"vcodec"`
+		ACodec         string  `json:"acodec"`
+		Width          int     `json:"width"`
+		Height         int     `json:"height"`
+		FPS            float64 `json:"fps"`
+		TBR            float64 `json:"tbr"` // kbit/s
+		Filesize       int64   `json:"filesize"`
+		FilesizeApprox int64   `json:"filesize_approx"`
+		FormatNote     string  `json:"format_note"`
+	} `json:"formats"`
+}
+
+func (s ytdlpSource) Name() string {
+	return SourceYtdlp
+}
+
+func (s ytdlpSource) Supports(url string) bool {
+	_, err := ExtractVideoID(url)
+	return err == nil
+}
+
+// available reports whether tools has what yt-dlp needs
+func (s ytdlpSource) available(tools Tools) error {
+	if tools.YtdlpPath == "" {
+		return fmt.Errorf("yt-dlp is not installed: %w", ErrSourceUnavailable)
+	}
+	if tools.FFmpegPath == "" {
+		return fmt.Errorf("yt-dlp needs ffmpeg: %w", ErrSourceUnavailable)
+	}
+	return nil
+}
+
+// metadata has yt-dlp describe a video without downloading it
+func (s ytdlpSource) metadata(ctx context.Context, url string, tools Tools) (*ytdlpMetadata, error) {
+	if tools.YtdlpPath == "" {
+		return nil, fmt.Errorf("yt-dlp is not installed: %w", ErrSourceUnavailable)
+	}
+	target, err := ytdlpTarget(url)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.d.ytdlpConfig()
+	args := append([]string{"-J", "--no-playlist", "--no-warnings"}, cfg.args...)
+	cmd := cfg.command(ctx, tools.YtdlpPath, append(args, target...))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, &ytdlpError{err: err, stderr: stderr.String()}
+	}
+	var meta ytdlpMetadata
+	if err := json.Unmarshal(out, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp output: %w", err)
+	}
+	return &meta, nil
+}
+
+func (s ytdlpSource) Resolve(ctx context.Context, url string, tools Tools) (*VideoInfo, error) {
+	meta, err := s.metadata(ctx, url, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	author := meta.Uploader
+	if author == "" {
+		author = meta.Channel
+	}
+	info := &VideoInfo{
+		ID:          meta.ID,
+		Title:       sanitizeFilename(meta.Title),
+		Author:      author,
+		Duration:    meta.Duration,
+		Thumbnail:   meta.Thumbnail,
+		Description: meta.Description,
+		Chapters:    meta.Chapters.list(),
+		LiveStatus:  ytdlpLiveStatus(meta.LiveStatus),
+	}
+	if info.LiveStatus == LiveStatusUpcoming {
+		info.ScheduledStart = meta.ReleaseTime
+	}
+	if info.Chapters == nil {
+		info.Chapters = ParseChapters(meta.Description, meta.Duration)
+	}
+	for _, f := range meta.Formats {
+		if f.Width > info.SourceWidth {
+			info.SourceWidth = f.Width
+			info.SourceHeight = f.Height
+		}
+	}
+	return info, nil
+}
+
+func (s ytdlpSource) ListFormats(ctx context.Context, url string, tools Tools) ([]FormatInfo, error) {
+	meta, err := s.metadata(ctx, url, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make([]FormatInfo, 0, len(meta.Formats))
+	for _, f := range meta.Formats {
+		// YouTube format IDs are itags; anything else (storyboards, HLS variants) can't
+		// be chosen by itag
+		itag, err := strconv.Atoi(f.FormatID)
+		if err != nil {
+			continue
+		}
+		info := FormatInfo{
+			Itag:         itag,
+			Container:    f.Ext,
+			Width:        f.Width,
+			Height:       f.Height,
+			FPS:          int(f.FPS),
+			Bitrate:      int(f.TBR * 1000),
+			Size:         f.Filesize,
+			QualityLabel: f.FormatNote,
+		}
+		if info.Size == 0 {
+			info.Size = f.FilesizeApprox
+		}
+		if f.VCodec != "none" {
+			info.VideoCodec = f.VCodec
+		}
+		if f.ACodec != "none" {
+			info.AudioCodec = f.ACodec
+		}
+		info.AudioOnly = info.VideoCodec == "" && info.AudioCodec != ""
+		info.VideoOnly = info.VideoCodec != "" && info.AudioCodec == ""
+		formats = append(formats, info)
+	}
+	return formats, nil
+}
+
+func (s ytdlpSource) Acquire(ctx context.Context, req AcquireRequest) (*DownloadResult, error) {
+	if err := s.available(req.Tools); err != nil {
+		return nil, err
+	}
+	videoID, err := ExtractVideoID(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newProgressTracker(req.Progress)
+	cfg, done := s.d.ytdlpDownloadConfig()
+	defer done()
+	offset := 0.0
+	if req.Section != nil {
+		offset = req.Section.Start
+	}
+
+	if req.Formats.AudioOnly {
+		fmt.Printf("[DEBUG] Trying yt-dlp for audio-only download\n")
+		outPath := filepath.Join(req.DestDir, videoID+"-audio.m4a")
+		chapters, err := downloadAudioWithYtdlp(ctx, req.URL, outPath, req.Tools.FFmpegPath, req.Tools.YtdlpPath, req.Formats.ytdlpFormat(), req.Section, cfg, tracker)
+		if err != nil {
+			return nil, err
+		}
+		return &DownloadResult{FilePath: outPath, Method: "yt-dlp", Offset: offset, AudioOnly: true, Chapters: chapters}, nil
+	}
+
+	fmt.Printf("[DEBUG] Trying yt-dlp for high-quality download\n")
+	outPath := filepath.Join(req.DestDir, videoID+"-preview.mp4")
+	chapters, err := downloadWithYtdlp(ctx, req.URL, outPath, req.Tools.FFmpegPath, req.Tools.YtdlpPath, req.Formats.ytdlpFormat(), req.Section, cfg, tracker)
+	if err != nil {
+		return nil, err
+	}
+	// Probe the downloaded file for resolution
+	w, h := probeResolution(req.Tools.FFmpegPath, outPath)
+	return &DownloadResult{FilePath: outPath, Method: "yt-dlp", Width: w, Height: h, Offset: offset, Chapters: chapters}, nil
+}
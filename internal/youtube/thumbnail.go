@@ -0,0 +1,134 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxThumbnailSize guards against saving something that clearly isn't a thumbnail
+	maxThumbnailSize = 10 * 1024 * 1024
+
+	// thumbnailFrameTime is where a fallback thumbnail frame is taken from, in seconds
+	thumbnailFrameTime = 5.0
+)
+
+// FetchThumbnail downloads the best available thumbnail of a video into destDir as
+// <videoID>.<ext> and returns its path. The full-resolution image is tried first,
+// then thumbnailURL (usually VideoInfo.Thumbnail), then the always-present hqdefault.
+func (d *Downloader) FetchThumbnail(ctx context.Context, videoID string, thumbnailURL string, destDir string) (string, error) {
+	candidates := []string{fmt.Sprintf("https://i.ytimg.com/vi/%s/maxresdefault.jpg", videoID)}
+	if thumbnailURL != "" {
+		candidates = append(candidates, thumbnailURL)
+	}
+	candidates = append(candidates, fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", videoID))
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
+	var lastErr error
+	for _, url := range candidates {
+		path, err := d.fetchThumbnailURL(ctx, url, filepath.Join(destDir, videoID))
+		if err == nil {
+			return path, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("failed to fetch thumbnail: %w", lastErr)
+}
+
+// fetchThumbnailURL saves one image to basePath plus an extension matching its type
+func (d *Downloader) fetchThumbnailURL(ctx context.Context, url string, basePath string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext := ""
+	switch mediaType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	case "image/png":
+		ext = ".png"
+	default:
+		return "", fmt.Errorf("unexpected thumbnail type %q", mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxThumbnailSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 || len(data) > maxThumbnailSize {
+		return "", fmt.Errorf("unexpected thumbnail size %d", len(data))
+	}
+
+	path := basePath + ext
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+	return path, nil
+}
+
+// ExtractThumbnail saves a frame of a downloaded video as a JPEG thumbnail. It is the
+// fallback when no thumbnail can be fetched, e.g. while offline.
+func ExtractThumbnail(ctx context.Context, ffmpegPath string, videoPath string, destPath string) error {
+	if ffmpegPath == "" {
+		return fmt.Errorf("ffmpeg is required to extract a thumbnail")
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
+	// Skip past a likely black first frame, but fall back to the start for very short videos
+	var lastErr error
+	for _, at := range []float64{thumbnailFrameTime, 0} {
+		cmd := exec.CommandContext(ctx, ffmpegPath,
+			"-y",
+			"-hide_banner",
+			"-loglevel", "error",
+			"-ss", strconv.FormatFloat(at, 'f', 3, 64),
+			"-i", videoPath,
+			"-frames:v", "1",
+			"-q:v", "2",
+			destPath,
+		)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if err == nil {
+			if info, statErr := os.Stat(destPath); statErr == nil && info.Size() > 0 {
+				return nil
+			}
+			err = fmt.Errorf("no frame at %.0fs", at)
+		} else if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		lastErr = err
+	}
+	_ = os.Remove(destPath)
+	return fmt.Errorf("failed to extract thumbnail: %w", lastErr)
+}
@@ -0,0 +1,146 @@
+package youtube
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	videoIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	timestampPattern = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+(?:\.\d+)?)s?)?$`)
+)
+
+// videoPathPrefixes are the paths on YouTube hosts that are followed by a video ID
+var videoPathPrefixes = []string{"/embed/", "/shorts/", "/live/", "/v/", "/e/"}
+
+// ParsedURL is what a YouTube video URL refers to
+type ParsedURL struct {
+	ID         string  `json:"id"`
+	URL        string  `json:"url"`                  // Canonical watch URL, without offsets or playlist
+	Start      float64 `json:"start"`                // Seconds from t=, start= or time_continue=; 0 when absent
+	End        float64 `json:"end"`                  // Seconds from end= on embed links; 0 when absent
+	PlaylistID string  `json:"playlistId,omitempty"` // Playlist the video was opened from
+}
+
+// ParseURL parses a YouTube video URL, or a bare video ID. It understands watch,
+// youtu.be, embed, shorts and live links on www., m., music. and youtube-nocookie.com
+// hosts, with or without the scheme.
+func ParseURL(raw string) (*ParsedURL, error) {
+	s := strings.TrimSpace(raw)
+	if videoIDPattern.MatchString(s) {
+		return &ParsedURL{ID: s, URL: watchURL(s)}, nil
+	}
+
+	u, err := parseWebURL(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract video ID from URL: %s", raw)
+	}
+	query := u.Query()
+
+	var id string
+	switch host := strings.ToLower(u.Hostname()); {
+	case host == "youtu.be" || host == "www.youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case isYouTubeHost(host):
+		if u.Path == "/watch" || u.Path == "/watch/" {
+			id = query.Get("v")
+			break
+		}
+		for _, prefix := range videoPathPrefixes {
+			if strings.HasPrefix(u.Path, prefix) {
+				id = strings.TrimSuffix(strings.TrimPrefix(u.Path, prefix), "/")
+				break
+			}
+		}
+	}
+	if !videoIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("could not extract video ID from URL: %s", raw)
+	}
+
+	parsed := &ParsedURL{ID: id, URL: watchURL(id)}
+	if list := query.Get("list"); playlistIDPattern.MatchString(list) {
+		parsed.PlaylistID = list
+	}
+
+	// Shared links put the time in t=, embeds in start=; some links use the fragment
+	fragment, _ := neturl.ParseQuery(u.Fragment)
+	for _, value := range []string{query.Get("t"), query.Get("start"), query.Get("time_continue"), fragment.Get("t")} {
+		if seconds, ok := parseTimestamp(value); ok {
+			parsed.Start = seconds
+			break
+		}
+	}
+	if seconds, ok := parseTimestamp(query.Get("end")); ok && seconds > parsed.Start {
+		parsed.End = seconds
+	}
+	return parsed, nil
+}
+
+// parseWebURL parses a pasted http(s) URL, which may leave out the scheme
+func parseWebURL(raw string) (*neturl.URL, error) {
+	s := strings.TrimSpace(raw)
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := neturl.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	return u, nil
+}
+
+// isYouTubeHost reports whether host serves YouTube video pages
+func isYouTubeHost(host string) bool {
+	switch host {
+	case "youtube.com", "www.youtube.com", "m.youtube.com", "music.youtube.com",
+		"youtube-nocookie.com", "www.youtube-nocookie.com":
+		return true
+	}
+	return false
+}
+
+// watchURL returns the canonical watch URL of a video
+func watchURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + id
+}
+
+// parseTimestamp parses a URL time offset such as "90", "90s" or "1h2m30s" into
+// seconds
+func parseTimestamp(s string) (float64, bool) {
+	m := timestampPattern.FindStringSubmatch(s)
+	if s == "" || m == nil {
+		return 0, false
+	}
+	var seconds float64
+	if m[1] != "" {
+		h, _ := strconv.Atoi(m[1])
+		seconds += float64(h) * 3600
+	}
+	if m[2] != "" {
+		mins, _ := strconv.Atoi(m[2])
+		seconds += float64(mins) * 60
+	}
+	if m[3] != "" {
+		sec, _ := strconv.ParseFloat(m[3], 64)
+		seconds += sec
+	}
+	return seconds, true
+}
+
+// ytdlpTarget returns the arguments that name a video for yt-dlp, to go last on its
+// command line. yt-dlp never sees the input itself, only a watch URL rebuilt from the
+// parsed ID, and the "--" before it ends option parsing, so nothing pasted by the user
+// can be read as an option.
+func ytdlpTarget(url string) ([]string, error) {
+	parsed, err := ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"--", parsed.URL}, nil
+}
@@ -0,0 +1,135 @@
+package youtube
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWatchURL = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ParsedURL
+	}{
+		{"dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"  dQw4w9WgXcQ\n", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"youtube.com/watch?v=dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"http://m.youtube.com/watch?v=dQw4w9WgXcQ&feature=share", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"https://youtu.be/dQw4w9WgXcQ?t=90", ParsedURL{ID: "dQw4w9WgXcQ", Start: 90}},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=1h2m3s", ParsedURL{ID: "dQw4w9WgXcQ", Start: 3723}},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ#t=1m30s", ParsedURL{ID: "dQw4w9WgXcQ", Start: 90}},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ?start=10&end=20", ParsedURL{ID: "dQw4w9WgXcQ", Start: 10, End: 20}},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ?start=30&end=20", ParsedURL{ID: "dQw4w9WgXcQ", Start: 30}},
+		{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/live/dQw4w9WgXcQ?si=abc", ParsedURL{ID: "dQw4w9WgXcQ"}},
+		{
+			"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf&index=3",
+			ParsedURL{ID: "dQw4w9WgXcQ", PlaylistID: "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseURL(tt.in)
+		if err != nil {
+			t.Errorf("ParseURL(%q): %v", tt.in, err)
+			continue
+		}
+		tt.want.URL = watchURL(tt.want.ID)
+		if *got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+// smugglingInputs are pasted "URLs" that try to slip options or other targets to
+// yt-dlp. reject is set for the ones that aren't a YouTube video at all.
+var smugglingInputs = []struct {
+	in     string
+	reject bool
+}{
+	{"--exec=touch /tmp/pwned", true},
+	{"--exec", true},
+	{"-o/tmp/x", true},
+	{"-o /tmp/x", true},
+	{"--config-location=/tmp/evil.conf", true},
+	{"https://youtube.com/watch?v=dQw4w9WgXcQ --exec", true},
+	{"https://youtube.com/watch?v=dQw4w9WgXcQ\n--exec=id", true},
+	{"https://youtube.com/watch?v=dQw4w9WgXcQ\r\n-o/tmp/x", true},
+	{"https://www.youtube.com/watch?v=dQw4w9WgXcQ\t--exec", true},
+	{"https://youtu.be/dQw4w9WgXcQ%0A--exec=id", true},
+	{"https://evil.com/watch?v=dQw4w9WgXcQ", true},
+	{"https://youtube.com.evil.com/watch?v=dQw4w9WgXcQ", true},
+	{"https://evil.com/?u=youtube.com/watch?v=dQw4w9WgXcQ", true},
+	{"ftp://youtube.com/watch?v=dQw4w9WgXcQ", true},
+	{"file:///etc/passwd", true},
+	{"ytsearch:never gonna give you up", true},
+	// YouTube URLs carrying extra baggage: only the ID survives
+	{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&--exec=touch%20/tmp/x", false},
+	{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&o=/tmp/x#--exec", false},
+	{"https://user@evil.com@www.youtube.com/watch?v=dQw4w9WgXcQ", false},
+	{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PL--exec", false},
+}
+
+func TestYtdlpTargetSmuggling(t *testing.T) {
+	for _, tt := range smugglingInputs {
+		got, err := ytdlpTarget(tt.in)
+		if tt.reject {
+			if err == nil {
+				t.Errorf("ytdlpTarget(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if want := []string{"--", testWatchURL}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ytdlpTarget(%q) = %q, %v; want %q", tt.in, got, err, want)
+		}
+	}
+}
+
+// IDs may start with a dash; after "--" that is harmless
+func TestYtdlpTargetDashID(t *testing.T) {
+	got, err := ytdlpTarget("-dQw4w9WgXc")
+	if want := []string{"--", "https://www.youtube.com/watch?v=-dQw4w9WgXc"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ytdlpTarget = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestYtdlpGetsOnlyCanonicalURL(t *testing.T) {
+	ytdlpPath, argsPath := argsScript(t, "yt-dlp", `echo '{"id": "dQw4w9WgXcQ", "title": "Video", "duration": 10}'`)
+	d := NewDownloader()
+	if err := d.SetStrategy([]string{SourceYtdlp}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range smugglingInputs {
+		_ = os.Remove(argsPath)
+		_, err := d.ResolveVideo(context.Background(), tt.in, Tools{YtdlpPath: ytdlpPath})
+
+		data, readErr := os.ReadFile(argsPath)
+		if tt.reject {
+			if err == nil || readErr == nil {
+				t.Errorf("%q: yt-dlp was run", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		args := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		dashes := 0
+		for _, arg := range args {
+			if arg == "--" {
+				dashes++
+			}
+		}
+		if n := len(args); n < 2 || dashes != 1 || args[n-2] != "--" || args[n-1] != testWatchURL {
+			t.Errorf("%q: yt-dlp got %q, want options then -- %s", tt.in, args, testWatchURL)
+		}
+	}
+}
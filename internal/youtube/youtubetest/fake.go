@@ -0,0 +1,146 @@
+// Package youtubetest provides a fake youtube.Source for testing code that downloads
+// videos, without the network. Only tests import it, so it is never part of the app.
+package youtubetest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"yt-downloader/internal/youtube"
+)
+
+// SourceName is the name of Source in a strategy
+const SourceName = "fake"
+
+// Source is an in-process youtube.Source for testing the whole download flow without
+// the network. It claims every YouTube URL and serves the same local MP4 for each, which
+// must be H.264/AAC so it plays as it is. Plain downloads only copy the file; sections
+// and audio-only downloads go through the local file source, which needs ffmpeg. Add it
+// with Downloader.AddSource.
+type Source struct {
+	Path     string // The MP4 served for every video
+	Title    string // Defaults to "Test video <id>"
+	Author   string
+	Duration float64 // in seconds
+	Width    int
+	Height   int
+	Chapters []youtube.Chapter
+
+	// Err, if set, is returned by every call instead, for testing failures
+	Err error
+}
+
+func (s *Source) Name() string {
+	return SourceName
+}
+
+func (s *Source) Supports(url string) bool {
+	_, err := youtube.ExtractVideoID(url)
+	return err == nil
+}
+
+func (s *Source) Resolve(ctx context.Context, url string, tools youtube.Tools) (*youtube.VideoInfo, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	videoID, err := youtube.ExtractVideoID(url)
+	if err != nil {
+		return nil, err
+	}
+	title := s.Title
+	if title == "" {
+		title = fmt.Sprintf("Test video %s", videoID)
+	}
+	return &youtube.VideoInfo{
+		ID:           videoID,
+		Title:        title,
+		Author:       s.Author,
+		Duration:     s.Duration,
+		SourceWidth:  s.Width,
+		SourceHeight: s.Height,
+		Chapters:     s.Chapters,
+	}, nil
+}
+
+func (s *Source) ListFormats(ctx context.Context, url string, tools youtube.Tools) ([]youtube.FormatInfo, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return []youtube.FormatInfo{{
+		Itag:       18,
+		Container:  "mp4",
+		VideoCodec: "avc1.42001E",
+		AudioCodec: "mp4a.40.2",
+		Width:      s.Width,
+		Height:     s.Height,
+	}}, nil
+}
+
+func (s *Source) Acquire(ctx context.Context, req youtube.AcquireRequest) (*youtube.DownloadResult, error) {
+	info, err := s.Resolve(ctx, req.URL, req.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *youtube.DownloadResult
+	if req.Section == nil && !req.Formats.AudioOnly {
+		result = &youtube.DownloadResult{
+			FilePath: filepath.Join(req.DestDir, info.ID+"-preview.mp4"),
+			Width:    s.Width,
+			Height:   s.Height,
+		}
+		if err := copyFile(s.Path, result.FilePath); err != nil {
+			return nil, fmt.Errorf("failed to copy file: %w", err)
+		}
+		if req.Progress != nil {
+			req.Progress(youtube.Progress{Fraction: 1.0, Stage: youtube.StageDownloading})
+		}
+	} else {
+		if req.Tools.FFmpegPath == "" {
+			return nil, fmt.Errorf("the fake source needs ffmpeg for sections and audio-only downloads")
+		}
+		result, err = s.acquireLocal(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Method = SourceName
+	result.Chapters = s.Chapters
+	return result, nil
+}
+
+// acquireLocal cuts or converts the file as the local file source would
+func (s *Source) acquireLocal(ctx context.Context, req youtube.AcquireRequest) (*youtube.DownloadResult, error) {
+	path, err := filepath.Abs(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	local := youtube.NewDownloader()
+	if err := local.SetStrategy([]string{youtube.SourceLocal}); err != nil {
+		return nil, err
+	}
+	if req.Section != nil {
+		return local.DownloadSection(ctx, path, req.DestDir, req.Tools.FFmpegPath, "", *req.Section, req.Formats, req.Progress)
+	}
+	return local.DownloadForPreview(ctx, path, nil, req.DestDir, req.Tools.FFmpegPath, "", req.Formats, req.Progress)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
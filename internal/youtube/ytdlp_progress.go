@@ -0,0 +1,122 @@
+package youtube
+
+import (
+	"strconv"
+	"strings"
+)
+
+// yt-dlp is asked to print progress in this machine-readable form (one report per line
+// thanks to --newline) so the real byte counts can be forwarded to the UI.
+const (
+	ytdlpProgressPrefix    = "[ytdlp-progress] "
+	ytdlpPostprocessPrefix = "[ytdlp-postprocess] "
+
+	ytdlpDownloadTemplate = "download:" + ytdlpProgressPrefix +
+		"%(progress.status)s|%(progress.downloaded_bytes)s|%(progress.total_bytes)s|%(progress.total_bytes_estimate)s|" +
+		"%(progress.speed)s|%(progress.eta)s|%(info.vcodec)s|%(info.acodec)s"
+	ytdlpPostprocessTemplate = "postprocess:" + ytdlpPostprocessPrefix +
+		"%(progress.status)s|%(progress.postprocessor)s"
+)
+
+// ytdlpProgressParser turns yt-dlp stdout into progress reports. yt-dlp downloads the
+// video stream, then the audio stream, then merges them, so each stage is mapped onto
+// its own slice of the overall progress range, matching the native mux path.
+type ytdlpProgressParser struct {
+	tracker  *progressTracker
+	sawVideo bool
+	stage    Stage
+	fraction float64 // Last overall fraction reported
+}
+
+// parseLine handles one line of yt-dlp stdout, ignoring anything it doesn't recognize
+func (p *ytdlpProgressParser) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, ytdlpProgressPrefix):
+		p.parseDownload(strings.TrimPrefix(line, ytdlpProgressPrefix))
+	case strings.HasPrefix(line, ytdlpPostprocessPrefix):
+		fields := strings.Split(strings.TrimPrefix(line, ytdlpPostprocessPrefix), "|")
+		if len(fields) == 2 && fields[1] == "Merger" {
+			p.setMerging()
+		}
+	case strings.HasPrefix(line, "[Merger]"):
+		// Older yt-dlp builds don't apply postprocess templates
+		p.setMerging()
+	}
+}
+
+func (p *ytdlpProgressParser) parseDownload(s string) {
+	fields := strings.Split(s, "|")
+	if len(fields) != 8 {
+		return
+	}
+	status, vcodec, acodec := fields[0], fields[6], fields[7]
+
+	stage := StageDownloading
+	base, weight := 0.0, 0.95
+	switch {
+	case vcodec == "none":
+		stage = StageDownloadingAudio
+		if p.sawVideo {
+			base, weight = 0.75, 0.20
+		}
+	case acodec == "none":
+		stage = StageDownloadingVideo
+		p.sawVideo = true
+		base, weight = 0.0, 0.75
+	}
+
+	downloaded := parseYtdlpNumber(fields[1])
+	total := parseYtdlpNumber(fields[2])
+	if total <= 0 {
+		total = parseYtdlpNumber(fields[3])
+	}
+
+	fraction := 0.0
+	if total > 0 {
+		fraction = downloaded / total
+	}
+	if status == "finished" || fraction > 1 {
+		fraction = 1
+	}
+
+	overall := base + fraction*weight
+	if total <= 0 && status != "finished" && p.fraction > overall {
+		// The size stopped being known partway; hold on rather than fall back to 0
+		overall = p.fraction
+	}
+
+	eta := -1.0
+	if fields[5] != "NA" {
+		eta = parseYtdlpNumber(fields[5])
+	}
+
+	p.stage = stage
+	p.fraction = overall
+	p.tracker.report(Progress{
+		Fraction:        overall,
+		Stage:           stage,
+		DownloadedBytes: int64(downloaded),
+		TotalBytes:      int64(total),
+		Speed:           parseYtdlpNumber(fields[4]),
+		ETA:             eta,
+	})
+}
+
+func (p *ytdlpProgressParser) setMerging() {
+	if p.stage == StageMerging {
+		return
+	}
+	p.stage = StageMerging
+	p.fraction = 0.95
+	p.tracker.report(Progress{Fraction: 0.95, Stage: StageMerging, ETA: -1})
+}
+
+// parseYtdlpNumber parses a template field, which is "NA" when yt-dlp doesn't know the value
+func parseYtdlpNumber(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,131 @@
+package youtube
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// replayYtdlp runs a yt-dlp stand-in that prints output, as recorded from a real
+// run with the progress templates, through runYtdlp and returns the reports
+func replayYtdlp(t *testing.T, output string) []Progress {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake yt-dlp is a shell script")
+	}
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "output")
+	if err := os.WriteFile(outputPath, []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "yt-dlp")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\ncat '"+outputPath+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var reports []Progress
+	tracker := newProgressTracker(func(p Progress) {
+		mu.Lock()
+		reports = append(reports, p)
+		mu.Unlock()
+	})
+	if err := runYtdlp(context.Background(), path, nil, ytdlpConfig{}, tracker); err != nil {
+		t.Fatal(err)
+	}
+	return reports
+}
+
+func checkReports(t *testing.T, got []Progress, want []Progress) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d reports, want %d:\n%+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if math.Abs(g.Fraction-w.Fraction) > 1e-9 {
+			t.Errorf("report %d: fraction %v, want %v", i, g.Fraction, w.Fraction)
+		}
+		g.Fraction, w.Fraction = 0, 0
+		if g != w {
+			t.Errorf("report %d:\n got %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func TestYtdlpProgressVideoAudioMerge(t *testing.T) {
+	reports := replayYtdlp(t, `[youtube] Extracting URL: https://www.youtube.com/watch?v=dQw4w9WgXcQ
+[youtube] dQw4w9WgXcQ: Downloading webpage
+[info] dQw4w9WgXcQ: Downloading 1 format(s): 137+140
+[download] Destination: /tmp/dQw4w9WgXcQ-preview.f137.mp4
+[ytdlp-progress] downloading|1048576|4194304|NA|524288.5|6|avc1.640028|none
+[ytdlp-progress] finished|4194304|4194304|NA|NA|NA|avc1.640028|none
+[download] Destination: /tmp/dQw4w9WgXcQ-preview.f140.m4a
+[ytdlp-progress] downloading|500000|1000000|NA|250000|2|none|mp4a.40.2
+[ytdlp-progress] finished|1000000|1000000|NA|NA|NA|none|mp4a.40.2
+[Merger] Merging formats into "/tmp/dQw4w9WgXcQ-preview.mp4"
+[ytdlp-postprocess] started|Merger
+[ytdlp-postprocess] finished|Merger
+[ytdlp-postprocess] started|MoveFiles
+`)
+	checkReports(t, reports, []Progress{
+		{Fraction: 0.25 * 0.75, Stage: StageDownloadingVideo, DownloadedBytes: 1048576, TotalBytes: 4194304, Speed: 524288.5, ETA: 6},
+		{Fraction: 0.75, Stage: StageDownloadingVideo, DownloadedBytes: 4194304, TotalBytes: 4194304, ETA: -1},
+		{Fraction: 0.75 + 0.5*0.20, Stage: StageDownloadingAudio, DownloadedBytes: 500000, TotalBytes: 1000000, Speed: 250000, ETA: 2},
+		{Fraction: 0.95, Stage: StageDownloadingAudio, DownloadedBytes: 1000000, TotalBytes: 1000000, ETA: -1},
+		// "[Merger]" and the postprocess template both announce the merge; it is reported once
+		{Fraction: 0.95, Stage: StageMerging, ETA: -1},
+		{Fraction: 1, Stage: StageMerging, ETA: 0},
+	})
+}
+
+func TestYtdlpProgressAudioOnly(t *testing.T) {
+	reports := replayYtdlp(t, `[info] dQw4w9WgXcQ: Downloading 1 format(s): 140
+[ytdlp-progress] downloading|250000|1000000|NA|100000|7.5|none|mp4a.40.2
+[ytdlp-progress] finished|1000000|1000000|NA|NA|NA|none|mp4a.40.2
+`)
+	// Without a video stream first, the audio stream is the whole download
+	checkReports(t, reports, []Progress{
+		{Fraction: 0.25 * 0.95, Stage: StageDownloadingAudio, DownloadedBytes: 250000, TotalBytes: 1000000, Speed: 100000, ETA: 7.5},
+		{Fraction: 0.95, Stage: StageDownloadingAudio, DownloadedBytes: 1000000, TotalBytes: 1000000, ETA: -1},
+		{Fraction: 1, Stage: StageDownloadingAudio, ETA: 0},
+	})
+}
+
+func TestYtdlpProgressUnknownFields(t *testing.T) {
+	reports := replayYtdlp(t, `[ytdlp-progress] downloading|NA|NA|NA|NA|NA|NA|NA
+[ytdlp-progress] downloading|300|NA|1200|NA|NA|avc1.4d401e|mp4a.40.2
+[ytdlp-progress] downloading|1500|NA|1200|800|NA|avc1.4d401e|mp4a.40.2
+[ytdlp-progress] downloading|1800|NA|NA|NA|NA|avc1.4d401e|mp4a.40.2
+[ytdlp-progress] downloading|garbage
+[ytdlp-progress] finished|2000|2000|NA|NA|0|avc1.4d401e|mp4a.40.2
+`)
+	checkReports(t, reports, []Progress{
+		// Nothing known yet
+		{Fraction: 0, Stage: StageDownloading, ETA: -1},
+		// total_bytes is NA, so the estimate stands in for it
+		{Fraction: 0.25 * 0.95, Stage: StageDownloading, DownloadedBytes: 300, TotalBytes: 1200, ETA: -1},
+		// An estimate that turned out low doesn't push the fraction past the stage
+		{Fraction: 0.95, Stage: StageDownloading, DownloadedBytes: 1500, TotalBytes: 1200, Speed: 800, ETA: -1},
+		// Neither is known any more; the fraction stays where it was
+		{Fraction: 0.95, Stage: StageDownloading, DownloadedBytes: 1800, ETA: -1},
+		{Fraction: 0.95, Stage: StageDownloading, DownloadedBytes: 2000, TotalBytes: 2000, ETA: 0},
+		{Fraction: 1, Stage: StageDownloading, ETA: 0},
+	})
+}
+
+// yt-dlp's own output never looks like a report, whatever the video is called
+func TestYtdlpProgressIgnoresOtherLines(t *testing.T) {
+	reports := replayYtdlp(t, strings.Join([]string{
+		`[download] Destination: [ytdlp-progress] downloading|1|2|3|4|5|6.mp4`,
+		`[download]  50.0% of 10.00MiB at 1.00MiB/s ETA 00:05`,
+		`WARNING: [youtube] Merger not found`,
+		``,
+	}, "\n"))
+	checkReports(t, reports, []Progress{{Fraction: 1, ETA: 0}})
+}
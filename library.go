@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"yt-downloader/internal/library"
+	"yt-downloader/internal/youtube"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// getLibrary returns the media library, or nil if no library directory is configured
+func (a *App) getLibrary() *library.Library {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	return a.library
+}
+
+// openLibrary opens the library at dir and allows the preview server to serve from it
+func (a *App) openLibrary(dir string) error {
+	lib, err := library.Open(dir)
+	if err != nil {
+		return err
+	}
+	a.settingsMu.Lock()
+	a.library = lib
+	a.settingsMu.Unlock()
+	a.videoServer.AddAllowedDir(dir)
+	return nil
+}
+
+// GetLibraryDirectory returns the media library directory, or "" if none is set
+func (a *App) GetLibraryDirectory() string {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	return a.settings.LibraryDir
+}
+
+// SetLibraryDirectory chooses where downloaded videos, thumbnails and clips are kept
+func (a *App) SetLibraryDirectory(dir string) error {
+	if err := a.openLibrary(dir); err != nil {
+		return fmt.Errorf("failed to open library: %w", err)
+	}
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings.LibraryDir = dir
+	return a.saveSettings()
+}
+
+// SelectLibraryDirectory opens a native directory picker and uses the result as the library
+func (a *App) SelectLibraryDirectory() (string, error) {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Select Library Directory",
+		CanCreateDirectories: true,
+	})
+	if err != nil || dir == "" {
+		return "", err
+	}
+	if err := a.SetLibraryDirectory(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// ListLibrary returns every video in the library, most recently added first
+func (a *App) ListLibrary() []library.Entry {
+	return a.SearchLibrary("")
+}
+
+// SearchLibrary returns library videos whose title, author or description match query
+func (a *App) SearchLibrary(query string) []library.Entry {
+	lib := a.getLibrary()
+	if lib == nil {
+		return []library.Entry{}
+	}
+	return lib.Search(query)
+}
+
+// DeleteLibraryVideo removes a video, its thumbnail and its clips from the library
+func (a *App) DeleteLibraryVideo(id string) error {
+	lib := a.getLibrary()
+	if lib == nil {
+		return fmt.Errorf("no library directory set")
+	}
+	entry, err := lib.Get(id)
+	if err != nil {
+		return err
+	}
+	if a.videoServer.GetCurrentVideoPath() == lib.Path(entry.VideoPath) {
+		a.videoServer.ClearVideo()
+		a.currentVideoID = ""
+		a.currentOffset = 0
+		a.currentAudioOnly = false
+		a.currentSource = library.Provenance{}
+	}
+	return lib.Delete(id)
+}
+
+// OpenLibraryVideo loads a library video into the editor without any network access
+func (a *App) OpenLibraryVideo(id string) (*VideoInfo, error) {
+	lib := a.getLibrary()
+	if lib == nil {
+		return nil, fmt.Errorf("no library directory set")
+	}
+	entry, err := lib.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.openLibraryEntry(entry, nil)
+}
+
+// openLibraryEntry makes a library video the current preview. If section is set the
+// trim range is preset to it, as for a section download.
+func (a *App) openLibraryEntry(entry library.Entry, section *youtube.Section) (*VideoInfo, error) {
+	if a.previewBaseURL == "" {
+		return nil, fmt.Errorf("preview server not available")
+	}
+	lib := a.getLibrary()
+	path := lib.Path(entry.VideoPath)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("library file is missing: %w", err)
+	}
+
+	a.currentVideoID = entry.Info.ID
+	a.setCurrentSource(&entry.Info, entry.SourceURL)
+	a.videoServer.SetCurrentVideo(path, entry.Info.ID)
+	logInfo(a.ctx, fmt.Sprintf("Opened %s from library: %s", entry.Info.ID, path))
+
+	info := &VideoInfo{
+		ID:           entry.Info.ID,
+		Title:        entry.Info.Title,
+		Author:       entry.Info.Author,
+		Duration:     entry.Info.Duration,
+		Thumbnail:    a.cacheThumbnail(&entry.Info, path),
+		VideoURL:     a.previewBaseURL + a.videoServer.GetCurrentVideoURL(),
+		SourceWidth:  entry.Info.SourceWidth,
+		SourceHeight: entry.Info.SourceHeight,
+		AudioOnly:    entry.AudioOnly,
+	}
+	if entry.Section != nil {
+		info.Offset = entry.Section.Start
+		info.Duration = entry.Section.Duration()
+	}
+	a.currentOffset = info.Offset
+	a.currentAudioOnly = info.AudioOnly
+	if section != nil {
+		info.TrimStart = section.Start - info.Offset
+		info.TrimEnd = section.End - info.Offset
+	}
+	info.Chapters = a.setCurrentChapters(entry.Info.Chapters, info.Offset, info.Duration)
+	return info, nil
+}
+
+// findInLibrary returns the library entry for url if it covers section (nil for the whole
+// video). Audio-only entries are only returned when audioOnly is set.
+func (a *App) findInLibrary(url string, section *youtube.Section, audioOnly bool) (library.Entry, bool) {
+	lib := a.getLibrary()
+	if lib == nil {
+		return library.Entry{}, false
+	}
+	videoID, err := youtube.ExtractVideoID(url)
+	if err != nil {
+		return library.Entry{}, false
+	}
+	entry, err := lib.Get(videoID)
+	if err != nil || !entry.Covers(section) || (entry.AudioOnly && !audioOnly) {
+		return library.Entry{}, false
+	}
+	return entry, true
+}
+
+// addToLibrary moves a finished download into the library, if one is configured, and
+// returns the path the video now lives at. section is the range that was downloaded.
+func (a *App) addToLibrary(info youtube.VideoInfo, url string, result *youtube.DownloadResult, section *youtube.Section) string {
+	lib := a.getLibrary()
+	if lib == nil {
+		return result.FilePath
+	}
+	entry, err := lib.Add(info, url, result, section)
+	if err != nil {
+		logWarning(a.ctx, fmt.Sprintf("Failed to add %s to library: %v", info.ID, err))
+		return result.FilePath
+	}
+	emitEvent(a.ctx, "library:changed")
+	return lib.Path(entry.VideoPath)
+}
+
+// addClipToLibrary records an exported clip with the library video it was cut from
+func (a *App) addClipToLibrary(clipPath string, start float64, end float64) {
+	lib := a.getLibrary()
+	if lib == nil || a.currentVideoID == "" {
+		return
+	}
+	entry, err := lib.Get(a.currentVideoID)
+	if err != nil || a.videoServer.GetCurrentVideoPath() != lib.Path(entry.VideoPath) {
+		return
+	}
+	// Clip times are stored relative to the full video
+	offset := 0.0
+	if entry.Section != nil {
+		offset = entry.Section.Start
+	}
+	if _, err := lib.AddClip(entry.Info.ID, clipPath, start+offset, end+offset); err != nil {
+		logWarning(a.ctx, fmt.Sprintf("Failed to add clip to library: %v", err))
+		return
+	}
+	emitEvent(a.ctx, "library:changed")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"yt-downloader/internal/queue"
+	"yt-downloader/internal/youtube"
+)
+
+// CaptureLive records a running livestream (see youtube.LiveCapture) and opens the
+// recording like a downloaded video. Recording from now goes on until
+// StopLiveCapture is called. Recordings aren't kept in the library, so the finished
+// video can be downloaded there once the stream ends.
+func (a *App) CaptureLive(url string, capture youtube.LiveCapture) (*VideoInfo, error) {
+	if err := a.previewReady(); err != nil {
+		return nil, err
+	}
+	if err := capture.Validate(); err != nil {
+		return nil, err
+	}
+
+	jobID := queue.NewID()
+	ffmpegPath, ytdlpPath := a.toolPaths(jobID)
+	info, err := a.downloader.ResolveVideo(a.ctx, url, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath})
+	if err != nil {
+		a.emitDownloadError(jobID, err)
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+	if info.LiveStatus != youtube.LiveStatusLive {
+		if err := info.Downloadable(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("this video isn't live; load it instead")
+	}
+
+	stop := make(chan struct{})
+	a.captureMu.Lock()
+	if a.stopCapture != nil {
+		a.captureMu.Unlock()
+		return nil, fmt.Errorf("a livestream is already being recorded")
+	}
+	a.stopCapture = sync.OnceFunc(func() { close(stop) })
+	a.captureMu.Unlock()
+	defer func() {
+		a.captureMu.Lock()
+		a.stopCapture = nil
+		a.captureMu.Unlock()
+	}()
+
+	a.videoServer.ClearVideo()
+	dlResult, err := a.downloader.CaptureLive(a.ctx, url, a.tempDir, ffmpegPath, ytdlpPath, capture, stop, a.downloadProgress(jobID))
+	if err != nil {
+		a.emitDownloadError(jobID, err)
+		return nil, fmt.Errorf("failed to record livestream: %w", err)
+	}
+	logInfo(a.ctx, fmt.Sprintf("Recorded livestream %s: %.0fs at %dx%d", info.ID, dlResult.Duration, dlResult.Width, dlResult.Height))
+
+	a.currentVideoID = info.ID
+	a.currentOffset = 0
+	a.currentAudioOnly = dlResult.AudioOnly
+	a.setCurrentSource(info, url)
+	a.videoServer.SetCurrentVideo(dlResult.FilePath, info.ID)
+
+	emitEvent(a.ctx, "download:complete", map[string]interface{}{
+		"jobId": jobID,
+	})
+
+	result := &VideoInfo{
+		ID:           info.ID,
+		Title:        info.Title,
+		Author:       info.Author,
+		Duration:     dlResult.Duration,
+		Thumbnail:    a.cacheThumbnail(info, dlResult.FilePath),
+		VideoURL:     a.previewBaseURL + a.videoServer.GetCurrentVideoURL(),
+		SourceWidth:  dlResult.Width,
+		SourceHeight: dlResult.Height,
+		AudioOnly:    dlResult.AudioOnly,
+	}
+	result.Chapters = a.setCurrentChapters(nil, 0, result.Duration)
+	return result, nil
+}
+
+// StopLiveCapture ends the recording started by CaptureLive, which then opens what
+// was recorded. It does nothing if no livestream is being recorded.
+func (a *App) StopLiveCapture() {
+	a.captureMu.Lock()
+	defer a.captureMu.Unlock()
+	if a.stopCapture != nil {
+		a.stopCapture()
+	}
+}
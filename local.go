@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"yt-downloader/internal/youtube"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// SelectLocalFile opens a native file picker for a video or audio file to open
+func (a *App) SelectLocalFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Open Video or Audio File",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Media Files", Pattern: "*.mp4;*.m4v;*.mov;*.mkv;*.webm;*.avi;*.m4a;*.mp3;*.wav;*.flac;*.ogg;*.opus"},
+			{DisplayName: "All Files", Pattern: "*"},
+		},
+	})
+}
+
+// LoadLocalFile opens a video or audio file from disk in the editor, without any
+// network access. Files WebKit can't play are converted to a preview copy first;
+// clips are still exported from the original.
+func (a *App) LoadLocalFile(path string) (*VideoInfo, error) {
+	if a.previewBaseURL == "" {
+		if a.previewErr != nil {
+			return nil, fmt.Errorf("preview server failed to start: %w", a.previewErr)
+		}
+		return nil, fmt.Errorf("preview server not available")
+	}
+	if a.ffmpegInstaller == nil || !a.ffmpegInstaller.IsInstalled() {
+		return nil, fmt.Errorf("FFmpeg is not installed")
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	emitEvent(a.ctx, "download:status", map[string]interface{}{
+		"status": "Opening file...",
+	})
+	info, result, err := a.downloader.OpenLocalFile(a.ctx, absPath, a.tempDir, a.ffmpegInstaller.GetFFmpegPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	logInfo(a.ctx, fmt.Sprintf("Opened local file: method=%s resolution=%dx%d", result.Method, result.Width, result.Height))
+
+	a.videoServer.ClearVideo()
+	a.currentVideoID = info.ID
+	a.currentOffset = 0
+	a.setCurrentSource(info, absPath)
+	a.currentAudioOnly = result.AudioOnly
+	a.localVideoID, a.localOriginal = "", ""
+	if result.FilePath == absPath {
+		a.videoServer.SetOpenedVideo(absPath, info.ID)
+	} else {
+		// The preview copy is in the temp directory; remember where it came from
+		a.videoServer.SetCurrentVideo(result.FilePath, info.ID)
+		a.localVideoID, a.localOriginal = info.ID, absPath
+	}
+
+	videoInfo := &VideoInfo{
+		ID:           info.ID,
+		Title:        info.Title,
+		Duration:     info.Duration,
+		Thumbnail:    a.localThumbnail(info, result),
+		VideoURL:     a.previewBaseURL + a.videoServer.GetCurrentVideoURL(),
+		SourceWidth:  info.SourceWidth,
+		SourceHeight: info.SourceHeight,
+		AudioOnly:    result.AudioOnly,
+	}
+	videoInfo.Chapters = a.setCurrentChapters(nil, 0, info.Duration)
+	return videoInfo, nil
+}
+
+// localThumbnail extracts a frame of a local video to show as its thumbnail and
+// returns its URL, or "" for audio files
+func (a *App) localThumbnail(info *youtube.VideoInfo, result *youtube.DownloadResult) string {
+	if result.AudioOnly || a.thumbsDir == "" {
+		return ""
+	}
+	path := a.findThumbnail(info.ID)
+	if path == "" {
+		framePath := filepath.Join(a.thumbsDir, info.ID+".jpg")
+		if err := youtube.ExtractThumbnail(a.ctx, a.ffmpegInstaller.GetFFmpegPath(), result.FilePath, framePath); err != nil {
+			logWarning(a.ctx, fmt.Sprintf("Failed to extract thumbnail for %s: %v", info.Title, err))
+			return ""
+		}
+		path = framePath
+	}
+	a.videoServer.SetThumbnail(info.ID, path)
+	return a.previewBaseURL + a.videoServer.GetThumbnailURL(info.ID)
+}
+
+// exportSource returns the file clips of the current preview are cut from: the
+// original for a converted local file, otherwise the preview itself. It goes by the
+// video ID, as a later download can reuse the preview copy's path.
+func (a *App) exportSource() string {
+	inputPath := a.videoServer.GetCurrentVideoPath()
+	if inputPath != "" && a.localOriginal != "" && a.currentVideoID == a.localVideoID {
+		return a.localOriginal
+	}
+	return inputPath
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yt-downloader/internal/network"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// networkCheckURL is requested by CheckNetworkSettings
+const networkCheckURL = "https://www.youtube.com/"
+
+// GetNetworkSettings returns the proxy, certificate and timeout settings
+func (a *App) GetNetworkSettings() network.Settings {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	return a.settings.Network
+}
+
+// SetNetworkSettings applies network settings to all downloads, including yt-dlp's
+// and the FFmpeg installer's, and saves them
+func (a *App) SetNetworkSettings(s network.Settings) error {
+	if err := a.applyNetwork(s); err != nil {
+		return err
+	}
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings.Network = s
+	return a.saveSettings()
+}
+
+// CheckNetworkSettings tries to reach YouTube with network settings before they are saved
+func (a *App) CheckNetworkSettings(s network.Settings) error {
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+	return s.Check(ctx, networkCheckURL)
+}
+
+// GetRateLimit returns the download speed limit in bytes per second, 0 for none
+func (a *App) GetRateLimit() int64 {
+	return a.downloader.RateLimit()
+}
+
+// SetRateLimit caps the combined speed of all downloads in bytes per second; 0
+// removes the limit. Section downloads and live recordings aren't limited.
+func (a *App) SetRateLimit(bytesPerSec int64) error {
+	if bytesPerSec < 0 {
+		return fmt.Errorf("rate limit can't be negative")
+	}
+	a.downloader.SetRateLimit(bytesPerSec)
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	a.settings.RateLimit = bytesPerSec
+	return a.saveSettings()
+}
+
+// SelectCABundle opens a file dialog for choosing a PEM certificate bundle
+func (a *App) SelectCABundle() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select CA Certificates",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Certificates (PEM)", Pattern: "*.pem;*.crt;*.cer"},
+			{DisplayName: "All Files", Pattern: "*"},
+		},
+	})
+}
+
+// applyNetwork hands network settings to everything that goes online
+func (a *App) applyNetwork(s network.Settings) error {
+	if err := a.downloader.SetNetwork(s); err != nil {
+		return err
+	}
+	if a.ffmpegInstaller != nil {
+		client, err := s.Client()
+		if err != nil {
+			return fmt.Errorf("failed to set up network: %w", err)
+		}
+		a.ffmpegInstaller.SetHTTPClient(client)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"yt-downloader/internal/ffmpeg"
+	"yt-downloader/internal/library"
+	"yt-downloader/internal/youtube"
+)
+
+// ClipSource is where an exported clip came from, as recorded in its tags
+type ClipSource struct {
+	Provenance library.Provenance `json:"provenance"`
+	URL        string             `json:"url"` // Loads the source video with the clip's range preset
+}
+
+// setCurrentSource remembers where the current preview came from, so exported clips
+// can say so
+func (a *App) setCurrentSource(info *youtube.VideoInfo, url string) {
+	source := library.Provenance{Title: info.Title, Author: info.Author, SourceURL: url, VideoID: info.ID}
+	if parsed, err := youtube.ParseURL(url); err == nil {
+		source.SourceURL = parsed.URL
+	}
+	a.currentSource = source
+}
+
+// clipTags returns the tags for a clip of the current preview from start to end
+// (in seconds into the preview)
+func (a *App) clipTags(start float64, end float64) map[string]string {
+	if a.currentSource.VideoID == "" {
+		return nil
+	}
+	p := a.currentSource
+	p.ClipStart = start + a.currentOffset
+	p.ClipEnd = end + a.currentOffset
+	p.ExportedAt = time.Now()
+	return p.Tags()
+}
+
+// ReadClipProvenance reads where an exported clip came from out of its tags
+func (a *App) ReadClipProvenance(path string) (*ClipSource, error) {
+	if a.ffmpegInstaller == nil || !a.ffmpegInstaller.IsInstalled() {
+		return nil, fmt.Errorf("FFmpeg is not installed")
+	}
+	tags, err := ffmpeg.NewProcessor(a.ffmpegInstaller.GetFFmpegPath()).ReadMetadata(a.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := library.ParseProvenance(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &ClipSource{Provenance: *p, URL: p.ClipURL()}, nil
+}
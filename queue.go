@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"yt-downloader/internal/queue"
+	"yt-downloader/internal/youtube"
+)
+
+// runJob downloads a queued job into its own directory under downloadsDir.
+// The directory is kept when a download fails so a retry can resume it.
+func (a *App) runJob(ctx context.Context, job queue.Job, progressFn youtube.ProgressFunc) (*youtube.DownloadResult, error) {
+	destDir := filepath.Join(a.downloadsDir, job.ID)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	formats := youtube.FormatOptions{Ceiling: a.GetQualityCeiling()}
+	info, result, cached := a.downloader.CachedPreview(job.URL, destDir, formats)
+	if !cached {
+		ffmpegPath, ytdlpPath := a.toolPaths(job.ID)
+		var err error
+		info, err = a.downloader.ResolveVideo(ctx, job.URL, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get video info: %w", err)
+		}
+		// A running livestream would download for as long as it runs; it has to be captured instead
+		if err := info.Downloadable(); err != nil {
+			return nil, err
+		}
+		result, err = a.downloader.DownloadForPreview(ctx, job.URL, info, destDir, ffmpegPath, ytdlpPath, formats, progressFn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Move the finished download into the library, if one is configured
+	if a.getLibrary() != nil {
+		libraryInfo := *info
+		if len(result.Chapters) > 0 {
+			libraryInfo.Chapters = result.Chapters
+		}
+		downloadedPath := result.FilePath
+		result.FilePath = a.addToLibrary(libraryInfo, job.URL, result, nil)
+		if result.FilePath != downloadedPath {
+			_ = os.RemoveAll(destDir)
+		}
+	}
+	return result, nil
+}
+
+// QueueItem is a video the frontend wants added to the download queue
+type QueueItem struct {
+	URL   string `json:"url"`
+	Title string `json:"title"` // Optional; defaults to the video ID
+}
+
+// EnqueueVideos adds one or more videos to the download queue
+func (a *App) EnqueueVideos(items []QueueItem) ([]queue.Job, error) {
+	if a.queue == nil {
+		return nil, fmt.Errorf("download queue not available")
+	}
+
+	jobs := make([]queue.Job, 0, len(items))
+	for _, item := range items {
+		videoID, err := youtube.ExtractVideoID(item.URL)
+		if err != nil {
+			return jobs, err
+		}
+		title := item.Title
+		if title == "" {
+			title = videoID
+		}
+		job, err := a.queue.Enqueue(item.URL, title)
+		if err != nil {
+			return jobs, fmt.Errorf("failed to enqueue video: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// ListJobs returns every job in the download queue, in order
+func (a *App) ListJobs() []queue.Job {
+	if a.queue == nil {
+		return []queue.Job{}
+	}
+	return a.queue.List()
+}
+
+// MoveJob moves a job to a new position in the download queue
+func (a *App) MoveJob(id string, index int) error {
+	if a.queue == nil {
+		return fmt.Errorf("download queue not available")
+	}
+	return a.queue.Move(id, index)
+}
+
+// CancelJob stops a queued or running download
+func (a *App) CancelJob(id string) error {
+	if a.queue == nil {
+		return fmt.Errorf("download queue not available")
+	}
+	return a.queue.Cancel(id)
+}
+
+// RetryJob re-queues a failed or cancelled download, resuming any partial data
+func (a *App) RetryJob(id string) error {
+	if a.queue == nil {
+		return fmt.Errorf("download queue not available")
+	}
+	return a.queue.Retry(id)
+}
+
+// RemoveJob deletes a finished or queued job and any file it downloaded
+func (a *App) RemoveJob(id string) error {
+	if a.queue == nil {
+		return fmt.Errorf("download queue not available")
+	}
+	if err := a.queue.Remove(id); err != nil {
+		return err
+	}
+	if a.downloadsDir != "" {
+		_ = os.RemoveAll(filepath.Join(a.downloadsDir, id))
+	}
+	return nil
+}
+
+// GetMaxConcurrentDownloads returns how many queued downloads run at once
+func (a *App) GetMaxConcurrentDownloads() int {
+	if a.queue == nil {
+		return queue.DefaultConcurrency
+	}
+	return a.queue.Concurrency()
+}
+
+// SetMaxConcurrentDownloads changes how many queued downloads run at once
+func (a *App) SetMaxConcurrentDownloads(n int) error {
+	if a.queue == nil {
+		return fmt.Errorf("download queue not available")
+	}
+	if err := a.queue.SetConcurrency(n); err != nil {
+		return err
+	}
+	a.downloader.SetDownloadSlots(a.queue.Concurrency() + 1)
+	return nil
+}
+
+// ScheduleStatus describes the download schedule and whether it is holding jobs back
+type ScheduleStatus struct {
+	Schedule queue.Schedule `json:"schedule"`
+	Paused   bool           `json:"paused"`
+	ResumeAt string         `json:"resumeAt,omitempty"` // Local time as "HH:MM", while paused
+}
+
+// GetSchedule returns when queued downloads may run
+func (a *App) GetSchedule() ScheduleStatus {
+	if a.queue == nil {
+		return ScheduleStatus{}
+	}
+	status := ScheduleStatus{Schedule: a.queue.Schedule()}
+	paused, resumeAt := a.queue.Paused()
+	status.Paused = paused
+	if paused && !resumeAt.IsZero() {
+		status.ResumeAt = resumeAt.Format("15:04")
+	}
+	return status
+}
+
+// SetSchedule limits queued downloads to a daily window, e.g. 18:00 to 07:00. Running
+// downloads pause when it closes and resume when it opens.
+func (a *App) SetSchedule(s queue.Schedule) (ScheduleStatus, error) {
+	if a.queue == nil {
+		return ScheduleStatus{}, fmt.Errorf("download queue not available")
+	}
+	if err := a.queue.SetSchedule(s); err != nil {
+		return ScheduleStatus{}, err
+	}
+	return a.GetSchedule(), nil
+}
+
+// OpenJob loads a completed download into the editor
+func (a *App) OpenJob(id string) (*VideoInfo, error) {
+	if a.queue == nil {
+		return nil, fmt.Errorf("download queue not available")
+	}
+	if a.previewBaseURL == "" {
+		return nil, fmt.Errorf("preview server not available")
+	}
+
+	job, err := a.queue.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != queue.StateDone || job.Result == nil {
+		return nil, fmt.Errorf("job has not finished downloading")
+	}
+	if entry, ok := a.findInLibrary(job.URL, nil, false); ok {
+		return a.openLibraryEntry(entry, nil)
+	}
+	if _, err := os.Stat(job.Result.FilePath); err != nil {
+		return nil, fmt.Errorf("downloaded file is missing: %w", err)
+	}
+
+	ffmpegPath, ytdlpPath := a.toolPaths("")
+	info, err := a.downloader.ResolveVideo(a.ctx, job.URL, youtube.Tools{FFmpegPath: ffmpegPath, YtdlpPath: ytdlpPath})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	a.currentVideoID = info.ID
+	a.currentOffset = 0
+	a.currentAudioOnly = false
+	a.setCurrentSource(info, job.URL)
+	a.videoServer.SetCurrentVideo(job.Result.FilePath, info.ID)
+	logInfo(a.ctx, fmt.Sprintf("Opened queued download %s: %s", job.ID, job.Result.FilePath))
+
+	chapters := info.Chapters
+	if len(job.Result.Chapters) > 0 {
+		chapters = job.Result.Chapters
+	}
+
+	return &VideoInfo{
+		ID:           info.ID,
+		Title:        info.Title,
+		Author:       info.Author,
+		Duration:     info.Duration,
+		Thumbnail:    a.cacheThumbnail(info, job.Result.FilePath),
+		VideoURL:     a.previewBaseURL + a.videoServer.GetCurrentVideoURL(),
+		SourceWidth:  info.SourceWidth,
+		SourceHeight: info.SourceHeight,
+		Chapters:     a.setCurrentChapters(chapters, 0, info.Duration),
+	}, nil
+}
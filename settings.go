@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"yt-downloader/internal/network"
+	"yt-downloader/internal/youtube"
+)
+
+// settings are user preferences persisted in the app data directory
+type settings struct {
+	LibraryDir     string                 `json:"libraryDir,omitempty"`
+	QualityCeiling youtube.QualityCeiling `json:"qualityCeiling"`
+	Strategy       []string               `json:"strategy,omitempty"` // Download source order; empty for the default
+	Network        network.Settings       `json:"network"`
+	RateLimit      int64                  `json:"rateLimit,omitempty"` // Bytes per second for all downloads; 0 for none
+}
+
+// loadSettings reads the settings file, returning defaults if it doesn't exist yet
+func loadSettings(path string) (settings, error) {
+	var s settings
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	return s, nil
+}
+
+// saveSettings writes the settings file atomically. Callers must hold a.settingsMu.
+func (a *App) saveSettings() error {
+	if a.settingsPath == "" {
+		return fmt.Errorf("app data directory not available")
+	}
+	data, err := json.MarshalIndent(a.settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(a.settingsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	tmp := a.settingsPath + ".tmp"
+	// Private to the user, since the proxy password is stored here
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, a.settingsPath); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
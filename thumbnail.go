@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"yt-downloader/internal/youtube"
+)
+
+// thumbnailExts are the image types a cached thumbnail may have been saved as
+var thumbnailExts = []string{".jpg", ".webp", ".png"}
+
+// cacheThumbnail makes sure a video has a local thumbnail and returns the URL the
+// frontend should show. The library copy is used if there is one; otherwise the
+// thumbnail is fetched into the cache, or a frame of videoPath is extracted when
+// the fetch fails. The remote URL is returned only if all of that fails.
+func (a *App) cacheThumbnail(info *youtube.VideoInfo, videoPath string) string {
+	path := a.findThumbnail(info.ID)
+	if path == "" && a.thumbsDir != "" {
+		fetched, err := a.downloader.FetchThumbnail(a.ctx, info.ID, info.Thumbnail, a.thumbsDir)
+		if err == nil {
+			path = fetched
+		} else {
+			logWarning(a.ctx, fmt.Sprintf("Failed to fetch thumbnail for %s, extracting a frame instead: %v", info.ID, err))
+			framePath := filepath.Join(a.thumbsDir, info.ID+".jpg")
+			ffmpegPath := ""
+			if a.ffmpegInstaller != nil && a.ffmpegInstaller.IsInstalled() {
+				ffmpegPath = a.ffmpegInstaller.GetFFmpegPath()
+			}
+			if err := youtube.ExtractThumbnail(a.ctx, ffmpegPath, videoPath, framePath); err != nil {
+				logWarning(a.ctx, fmt.Sprintf("Failed to extract thumbnail for %s: %v", info.ID, err))
+			} else {
+				path = framePath
+			}
+		}
+	}
+	if path == "" {
+		return info.Thumbnail
+	}
+
+	// Keep a copy with the library entry so the library stays self-contained
+	if lib := a.getLibrary(); lib != nil {
+		if entry, err := lib.Get(info.ID); err == nil && entry.Thumbnail == "" {
+			if entry, err := lib.SetThumbnail(info.ID, path); err == nil {
+				path = lib.Path(entry.Thumbnail)
+			}
+		}
+	}
+
+	a.videoServer.SetThumbnail(info.ID, path)
+	return a.previewBaseURL + a.videoServer.GetThumbnailURL(info.ID)
+}
+
+// findThumbnail returns an existing local thumbnail for a video, from the library or the cache
+func (a *App) findThumbnail(videoID string) string {
+	if lib := a.getLibrary(); lib != nil {
+		if entry, err := lib.Get(videoID); err == nil && entry.Thumbnail != "" {
+			return lib.Path(entry.Thumbnail)
+		}
+	}
+	if a.thumbsDir == "" {
+		return ""
+	}
+	for _, ext := range thumbnailExts {
+		path := filepath.Join(a.thumbsDir, videoID+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
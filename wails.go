@@ -0,0 +1,13 @@
+package main
+
+import "github.com/wailsapp/wails/v2/pkg/runtime"
+
+// The Wails runtime functions the App calls with its context. They only work with the
+// context Wails passes to startup, so they are variables that tests can replace to run
+// the App without a window.
+var (
+	emitEvent  = runtime.EventsEmit
+	logInfo    = runtime.LogInfo
+	logWarning = runtime.LogWarning
+	logError   = runtime.LogError
+)